@@ -304,13 +304,18 @@ func (fc *FormatConfig) FormatColumn(value spanner.GenericColumnValue, toplevel
 // extract the wire list value (non-list payloads are
 // [ErrUnexpectedComplexValueKind]), recurse into each element with
 // formatter.FormatColumn(elem, false), and hand the element strings to join.
+// Element failures are reported as a [*PathError] locating the element.
 func formatArrayElems(formatter Formatter, value spanner.GenericColumnValue, toplevel bool, join FormatArrayFunc) (string, error) {
 	listValue, err := getComplexListValue(sppb.TypeCode_ARRAY, value.Value)
 	if err != nil {
 		return "", err
 	}
-	elemStrings, err := lo.MapErr(listValue.GetValues(), func(v *structpb.Value, _ int) (string, error) {
-		return formatter.FormatColumn(typeValueToGCV(value.Type.GetArrayElementType(), v), false)
+	elemStrings, err := lo.MapErr(listValue.GetValues(), func(v *structpb.Value, i int) (string, error) {
+		s, err := formatter.FormatColumn(typeValueToGCV(value.Type.GetArrayElementType(), v), false)
+		if err != nil {
+			return "", prependPathElem(IndexElem(i), err)
+		}
+		return s, nil
 	})
 	if err != nil {
 		return "", err
@@ -322,7 +327,8 @@ func formatArrayElems(formatter Formatter, value spanner.GenericColumnValue, top
 // extract the wire list value (non-list payloads are
 // [ErrUnexpectedComplexValueKind]), check the value count against the field
 // descriptors ([ErrMismatchedFields]), format each field with the field
-// callback, and hand the field strings to paren.
+// callback, and hand the field strings to paren. Field failures are reported
+// as a [*PathError] locating the field.
 func formatStructFields(formatter Formatter, value spanner.GenericColumnValue, toplevel bool, field FormatStructFieldFunc, paren FormatStructParenFunc) (string, error) {
 	listValue, err := getComplexListValue(sppb.TypeCode_STRUCT, value.Value)
 	if err != nil {
@@ -334,7 +340,11 @@ func formatStructFields(formatter Formatter, value spanner.GenericColumnValue, t
 		return "", fmt.Errorf("%w: got %d values, want %d", ErrMismatchedFields, len(fieldValues), len(fields))
 	}
 	fieldStrings, err := lo.MapErr(fields, func(f *sppb.StructType_Field, i int) (string, error) {
-		return field(formatter, f, fieldValues[i])
+		s, err := field(formatter, f, fieldValues[i])
+		if err != nil {
+			return "", prependPathElem(structFieldElem(fields, i), err)
+		}
+		return s, nil
 	})
 	if err != nil {
 		return "", err
@@ -53,7 +53,8 @@
 //
 //   - Native [*spanner.RowIterator] export
 //     ([github.com/apstndb/spanvalue/writer.WriteRowIterator]).
-//   - String → GCV parsing, PostgreSQL table cells, or built-in ASCII table layout.
+//   - String → GCV parsing or PostgreSQL table cells. For a text table, pass a
+//     [github.com/apstndb/spanvalue/writer.TableWriter] as the [GCVStreamWriter].
 //   - Batch orchestration, SQL INSERT export, or owning db.QueryContext / driver ExecOptions.
 //
 // # API overview
@@ -96,8 +96,9 @@ type SQLRowsResult struct {
 // When ReadResultSetStats is false (the default), rows remain on the data
 // result set after export so the caller can advance to stats separately.
 //
-// For custom sinks (for example ASCII table rendering), use [RunRows] with
-// [SQLRowsHooks] instead.
+// For custom sinks, use [RunRows] with [SQLRowsHooks] instead. Text tables
+// do not need one: [github.com/apstndb/spanvalue/writer.TableWriter] is a
+// GCVStreamWriter.
 func WriteRows(rows *sql.Rows, w GCVStreamWriter, cfg SQLRowsConfig) (*SQLRowsResult, error) {
 	if rows == nil {
 		return nil, ErrNilRows
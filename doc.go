@@ -62,10 +62,27 @@
 // [FormatBracketStruct], [FormatUntypedArray], [FormatOptionallyTypedArray],
 // [FormatCompactArray], and [NewJSONObjectStructFormatter].
 //
+// # Walking nested values
+//
+// [Walk] visits a value and every ARRAY element and STRUCT field nested in
+// it as typed [cloud.google.com/go/spanner.GenericColumnValue] children, each
+// located by a [Path]. [Transform] rebuilds a value from per-node
+// replacements and keeps ARRAY and STRUCT types consistent with the
+// transformed children. Both accept [ErrSkipSubtree] to prune and report
+// failures as [*PathError].
+//
+// [ParsePath] reads the same syntax [Path.String] prints (`items[2].sku`,
+// with `[*]` for every element); [Get] extracts the addressed values and
+// [Set] returns a copy with them replaced. Formatting errors inside ARRAY
+// and STRUCT values are also [*PathError], so the failing element can be
+// fetched back with [Get].
+//
 // # Related packages
 //
 // To build [cloud.google.com/go/spanner.GenericColumnValue] values from Go types, see
-// [github.com/apstndb/spanvalue/gcvctor]. For streaming row export, see
+// [github.com/apstndb/spanvalue/gcvctor]; for random values of any type in property
+// tests, see [github.com/apstndb/spanvalue/gcvgen]. For streaming row export, see
 // [github.com/apstndb/spanvalue/writer]. For opt-in descriptor-aware PROTO and ENUM
-// display plugins, see [github.com/apstndb/spanvalue/protofmt].
+// display plugins, see [github.com/apstndb/spanvalue/protofmt]. To encode rows as protobuf
+// messages with a generated descriptor, see [github.com/apstndb/spanvalue/rowproto].
 package spanvalue
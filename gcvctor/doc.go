@@ -73,10 +73,22 @@
 // formatters treat NUMERIC string payloads as authoritative and do not parse them again.
 //
 // Formatting these values as strings is provided by the sibling package
-// [github.com/apstndb/spanvalue]. For converting arbitrary Go values with the official
-// Cloud Spanner Go client's encoding semantics (struct tags, null wrappers, Encoder), see
+// [github.com/apstndb/spanvalue].
+//
+// # Native Go values
+//
+// [FromGo] converts an arbitrary Go value with the official Cloud Spanner Go client's
+// parameter encoding (struct tags, null wrappers, slices, [cloud.google.com/go/spanner.Encoder],
+// protobuf messages and enums), inferring the type; nil pointers become typed NULLs. [ToGo]
+// goes the other way, returning JSON- and template-friendly Go values with STRUCT as the
+// ordered [StructValue]. For a standalone encoder with the same semantics, see
 // [github.com/apstndb/spanenc].
 //
+// [StructValueFromGo] builds a STRUCT from a `spanner:"name"`-tagged Go struct, recursing
+// into nested structs and slices of structs and honoring `spanner:"-"`. [RowValuesFromGo]
+// and [RowFromGo] turn the same struct into a row type with column values or a
+// [cloud.google.com/go/spanner.Row], so fixtures and package writer can take domain types.
+//
 // # Test fixtures
 //
 // For nested ARRAY and STRUCT trees in tests, prefer [MustArrayValue], [MustArrayValueOf],
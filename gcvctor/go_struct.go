@@ -0,0 +1,203 @@
+package gcvctor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	// ErrNotGoStruct is returned by [StructValueFromGo], [RowValuesFromGo], and
+	// [RowFromGo] when the Go value is not a struct or pointer to struct, and
+	// by the row builders for a nil pointer.
+	ErrNotGoStruct = errors.New("gcvctor: not a Go struct")
+	// ErrEmbeddedGoStructField is returned by [StructValueFromGo] and the row
+	// builders for an embedded struct field, which the Spanner client rejects.
+	ErrEmbeddedGoStructField = errors.New("gcvctor: embedded struct fields are not supported")
+)
+
+// StructValueFromGo converts a Go struct, or pointer to struct, to a STRUCT
+// value. Exported fields become STRUCT fields in declaration order, named by
+// their `spanner:"name"` tag or else the Go field name; `spanner:"-"` fields
+// are skipped, as when the client reads rows into structs.
+//
+// Fields are converted recursively: nested structs become STRUCT, slices of
+// structs become ARRAY<STRUCT>, nil struct pointers and nil slices become
+// typed NULLs (a nil pointer v yields a NULL STRUCT), and every other field
+// goes through [FromGo]. Structs the client encodes as scalars, such as
+// [time.Time], [math/big.Rat], and [cloud.google.com/go/spanner.NullString],
+// are not STRUCTs; passing one as v returns [ErrNotGoStruct]. Field errors are
+// reported as [*StructFieldError] and [*ArrayElementError].
+func StructValueFromGo(v any) (spanner.GenericColumnValue, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return spanner.GenericColumnValue{}, ErrUntypedNil
+	}
+	t := rv.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !isGoStructType(t) {
+		return spanner.GenericColumnValue{}, fmt.Errorf("%w: %T", ErrNotGoStruct, v)
+	}
+	return goStructValue(rv)
+}
+
+// RowValuesFromGo converts a Go struct, or non-nil pointer to struct, to one
+// row: the row type and its top-level column values. Fields convert as in
+// [StructValueFromGo].
+func RowValuesFromGo(v any) (*sppb.StructType, []spanner.GenericColumnValue, error) {
+	gcv, err := StructValueFromGo(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isNullWire(gcv.Value) {
+		return nil, nil, fmt.Errorf("%w: nil %T cannot be a row", ErrNotGoStruct, v)
+	}
+	rowType := gcv.Type.GetStructType()
+	values := gcv.Value.GetListValue().GetValues()
+	gcvs := make([]spanner.GenericColumnValue, len(values))
+	for i, field := range rowType.GetFields() {
+		gcvs[i] = spanner.GenericColumnValue{Type: field.GetType(), Value: values[i]}
+	}
+	return rowType, gcvs, nil
+}
+
+// RowFromGo is [RowValuesFromGo] returning a [*cloud.google.com/go/spanner.Row]
+// for APIs that consume rows, such as package writer.
+func RowFromGo(v any) (*spanner.Row, error) {
+	rowType, gcvs, err := RowValuesFromGo(v)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(gcvs))
+	values := make([]any, len(gcvs))
+	for i, field := range rowType.GetFields() {
+		names[i] = field.GetName()
+		values[i] = gcvs[i]
+	}
+	return spanner.NewRow(names, values)
+}
+
+// goStructKinds caches isGoStructType by reflect.Type.
+var goStructKinds sync.Map
+
+// isGoStructType reports whether t is a struct type converted field by field
+// rather than a struct the client encodes as a scalar. A struct counts as a
+// scalar when the client successfully encodes its zero value, or a pointer
+// to it, as something other than STRUCT.
+func isGoStructType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if cached, ok := goStructKinds.Load(t); ok {
+		return cached.(bool)
+	}
+	isStruct := true
+	for _, zero := range []any{reflect.Zero(t).Interface(), reflect.New(t).Interface()} {
+		if gcv, err := FromGo(zero); err == nil && gcv.Type.GetCode() != sppb.TypeCode_STRUCT {
+			isStruct = false
+			break
+		}
+	}
+	goStructKinds.Store(t, isStruct)
+	return isStruct
+}
+
+// goStructValue converts rv, a struct or pointer to struct.
+func goStructValue(rv reflect.Value) (spanner.GenericColumnValue, error) {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			typ, err := goStructType(rv.Type().Elem())
+			if err != nil {
+				return spanner.GenericColumnValue{}, err
+			}
+			return NullOf(typ), nil
+		}
+		rv = rv.Elem()
+	}
+	t := rv.Type()
+	var names []string
+	var gcvs []spanner.GenericColumnValue
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, ok := sf.Tag.Lookup("spanner")
+		switch {
+		case sf.Anonymous:
+			return spanner.GenericColumnValue{}, wrapStructFieldError(len(names), sf.Name, ErrEmbeddedGoStructField)
+		case !sf.IsExported(), name == "-":
+			continue
+		case !ok:
+			name = sf.Name
+		}
+		gcv, err := goFieldValue(rv.Field(i))
+		if err != nil {
+			return spanner.GenericColumnValue{}, wrapStructFieldError(len(names), name, err)
+		}
+		names = append(names, name)
+		gcvs = append(gcvs, gcv)
+	}
+	if len(gcvs) == 0 {
+		// StructValueOf infers nothing from zero fields; build the empty STRUCT directly.
+		return spanner.GenericColumnValue{
+			Type:  typector.StructTypeFieldsToStructType(nil),
+			Value: structpb.NewListValue(&structpb.ListValue{}),
+		}, nil
+	}
+	return StructValueOf(names, gcvs)
+}
+
+// goStructType returns the STRUCT type of struct type t.
+func goStructType(t reflect.Type) (*sppb.Type, error) {
+	gcv, err := goStructValue(reflect.New(t).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return gcv.Type, nil
+}
+
+// goFieldValue converts one struct field, recursing into struct-shaped
+// values and delegating everything else to FromGo.
+func goFieldValue(rv reflect.Value) (spanner.GenericColumnValue, error) {
+	t := rv.Type()
+	switch {
+	case isGoStructType(t), t.Kind() == reflect.Pointer && isGoStructType(t.Elem()):
+		return goStructValue(rv)
+	case t.Kind() == reflect.Slice && goStructElem(t.Elem()) != nil:
+		elemType, err := goStructType(goStructElem(t.Elem()))
+		if err != nil {
+			return spanner.GenericColumnValue{}, err
+		}
+		if rv.IsNil() {
+			return NullArrayOf(elemType), nil
+		}
+		elems := make([]spanner.GenericColumnValue, rv.Len())
+		for i := range elems {
+			elem, err := goStructValue(rv.Index(i))
+			if err != nil {
+				return spanner.GenericColumnValue{}, wrapArrayElementError(i, err)
+			}
+			elems[i] = elem
+		}
+		return ArrayValueOf(elemType, elems...)
+	}
+	return FromGo(rv.Interface())
+}
+
+// goStructElem returns the struct type of a slice element type that is a
+// struct or pointer to struct, or nil.
+func goStructElem(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !isGoStructType(t) {
+		return nil
+	}
+	return t
+}
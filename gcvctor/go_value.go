@@ -0,0 +1,215 @@
+package gcvctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	// ErrUnsupportedGoValue is returned by [FromGo] when the Spanner client
+	// cannot encode the Go value.
+	ErrUnsupportedGoValue = errors.New("gcvctor: unsupported Go value")
+	// ErrUntypedNil is returned by [FromGo] for an untyped nil, whose SQL type
+	// cannot be inferred. Pass a nil pointer or a Null* wrapper instead.
+	ErrUntypedNil = errors.New("gcvctor: cannot infer type of untyped nil")
+)
+
+// FromGo converts v with the Spanner client's query parameter encoding, so it
+// accepts exactly what [cloud.google.com/go/spanner.Statement] Params accept:
+// Go scalars and their pointers, [cloud.google.com/go/spanner.NullInt64] and
+// the other Null* wrappers, slices, spanner-tagged Go structs and slices of
+// them (as STRUCT and ARRAY<STRUCT>), [cloud.google.com/go/spanner.Encoder]
+// implementations, protobuf messages and enums, and
+// [cloud.google.com/go/spanner.GenericColumnValue] as-is.
+//
+// The type is inferred from the Go type, so a nil pointer or an invalid Null*
+// wrapper yields a typed NULL (a nil *int64 is a NULL INT64). An untyped nil
+// returns [ErrUntypedNil]; values the client rejects return
+// [ErrUnsupportedGoValue] wrapping the client error.
+func FromGo(v any) (spanner.GenericColumnValue, error) {
+	if v == nil {
+		return spanner.GenericColumnValue{}, ErrUntypedNil
+	}
+	// spanner.NewRow is the exported entry point to the client's encodeValue.
+	row, err := spanner.NewRow([]string{""}, []any{v})
+	if err != nil {
+		return spanner.GenericColumnValue{}, fmt.Errorf("%w %T: %w", ErrUnsupportedGoValue, v, err)
+	}
+	var gcv spanner.GenericColumnValue
+	if err := row.Column(0, &gcv); err != nil {
+		return spanner.GenericColumnValue{}, fmt.Errorf("%w %T: %w", ErrUnsupportedGoValue, v, err)
+	}
+	if gcv.Type == nil {
+		return spanner.GenericColumnValue{}, fmt.Errorf("%w %T", ErrUntypedNil, v)
+	}
+	return gcv, nil
+}
+
+// StructField is one field of a [StructValue].
+type StructField struct {
+	// Name is the field name; empty for unnamed fields.
+	Name  string
+	Value any
+}
+
+// StructValue is the [ToGo] form of a STRUCT: its fields in declaration
+// order. Unlike a Go map it keeps order, unnamed fields, and duplicate names.
+type StructValue []StructField
+
+// Get returns the value of the first field named name and whether one
+// exists. Templates can call it as {{.Get "name"}}.
+func (s StructValue) Get(name string) (any, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON encodes s as a JSON object with keys in field order. Unnamed
+// fields use the empty key, and duplicate names are emitted as-is.
+func (s StructValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("struct field %d (%q): %w", i, f.Name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ToGo converts gcv to a native Go value that works with text/template and
+// encoding/json. SQL NULL (at any depth) becomes nil; otherwise:
+//
+//   - BOOL: bool; INT64 and ENUM: int64; FLOAT64: float64; FLOAT32: float32
+//   - STRING: string; BYTES and PROTO: []byte
+//   - NUMERIC: [*big.Rat] (PG NUMERIC NaN stays the string "NaN")
+//   - TIMESTAMP: [time.Time]; DATE: [cloud.google.com/go/civil.Date]
+//   - INTERVAL: [cloud.google.com/go/spanner.Interval]; UUID: [github.com/google/uuid.UUID]
+//   - JSON: the decoded document (map[string]any, []any, [encoding/json.Number], ...)
+//   - ARRAY: []any; STRUCT: [StructValue]
+//
+// ToGo does not validate: a wire value that does not decode as its type is
+// returned in its raw protobuf form (usually the wire string).
+func ToGo(gcv spanner.GenericColumnValue) any {
+	if isNullWire(gcv.Value) {
+		return nil
+	}
+	switch gcv.Type.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		if gcv.Value.GetListValue() == nil {
+			break
+		}
+		values := gcv.Value.GetListValue().GetValues()
+		elemType := gcv.Type.GetArrayElementType()
+		out := make([]any, len(values))
+		for i, v := range values {
+			out[i] = ToGo(spanner.GenericColumnValue{Type: elemType, Value: v})
+		}
+		return out
+	case sppb.TypeCode_STRUCT:
+		fields := gcv.Type.GetStructType().GetFields()
+		values := gcv.Value.GetListValue().GetValues()
+		if gcv.Value.GetListValue() == nil || len(values) != len(fields) {
+			break
+		}
+		out := make(StructValue, len(fields))
+		for i, f := range fields {
+			out[i] = StructField{Name: f.GetName(), Value: ToGo(spanner.GenericColumnValue{Type: f.GetType(), Value: values[i]})}
+		}
+		return out
+	case sppb.TypeCode_JSON:
+		dec := json.NewDecoder(bytes.NewReader([]byte(gcv.Value.GetStringValue())))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err == nil {
+			return v
+		}
+	case sppb.TypeCode_NUMERIC:
+		if gcv.Type.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			if r, ok := new(big.Rat).SetString(gcv.Value.GetStringValue()); ok {
+				return r
+			}
+			break
+		}
+		if r := new(big.Rat); decodeScalar(gcv, r) {
+			return r
+		}
+	case sppb.TypeCode_BOOL:
+		return decodeOrRaw[bool](gcv)
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return decodeOrRaw[int64](gcv)
+	case sppb.TypeCode_FLOAT64:
+		return decodeOrRaw[float64](gcv)
+	case sppb.TypeCode_FLOAT32:
+		return decodeOrRaw[float32](gcv)
+	case sppb.TypeCode_STRING:
+		return gcv.Value.GetStringValue()
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return decodeOrRaw[[]byte](gcv)
+	case sppb.TypeCode_TIMESTAMP:
+		return decodeOrRaw[time.Time](gcv)
+	case sppb.TypeCode_DATE:
+		return decodeOrRaw[civil.Date](gcv)
+	case sppb.TypeCode_INTERVAL:
+		return decodeOrRaw[spanner.Interval](gcv)
+	case sppb.TypeCode_UUID:
+		return decodeOrRaw[uuid.UUID](gcv)
+	}
+	return gcv.Value.AsInterface()
+}
+
+// decodeOrRaw decodes gcv into a T with the Spanner client, falling back to
+// the raw protobuf form when it does not decode.
+func decodeOrRaw[T any](gcv spanner.GenericColumnValue) any {
+	var v T
+	if !decodeScalar(gcv, &v) {
+		return gcv.Value.AsInterface()
+	}
+	return v
+}
+
+// decodeScalar decodes gcv into ptr. The client does not decode ENUM and
+// PROTO into plain Go types, so those decode as INT64 and BYTES.
+func decodeScalar(gcv spanner.GenericColumnValue, ptr any) bool {
+	switch gcv.Type.GetCode() {
+	case sppb.TypeCode_ENUM:
+		gcv.Type = &sppb.Type{Code: sppb.TypeCode_INT64}
+	case sppb.TypeCode_PROTO:
+		gcv.Type = &sppb.Type{Code: sppb.TypeCode_BYTES}
+	}
+	return gcv.Decode(ptr) == nil
+}
+
+// isNullWire reports whether v is a protobuf NULL; a nil Value counts as NULL.
+func isNullWire(v *structpb.Value) bool {
+	if v == nil {
+		return true
+	}
+	_, ok := v.GetKind().(*structpb.Value_NullValue)
+	return ok
+}
@@ -0,0 +1,226 @@
+package gcvctor_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+type goValueItem struct {
+	SKU string `spanner:"sku"`
+	Qty int64
+}
+
+type upperEncoder string
+
+func (u upperEncoder) EncodeSpanner() (any, error) { return "<" + string(u) + ">", nil }
+
+func TestFromGo(t *testing.T) {
+	t.Parallel()
+
+	str := "x"
+	itemType := typector.MustNameCodeSlicesToStructType([]string{"sku", "Qty"}, []sppb.TypeCode{sppb.TypeCode_STRING, sppb.TypeCode_INT64})
+	tests := []struct {
+		name string
+		in   any
+		want spanner.GenericColumnValue
+	}{
+		{"int", 7, gcvctor.Int64Value(7)},
+		{"string pointer", &str, gcvctor.StringValue("x")},
+		{"nil pointer", (*int64)(nil), gcvctor.NullFromCode(sppb.TypeCode_INT64)},
+		{"invalid NullString", spanner.NullString{}, gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+		{"NullDate", spanner.NullDate{Date: civil.Date{Year: 2024, Month: 1, Day: 2}, Valid: true}, gcvctor.DateValue(civil.Date{Year: 2024, Month: 1, Day: 2})},
+		{"slice", []int64{1, 2}, gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.Int64Value(2))},
+		{"nil slice", []string(nil), gcvctor.NullArrayFromCode(sppb.TypeCode_STRING)},
+		{"struct", goValueItem{SKU: "a", Qty: 1}, gcvctor.MustStructValueOf([]string{"sku", "Qty"}, []spanner.GenericColumnValue{
+			gcvctor.StringValue("a"), gcvctor.Int64Value(1),
+		})},
+		{"nil struct pointer", (*goValueItem)(nil), gcvctor.NullOf(itemType)},
+		{"encoder", upperEncoder("a"), gcvctor.StringValue("<a>")},
+		{"GenericColumnValue", gcvctor.BoolValue(true), gcvctor.BoolValue(true)},
+	}
+	for _, tt := range tests {
+		got, err := gcvctor.FromGo(tt.in)
+		if err != nil {
+			t.Errorf("%s: FromGo: %v", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("%s: FromGo mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	if _, err := gcvctor.FromGo(nil); !errors.Is(err, gcvctor.ErrUntypedNil) {
+		t.Errorf("FromGo(nil) error = %v, want ErrUntypedNil", err)
+	}
+	if _, err := gcvctor.FromGo(map[string]int{}); !errors.Is(err, gcvctor.ErrUnsupportedGoValue) {
+		t.Errorf("FromGo(map) error = %v, want ErrUnsupportedGoValue", err)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		name string
+		in   spanner.GenericColumnValue
+		want any
+	}{
+		{"NULL", gcvctor.NullFromCode(sppb.TypeCode_INT64), nil},
+		{"INT64", gcvctor.Int64Value(-3), int64(-3)},
+		{"ENUM", gcvctor.EnumValue("examples.Color", 2), int64(2)},
+		{"FLOAT64", gcvctor.Float64Value(1.5), 1.5},
+		{"FLOAT32", gcvctor.Float32Value(0.5), float32(0.5)},
+		{"BYTES", gcvctor.BytesValue([]byte("hi")), []byte("hi")},
+		{"PROTO", gcvctor.ProtoValue("examples.Msg", []byte{1}), []byte{1}},
+		{"TIMESTAMP", gcvctor.TimestampValue(ts), ts},
+		{"DATE", gcvctor.DateValue(civil.Date{Year: 2024, Month: 1, Day: 2}), civil.Date{Year: 2024, Month: 1, Day: 2}},
+		{"JSON", gcvctor.MustJSONStringValue(`{"a":[1,2.5]}`), map[string]any{"a": []any{json.Number("1"), json.Number("2.5")}}},
+		{"NUMERIC", gcvctor.StringBasedValueOf(typector.CodeToSimpleType(sppb.TypeCode_NUMERIC), "1.5"), big.NewRat(3, 2)},
+		{"malformed INT64", spanner.GenericColumnValue{Type: typector.CodeToSimpleType(sppb.TypeCode_INT64), Value: structpb.NewStringValue("x")}, "x"},
+		{"ARRAY", gcvctor.MustArrayValueOf(typector.CodeToSimpleType(sppb.TypeCode_STRING), gcvctor.StringValue("a"), gcvctor.NullFromCode(sppb.TypeCode_STRING)), []any{"a", nil}},
+		{"STRUCT", gcvctor.MustStructValueOf([]string{"id", ""}, []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.BoolValue(true)}),
+			gcvctor.StructValue{{Name: "id", Value: int64(1)}, {Name: "", Value: true}}},
+	}
+	for _, tt := range tests {
+		got := gcvctor.ToGo(tt.in)
+		if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 })); diff != "" {
+			t.Errorf("%s: ToGo mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	pgNaN := gcvctor.StringBasedValueOf(typector.CodeToSimpleType(sppb.TypeCode_NUMERIC), "NaN")
+	pgNaN.Type.TypeAnnotation = sppb.TypeAnnotationCode_PG_NUMERIC
+	if got := gcvctor.ToGo(pgNaN); got != "NaN" {
+		t.Errorf("ToGo(PG NUMERIC NaN) = %#v, want \"NaN\"", got)
+	}
+}
+
+func TestStructValueJSONAndRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in, err := gcvctor.FromGo([]goValueItem{{SKU: "a", Qty: 1}, {SKU: "b", Qty: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(gcvctor.ToGo(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `[{"sku":"a","Qty":1},{"sku":"b","Qty":2}]`; got != want {
+		t.Errorf("json.Marshal(ToGo) = %s, want %s", got, want)
+	}
+
+	sv := gcvctor.ToGo(in).([]any)[1].(gcvctor.StructValue)
+	if v, ok := sv.Get("Qty"); !ok || v != int64(2) {
+		t.Errorf("Get(Qty) = (%v, %v), want (2, true)", v, ok)
+	}
+	if _, ok := sv.Get("missing"); ok {
+		t.Error("Get(missing) found a field")
+	}
+}
+
+type goValueOrder struct {
+	ID       int64         `spanner:"id"`
+	Customer *goValueItem  `spanner:"customer"`
+	Items    []goValueItem `spanner:"items"`
+	Internal string        `spanner:"-"`
+}
+
+func TestStructValueFromGo(t *testing.T) {
+	t.Parallel()
+
+	itemType := typector.MustNameCodeSlicesToStructType([]string{"sku", "Qty"}, []sppb.TypeCode{sppb.TypeCode_STRING, sppb.TypeCode_INT64})
+	item := func(sku string, qty int64) spanner.GenericColumnValue {
+		return gcvctor.MustStructValueOf([]string{"sku", "Qty"}, []spanner.GenericColumnValue{gcvctor.StringValue(sku), gcvctor.Int64Value(qty)})
+	}
+	got, err := gcvctor.StructValueFromGo(&goValueOrder{ID: 1, Items: []goValueItem{{SKU: "a", Qty: 2}}, Internal: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gcvctor.MustStructValueOf([]string{"id", "customer", "items"}, []spanner.GenericColumnValue{
+		gcvctor.Int64Value(1),
+		gcvctor.NullOf(itemType),
+		gcvctor.MustArrayValueOf(itemType, item("a", 2)),
+	})
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("StructValueFromGo mismatch (-want +got):\n%s", diff)
+	}
+
+	null, err := gcvctor.StructValueFromGo((*goValueOrder)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(gcvctor.NullOf(want.Type), null, protocmp.Transform()); diff != "" {
+		t.Errorf("StructValueFromGo(nil) mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, in := range []any{int64(1), time.Time{}, spanner.NullString{}} {
+		if _, err := gcvctor.StructValueFromGo(in); !errors.Is(err, gcvctor.ErrNotGoStruct) {
+			t.Errorf("StructValueFromGo(%T) error = %v, want ErrNotGoStruct", in, err)
+		}
+	}
+}
+
+func TestRowFromGo(t *testing.T) {
+	t.Parallel()
+
+	rowType, values, err := gcvctor.RowValuesFromGo(goValueItem{SKU: "a", Qty: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantType := typector.MustNameCodeSlicesToStructType([]string{"sku", "Qty"}, []sppb.TypeCode{sppb.TypeCode_STRING, sppb.TypeCode_INT64}).GetStructType()
+	if diff := cmp.Diff(wantType, rowType, protocmp.Transform()); diff != "" {
+		t.Errorf("row type mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]spanner.GenericColumnValue{gcvctor.StringValue("a"), gcvctor.Int64Value(2)}, values, protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+
+	row, err := gcvctor.RowFromGo(&goValueItem{SKU: "b", Qty: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sku string
+	var qty int64
+	if err := row.ColumnByName("sku", &sku); err != nil {
+		t.Fatal(err)
+	}
+	if err := row.ColumnByName("Qty", &qty); err != nil {
+		t.Fatal(err)
+	}
+	if sku != "b" || qty != 3 {
+		t.Errorf("row = (%q, %d), want (b, 3)", sku, qty)
+	}
+
+	if _, err := gcvctor.RowFromGo((*goValueItem)(nil)); !errors.Is(err, gcvctor.ErrNotGoStruct) {
+		t.Errorf("RowFromGo(nil pointer) error = %v, want ErrNotGoStruct", err)
+	}
+}
+
+func TestStructValueFromGoEmbeddedField(t *testing.T) {
+	t.Parallel()
+
+	type embedded struct {
+		goValueItem
+		ID int64
+	}
+	_, err := gcvctor.StructValueFromGo(embedded{})
+	var fieldErr *gcvctor.StructFieldError
+	if !errors.Is(err, gcvctor.ErrEmbeddedGoStructField) || !errors.As(err, &fieldErr) {
+		t.Fatalf("error = %v, want StructFieldError wrapping ErrEmbeddedGoStructField", err)
+	}
+}
@@ -0,0 +1,24 @@
+// Package gcvgen generates random, valid [cloud.google.com/go/spanner.GenericColumnValue]
+// values of any Spanner type for property-based tests and fuzzing.
+//
+// [Generate] walks a [cloud.google.com/go/spanner/apiv1/spannerpb.Type] and produces a value
+// of exactly that type, with canonical wire payloads built by
+// [github.com/apstndb/spanvalue/gcvctor]. [Options] control how nasty the values are: the
+// NULL probability at every depth, ARRAY and STRING lengths, the string alphabet
+// ([NastyAlphabet] mixes quotes, backslashes, control characters, and tricky Unicode), FLOAT
+// special values (NaN, ±Inf, -0), range extremes for INT64, NUMERIC, DATE, TIMESTAMP, and
+// INTERVAL, and descriptor-backed PROTO and ENUM payloads through [Options.Resolver].
+// [DefaultOptions] is a reasonable starting point.
+//
+// Generation is deterministic for a given [math/rand/v2.Rand] state; [NewRand] derives one
+// from a single seed. [Fuzz] wires both into a [testing.F] target so native fuzzing mutates
+// the seed:
+//
+//	func FuzzLiteral(f *testing.F) {
+//		gcvgen.Fuzz(f, typ, gcvgen.DefaultOptions(), func(t *testing.T, v spanner.GenericColumnValue) {
+//			if _, err := spanvalue.FormatColumnLiteral(v); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+package gcvgen
@@ -0,0 +1,31 @@
+package gcvgen
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// FuzzSeedCount is the number of seeds [Fuzz] adds to the corpus, so plain
+// go test runs exercise that many generated values.
+const FuzzSeedCount = 32
+
+// Fuzz registers a fuzz target on f that generates a value of type typ from
+// the fuzzed uint64 seed ([NewRand]) and passes it to fn. It adds seeds 0
+// through [FuzzSeedCount]-1 to the corpus, so the target also runs as a
+// regular test; a failing input reproduces with Generate(NewRand(seed), typ,
+// opts). Generation errors (an unsupported typ) fail the test.
+func Fuzz(f *testing.F, typ *sppb.Type, opts Options, fn func(t *testing.T, value spanner.GenericColumnValue)) {
+	f.Helper()
+	for seed := range uint64(FuzzSeedCount) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed uint64) {
+		value, err := Generate(NewRand(seed), typ, opts)
+		if err != nil {
+			t.Fatalf("Generate(seed %d): %v", seed, err)
+		}
+		fn(t, value)
+	})
+}
@@ -0,0 +1,442 @@
+package gcvgen
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var (
+	// ErrUnsupportedType is returned by [Generate] for type codes it cannot
+	// produce values for, such as TYPE_CODE_UNSPECIFIED.
+	ErrUnsupportedType = errors.New("gcvgen: unsupported type")
+	// ErrInvalidAlphabet is returned by [Generate] when [Options.Alphabet]
+	// contains a rune that is not valid in a UTF-8 STRING.
+	ErrInvalidAlphabet = errors.New("gcvgen: invalid alphabet rune")
+)
+
+// Resolver resolves message and enum types for PROTO and ENUM generation.
+// [google.golang.org/protobuf/types/dynamicpb.Types] and
+// [google.golang.org/protobuf/reflect/protoregistry.Types] implement it.
+type Resolver interface {
+	protoregistry.MessageTypeResolver
+	FindEnumByName(protoreflect.FullName) (protoreflect.EnumType, error)
+}
+
+var (
+	_ Resolver = (*dynamicpb.Types)(nil)
+	_ Resolver = (*protoregistry.Types)(nil)
+)
+
+// ASCIIAlphabet is the default [Options.Alphabet]: ASCII letters and digits.
+var ASCIIAlphabet = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+
+// NastyAlphabet mixes characters that commonly break quoting and escaping:
+// SQL and CSV delimiters, quotes, backslashes, control characters including
+// NUL, combining marks, zero-width and bidi controls, line separators, and
+// characters outside the BMP.
+var NastyAlphabet = []rune("aZ09 ,;|\"'`\\\n\r\t\x00\x7f" +
+	"e\u00e9\u0301\u200b\u202e\ufeff\u2028\u2029\ufffd\u65e5\U0001f600\U0010ffff")
+
+// Options configures [Generate]. The zero value generates no NULLs, empty
+// ARRAYs, STRINGs, and BYTES, and no special or extreme values; see
+// [DefaultOptions].
+type Options struct {
+	// NullProbability is the probability that any value, at any depth
+	// including ARRAY elements and STRUCT fields, is NULL.
+	NullProbability float64
+	// MaxArrayLen is the maximum ARRAY length; lengths are uniform in
+	// [0, MaxArrayLen].
+	MaxArrayLen int
+	// MaxStringLen is the maximum length of STRING values in runes and of
+	// BYTES values in bytes; it also bounds JSON string members.
+	MaxStringLen int
+	// Alphabet is the set of runes STRING values draw from; nil means
+	// [ASCIIAlphabet].
+	Alphabet []rune
+	// FloatEdgeProbability is the probability that a FLOAT64 or FLOAT32
+	// value is one of NaN, +Inf, -Inf, -0, the largest finite value, or the
+	// smallest positive subnormal.
+	FloatEdgeProbability float64
+	// ExtremeProbability is the probability that an INT64, NUMERIC, DATE,
+	// TIMESTAMP, or INTERVAL value is taken from the ends of its range
+	// (and, for PostgreSQL NUMERIC, NaN) instead of uniformly.
+	ExtremeProbability float64
+	// Resolver, when non-nil, resolves PROTO message and ENUM types so
+	// values carry valid messages with random scalar fields and declared
+	// enum numbers. Without it, or when a type is not found, PROTO values
+	// are the empty message and ENUM values are 0.
+	Resolver Resolver
+}
+
+// DefaultOptions returns options suited to property tests: occasional NULLs,
+// short ARRAYs and STRINGs over [NastyAlphabet], and some special and
+// extreme values.
+func DefaultOptions() Options {
+	return Options{
+		NullProbability:      0.1,
+		MaxArrayLen:          4,
+		MaxStringLen:         12,
+		Alphabet:             NastyAlphabet,
+		FloatEdgeProbability: 0.1,
+		ExtremeProbability:   0.1,
+	}
+}
+
+// NewRand returns a deterministic [rand.Rand] derived from seed, for
+// reproducing a generated value from a logged seed.
+func NewRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// Generate returns a random value of type typ. The value's Type is typ
+// itself, so ARRAY and STRUCT values keep the caller's field names and
+// annotations. Scalar payloads are canonical wire forms, as Spanner would
+// return them. It returns [ErrUnsupportedType] for type codes without a
+// generator.
+func Generate(r *rand.Rand, typ *sppb.Type, opts Options) (spanner.GenericColumnValue, error) {
+	if opts.Alphabet == nil {
+		opts.Alphabet = ASCIIAlphabet
+	}
+	for _, c := range opts.Alphabet {
+		if c < 0 || c > 0x10FFFF || (0xD800 <= c && c <= 0xDFFF) {
+			return spanner.GenericColumnValue{}, fmt.Errorf("%w: %U", ErrInvalidAlphabet, c)
+		}
+	}
+	g := &generator{r: r, opts: opts}
+	value, err := g.value(typ)
+	if err != nil {
+		return spanner.GenericColumnValue{}, err
+	}
+	return spanner.GenericColumnValue{Type: typ, Value: value}, nil
+}
+
+type generator struct {
+	r    *rand.Rand
+	opts Options
+}
+
+func (g *generator) chance(p float64) bool {
+	return p > 0 && g.r.Float64() < p
+}
+
+func (g *generator) value(typ *sppb.Type) (*structpb.Value, error) {
+	if g.chance(g.opts.NullProbability) {
+		return structpb.NewNullValue(), nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		if typ.GetArrayElementType() == nil {
+			return nil, fmt.Errorf("%w: ARRAY without element type", ErrUnsupportedType)
+		}
+		values := make([]*structpb.Value, g.r.IntN(max(g.opts.MaxArrayLen, 0)+1))
+		for i := range values {
+			v, err := g.value(typ.GetArrayElementType())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case sppb.TypeCode_STRUCT:
+		fields := typ.GetStructType().GetFields()
+		values := make([]*structpb.Value, len(fields))
+		for i, field := range fields {
+			v, err := g.value(field.GetType())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	}
+	gcv, err := g.scalar(typ)
+	if err != nil {
+		return nil, err
+	}
+	return gcv.Value, nil
+}
+
+func (g *generator) scalar(typ *sppb.Type) (spanner.GenericColumnValue, error) {
+	pg := typ.GetTypeAnnotation()
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return gcvctor.BoolValue(g.r.IntN(2) == 0), nil
+	case sppb.TypeCode_INT64:
+		if pg == sppb.TypeAnnotationCode_PG_OID {
+			return gcvctor.PGOIDValue(g.r.Int64N(math.MaxUint32 + 1)), nil
+		}
+		return gcvctor.Int64Value(g.int64()), nil
+	case sppb.TypeCode_FLOAT64:
+		return gcvctor.Float64Value(g.float64()), nil
+	case sppb.TypeCode_FLOAT32:
+		return gcvctor.Float32Value(g.float32()), nil
+	case sppb.TypeCode_STRING:
+		return gcvctor.StringValue(g.string()), nil
+	case sppb.TypeCode_BYTES:
+		b := make([]byte, g.r.IntN(max(g.opts.MaxStringLen, 0)+1))
+		for i := range b {
+			b[i] = byte(g.r.UintN(256))
+		}
+		return gcvctor.BytesValue(b), nil
+	case sppb.TypeCode_DATE:
+		return gcvctor.DateValue(g.date()), nil
+	case sppb.TypeCode_TIMESTAMP:
+		return gcvctor.TimestampValue(g.timestamp()), nil
+	case sppb.TypeCode_NUMERIC:
+		if pg == sppb.TypeAnnotationCode_PG_NUMERIC {
+			if g.chance(g.opts.ExtremeProbability / 4) {
+				return gcvctor.StringBasedValueOf(typ, "NaN"), nil
+			}
+			return gcvctor.PGNumericValue(g.numeric()), nil
+		}
+		return gcvctor.NumericValue(g.numeric()), nil
+	case sppb.TypeCode_JSON:
+		if pg == sppb.TypeAnnotationCode_PG_JSONB {
+			return gcvctor.PGJSONBValue(g.json(2))
+		}
+		return gcvctor.JSONValue(g.json(2))
+	case sppb.TypeCode_INTERVAL:
+		return gcvctor.IntervalValue(g.interval()), nil
+	case sppb.TypeCode_UUID:
+		var u uuid.UUID
+		for i := range u {
+			u[i] = byte(g.r.UintN(256))
+		}
+		return gcvctor.UUIDValue(u), nil
+	case sppb.TypeCode_PROTO:
+		b, err := g.protoPayload(typ.GetProtoTypeFqn())
+		if err != nil {
+			return spanner.GenericColumnValue{}, err
+		}
+		return spanner.GenericColumnValue{Type: typ, Value: structpb.NewStringValue(base64.StdEncoding.EncodeToString(b))}, nil
+	case sppb.TypeCode_ENUM:
+		return spanner.GenericColumnValue{Type: typ, Value: structpb.NewStringValue(strconv.FormatInt(g.enumNumber(typ.GetProtoTypeFqn()), 10))}, nil
+	}
+	return spanner.GenericColumnValue{}, fmt.Errorf("%w: %v", ErrUnsupportedType, spantype.FormatTypeMoreVerbose(typ))
+}
+
+func (g *generator) int64() int64 {
+	if g.chance(g.opts.ExtremeProbability) {
+		return []int64{math.MinInt64, math.MaxInt64, 0, -1, 1}[g.r.IntN(5)]
+	}
+	// Mix magnitudes so small numbers are as common as huge ones.
+	v := g.r.Int64N(int64(1) << g.r.UintN(63))
+	if g.r.IntN(2) == 0 {
+		return -v
+	}
+	return v
+}
+
+func (g *generator) float64() float64 {
+	if g.chance(g.opts.FloatEdgeProbability) {
+		return []float64{math.NaN(), math.Inf(1), math.Inf(-1), math.Copysign(0, -1), math.MaxFloat64, math.SmallestNonzeroFloat64}[g.r.IntN(6)]
+	}
+	return g.r.NormFloat64() * math.Pow(10, float64(g.r.IntN(21)-10))
+}
+
+func (g *generator) float32() float32 {
+	if g.chance(g.opts.FloatEdgeProbability) {
+		return []float32{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.Copysign(0, -1)), math.MaxFloat32, math.SmallestNonzeroFloat32}[g.r.IntN(6)]
+	}
+	return float32(g.r.NormFloat64() * math.Pow(10, float64(g.r.IntN(11)-5)))
+}
+
+func (g *generator) string() string {
+	var b strings.Builder
+	for range g.r.IntN(max(g.opts.MaxStringLen, 0) + 1) {
+		b.WriteRune(g.opts.Alphabet[g.r.IntN(len(g.opts.Alphabet))])
+	}
+	return b.String()
+}
+
+var (
+	minDate = civil.Date{Year: 1, Month: time.January, Day: 1}
+	maxDate = civil.Date{Year: 9999, Month: time.December, Day: 31}
+	// minTimestamp and maxTimestamp bound Spanner TIMESTAMP values.
+	minTimestamp = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxTimestamp = time.Date(9999, time.December, 31, 23, 59, 59, 999999999, time.UTC)
+)
+
+func (g *generator) date() civil.Date {
+	if g.chance(g.opts.ExtremeProbability) {
+		return []civil.Date{minDate, maxDate}[g.r.IntN(2)]
+	}
+	return minDate.AddDays(g.r.IntN(maxDate.DaysSince(minDate) + 1))
+}
+
+func (g *generator) timestamp() time.Time {
+	if g.chance(g.opts.ExtremeProbability) {
+		return []time.Time{minTimestamp, maxTimestamp, time.Unix(0, 0).UTC()}[g.r.IntN(3)]
+	}
+	secs := g.r.Int64N(maxTimestamp.Unix() - minTimestamp.Unix() + 1)
+	ts := time.Unix(minTimestamp.Unix()+secs, 0).UTC()
+	// Vary the sub-second precision so formatting sees every fraction width.
+	switch g.r.IntN(3) {
+	case 0:
+		return ts
+	case 1:
+		return ts.Add(time.Duration(g.r.IntN(1000)) * time.Millisecond)
+	}
+	return ts.Add(time.Duration(g.r.IntN(int(time.Second))))
+}
+
+// maxNumeric is the largest GoogleSQL NUMERIC: 29 integer and 9 fractional digits.
+var maxNumeric, _ = new(big.Rat).SetString(strings.Repeat("9", 29) + "." + strings.Repeat("9", 9))
+
+func (g *generator) numeric() *big.Rat {
+	if g.chance(g.opts.ExtremeProbability) {
+		smallest := big.NewRat(1, 1_000_000_000)
+		return []*big.Rat{
+			new(big.Rat).Set(maxNumeric), new(big.Rat).Neg(maxNumeric),
+			smallest, new(big.Rat).Neg(smallest), new(big.Rat),
+		}[g.r.IntN(5)]
+	}
+	var b strings.Builder
+	if g.r.IntN(2) == 0 {
+		b.WriteByte('-')
+	}
+	b.WriteByte(byte('0' + g.r.IntN(10)))
+	for range g.r.IntN(29) {
+		b.WriteByte(byte('0' + g.r.IntN(10)))
+	}
+	if frac := g.r.IntN(10); frac > 0 {
+		b.WriteByte('.')
+		for range frac {
+			b.WriteByte(byte('0' + g.r.IntN(10)))
+		}
+	}
+	r, _ := new(big.Rat).SetString(b.String())
+	return r
+}
+
+// maxIntervalNanos bounds the nanosecond part of INTERVAL values.
+var maxIntervalNanos, _ = new(big.Int).SetString("316224000000000000000", 10)
+
+func (g *generator) interval() spanner.Interval {
+	if g.chance(g.opts.ExtremeProbability) {
+		sign := int64(1 - 2*g.r.IntN(2))
+		nanos := new(big.Int).Mul(maxIntervalNanos, big.NewInt(sign))
+		return spanner.Interval{Months: int32(120000 * sign), Days: int32(3660000 * sign), Nanos: nanos}
+	}
+	return spanner.Interval{
+		Months: int32(g.r.IntN(2401) - 1200),
+		Days:   int32(g.r.IntN(731) - 365),
+		Nanos:  big.NewInt(g.r.Int64N(2*int64(48*time.Hour)+1) - int64(48*time.Hour)),
+	}
+}
+
+// json returns a random JSON document of at most the given nesting depth.
+func (g *generator) json(depth int) any {
+	kinds := 4
+	if depth > 0 {
+		kinds = 6
+	}
+	switch g.r.IntN(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return g.r.IntN(2) == 0
+	case 2:
+		if g.r.IntN(2) == 0 {
+			return g.r.Int64N(1<<53) - 1<<52
+		}
+		return g.r.NormFloat64() * 1000
+	case 3:
+		return g.string()
+	case 4:
+		out := make([]any, g.r.IntN(max(g.opts.MaxArrayLen, 0)+1))
+		for i := range out {
+			out[i] = g.json(depth - 1)
+		}
+		return out
+	}
+	out := make(map[string]any)
+	for range g.r.IntN(max(g.opts.MaxArrayLen, 0) + 1) {
+		out[g.string()] = g.json(depth - 1)
+	}
+	return out
+}
+
+func (g *generator) protoPayload(fqn string) ([]byte, error) {
+	if g.opts.Resolver == nil {
+		return nil, nil
+	}
+	mt, err := g.opts.Resolver.FindMessageByName(protoreflect.FullName(fqn))
+	if err != nil || mt == nil {
+		return nil, nil
+	}
+	msg := mt.New()
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil || g.r.IntN(2) == 0 {
+			continue
+		}
+		msg.Set(fd, g.protoScalar(fd))
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg.Interface())
+}
+
+func (g *generator) protoScalar(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(g.r.IntN(2) == 0)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(g.r.IntN(values.Len())).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(g.r.Int32())
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(g.int64())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(g.r.Uint32())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(g.r.Uint64())
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(g.float32())
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(g.float64())
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(g.string())
+	}
+	b := make([]byte, g.r.IntN(max(g.opts.MaxStringLen, 0)+1))
+	for i := range b {
+		b[i] = byte(g.r.UintN(256))
+	}
+	return protoreflect.ValueOfBytes(b)
+}
+
+func (g *generator) enumNumber(fqn string) int64 {
+	if g.opts.Resolver == nil {
+		return 0
+	}
+	et, err := g.opts.Resolver.FindEnumByName(protoreflect.FullName(fqn))
+	if err != nil || et == nil {
+		return 0
+	}
+	values := et.Descriptor().Values()
+	if values.Len() == 0 {
+		return 0
+	}
+	return int64(values.Get(g.r.IntN(values.Len())).Number())
+}
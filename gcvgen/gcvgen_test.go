@@ -0,0 +1,223 @@
+package gcvgen_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvgen"
+	"github.com/apstndb/spanvalue/writer"
+)
+
+func scalarTypes() []*sppb.Type {
+	var types []*sppb.Type
+	for _, code := range []sppb.TypeCode{
+		sppb.TypeCode_BOOL, sppb.TypeCode_INT64, sppb.TypeCode_FLOAT64, sppb.TypeCode_FLOAT32,
+		sppb.TypeCode_STRING, sppb.TypeCode_BYTES, sppb.TypeCode_DATE, sppb.TypeCode_TIMESTAMP,
+		sppb.TypeCode_NUMERIC, sppb.TypeCode_JSON, sppb.TypeCode_INTERVAL, sppb.TypeCode_UUID,
+	} {
+		types = append(types, typector.CodeToSimpleType(code))
+	}
+	return append(types, typector.PGNumeric(), typector.PGJSONB(), typector.PGOID(),
+		typector.FQNToProtoType("google.protobuf.Duration"), typector.FQNToEnumType("google.protobuf.NullValue"))
+}
+
+// rowType is STRUCT<every scalar type..., arr ARRAY<STRUCT<s STRING, f FLOAT64>>>.
+func rowType() *sppb.Type {
+	types := scalarTypes()
+	names := make([]string, len(types))
+	for i := range names {
+		names[i] = "c" + strconv.Itoa(i)
+	}
+	elem := typector.MustNameCodeSlicesToStructType([]string{"s", "f"}, []sppb.TypeCode{sppb.TypeCode_STRING, sppb.TypeCode_FLOAT64})
+	return typector.MustNameTypeSlicesToStructType(append(names, "arr"), append(types, typector.ElemTypeToArrayType(elem)))
+}
+
+func TestGenerateFormatsAcrossPresets(t *testing.T) {
+	t.Parallel()
+
+	opts := gcvgen.DefaultOptions()
+	opts.Resolver = protoregistry.GlobalTypes
+	typ := rowType()
+	for seed := range uint64(200) {
+		v, err := gcvgen.Generate(gcvgen.NewRand(seed), typ, opts)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if v.Type != typ {
+			t.Fatalf("seed %d: Type is not the requested type", seed)
+		}
+		for name, fc := range map[string]*spanvalue.FormatConfig{
+			"literal": spanvalue.LiteralFormatConfig(),
+			"simple":  spanvalue.SimpleFormatConfig(),
+			"cli":     spanvalue.SpannerCLICompatibleFormatConfig(),
+		} {
+			if _, err := fc.FormatToplevelColumn(v); err != nil {
+				t.Fatalf("seed %d: %s: %v", seed, name, err)
+			}
+		}
+		s, err := spanvalue.JSONFormatConfig().FormatToplevelColumn(v)
+		if err != nil {
+			t.Fatalf("seed %d: json: %v", seed, err)
+		}
+		if !json.Valid([]byte(s)) {
+			t.Fatalf("seed %d: invalid JSON %q", seed, s)
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	t.Parallel()
+
+	typ := rowType()
+	a, err := gcvgen.Generate(gcvgen.NewRand(7), typ, gcvgen.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gcvgen.Generate(gcvgen.NewRand(7), typ, gcvgen.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(a, b, protocmp.Transform()); diff != "" {
+		t.Errorf("same seed generated different values (-a +b):\n%s", diff)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	t.Parallel()
+
+	r := gcvgen.NewRand(1)
+	v, err := gcvgen.Generate(r, rowType(), gcvgen.Options{NullProbability: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spanvalue.IsNull(v) {
+		t.Errorf("NullProbability 1 generated non-NULL %v", v.Value)
+	}
+
+	v, err = gcvgen.Generate(r, typector.ElemCodeToArrayType(sppb.TypeCode_STRING), gcvgen.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(v.Value.GetListValue().GetValues()); n != 0 {
+		t.Errorf("zero Options generated ARRAY of length %d, want 0", n)
+	}
+
+	v, err = gcvgen.Generate(r, typector.CodeToSimpleType(sppb.TypeCode_STRING), gcvgen.Options{MaxStringLen: 50, Alphabet: []rune("x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.Value.GetStringValue(); len(s) > 50 || strings.Trim(s, "x") != "" {
+		t.Errorf("STRING %q does not follow MaxStringLen and Alphabet", s)
+	}
+
+	for range 20 {
+		v, err = gcvgen.Generate(r, typector.CodeToSimpleType(sppb.TypeCode_FLOAT64), gcvgen.Options{FloatEdgeProbability: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f float64
+		if err := v.Decode(&f); err != nil {
+			t.Fatal(err)
+		}
+		if !math.IsNaN(f) && !math.IsInf(f, 0) && f != 0 && f != math.MaxFloat64 && f != math.SmallestNonzeroFloat64 {
+			t.Errorf("FloatEdgeProbability 1 generated %v", f)
+		}
+		if f == 0 && !math.Signbit(f) {
+			t.Errorf("FloatEdgeProbability 1 generated +0")
+		}
+	}
+}
+
+func TestGenerateProtoAndEnumFromResolver(t *testing.T) {
+	t.Parallel()
+
+	opts := gcvgen.Options{Resolver: protoregistry.GlobalTypes}
+	r := gcvgen.NewRand(3)
+	for range 20 {
+		v, err := gcvgen.Generate(r, typector.FQNToProtoType("google.protobuf.Duration"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := base64.StdEncoding.DecodeString(v.Value.GetStringValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proto.Unmarshal(b, &durationpb.Duration{}); err != nil {
+			t.Errorf("PROTO payload does not unmarshal: %v", err)
+		}
+
+		v, err = gcvgen.Generate(r, typector.FQNToEnumType("google.protobuf.NullValue"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Value.GetStringValue(); got != strconv.Itoa(int(structpb.NullValue_NULL_VALUE)) {
+			t.Errorf("ENUM number = %s, want a declared NullValue number", got)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	r := gcvgen.NewRand(0)
+	if _, err := gcvgen.Generate(r, typector.CodeToSimpleType(sppb.TypeCode_TYPE_CODE_UNSPECIFIED), gcvgen.Options{}); !errors.Is(err, gcvgen.ErrUnsupportedType) {
+		t.Errorf("UNSPECIFIED error = %v, want ErrUnsupportedType", err)
+	}
+	if _, err := gcvgen.Generate(r, &sppb.Type{Code: sppb.TypeCode_ARRAY}, gcvgen.Options{MaxArrayLen: 1}); !errors.Is(err, gcvgen.ErrUnsupportedType) {
+		t.Errorf("ARRAY without element type error = %v, want ErrUnsupportedType", err)
+	}
+	if _, err := gcvgen.Generate(r, typector.CodeToSimpleType(sppb.TypeCode_STRING), gcvgen.Options{Alphabet: []rune{0xD800}}); !errors.Is(err, gcvgen.ErrInvalidAlphabet) {
+		t.Errorf("surrogate alphabet error = %v, want ErrInvalidAlphabet", err)
+	}
+}
+
+// FuzzCSVRoundTrip checks that every generated row survives CSV quoting: the
+// output parses back with one record per row and one field per column.
+func FuzzCSVRoundTrip(f *testing.F) {
+	gcvgen.Fuzz(f, rowType(), gcvgen.DefaultOptions(), func(t *testing.T, v spanner.GenericColumnValue) {
+		if spanvalue.IsNull(v) {
+			t.Skip("NULL row")
+		}
+		fields := v.Type.GetStructType().GetFields()
+		values := make([]spanner.GenericColumnValue, len(fields))
+		for i, field := range fields {
+			values[i] = spanner.GenericColumnValue{Type: field.GetType(), Value: v.Value.GetListValue().GetValues()[i]}
+		}
+		var out bytes.Buffer
+		w, err := writer.NewCSVWriter(&out, writer.WithRowType(v.Type.GetStructType()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteGCVs(values); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("CSV does not parse back: %v\n%s", err, out.String())
+		}
+		if len(records) != 2 || len(records[1]) != len(fields) {
+			t.Fatalf("got %d records, want header and one row of %d fields", len(records), len(fields))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FloatFromWire decodes a FLOAT32/FLOAT64 wire value, which is a number or
+// one of the strings "NaN", "Infinity", and "-Infinity".
+func FloatFromWire(v *structpb.Value) (float64, error) {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return k.NumberValue, nil
+	case *structpb.Value_StringValue:
+		switch k.StringValue {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, fmt.Errorf("invalid float %q", k.StringValue)
+	default:
+		return 0, fmt.Errorf("float wire kind %T", k)
+	}
+}
+
+// TypesEquivalent reports whether a value of type got can be stored in a
+// typed column of type want. Spanner results may omit STRUCT field names on
+// values, so only codes, annotations, proto names, and nesting are compared.
+func TypesEquivalent(want, got *sppb.Type) bool {
+	if want.GetCode() != got.GetCode() || want.GetTypeAnnotation() != got.GetTypeAnnotation() ||
+		want.GetProtoTypeFqn() != got.GetProtoTypeFqn() {
+		return false
+	}
+	switch want.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		return TypesEquivalent(want.GetArrayElementType(), got.GetArrayElementType())
+	case sppb.TypeCode_STRUCT:
+		wantFields, gotFields := want.GetStructType().GetFields(), got.GetStructType().GetFields()
+		if len(wantFields) != len(gotFields) {
+			return false
+		}
+		for i := range wantFields {
+			if !TypesEquivalent(wantFields[i].GetType(), gotFields[i].GetType()) {
+				return false
+			}
+		}
+	}
+	return true
+}
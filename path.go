@@ -0,0 +1,477 @@
+package spanvalue
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// PathElemKind distinguishes the two ways of stepping into a nested value.
+type PathElemKind int
+
+const (
+	// PathElemField steps into a STRUCT field.
+	PathElemField PathElemKind = iota
+	// PathElemIndex steps into an ARRAY element.
+	PathElemIndex
+	// PathElemAllIndices steps into every ARRAY element; it renders as [*].
+	// Only [Get] and [Set] accept it.
+	PathElemAllIndices
+)
+
+// PathElem is one step of a [Path]: a STRUCT field or an ARRAY element.
+//
+// For [PathElemField], Index is the field ordinal and Name the field name.
+// Name is empty for unnamed fields and for fields whose name is shared with
+// a sibling (duplicate aliases), so the rendered path always addresses a
+// single field. For [PathElemIndex], Index is the element index and Name is
+// unused. [PathElemAllIndices] uses neither.
+type PathElem struct {
+	Kind  PathElemKind
+	Name  string
+	Index int
+}
+
+// FieldElem returns a [PathElemField] step for the STRUCT field at ordinal
+// index. Pass an empty name to address the field by ordinal.
+func FieldElem(name string, index int) PathElem {
+	return PathElem{Kind: PathElemField, Name: name, Index: index}
+}
+
+// IndexElem returns a [PathElemIndex] step for the ARRAY element at index.
+func IndexElem(index int) PathElem {
+	return PathElem{Kind: PathElemIndex, Index: index}
+}
+
+// AllIndicesElem returns a [PathElemAllIndices] step.
+func AllIndicesElem() PathElem {
+	return PathElem{Kind: PathElemAllIndices}
+}
+
+// Path locates a value nested inside a top-level
+// [cloud.google.com/go/spanner.GenericColumnValue]. The empty Path is the
+// top-level value itself.
+//
+// [Path.String] renders STRUCT fields as dot-separated names and ARRAY
+// elements as bracketed indices, for example order.items[2].sku. Unnamed
+// fields render as their ordinal (items[0].1), and names that are not plain
+// identifiers, or that start with a digit, are backquoted with GoogleSQL
+// identifier escapes (`first name`). [ParsePath] accepts the same syntax,
+// plus [*] for every ARRAY element and an optional leading dot, so the path
+// of a [*PathError] can be fed straight back into [Get] or [Set].
+type Path []PathElem
+
+// String renders p in the path syntax described on [Path].
+func (p Path) String() string {
+	var b strings.Builder
+	for i, elem := range p {
+		switch elem.Kind {
+		case PathElemIndex:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(elem.Index))
+			b.WriteByte(']')
+		case PathElemAllIndices:
+			b.WriteString("[*]")
+		default:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			if elem.Name == "" {
+				b.WriteString(strconv.Itoa(elem.Index))
+			} else {
+				b.WriteString(quotePathName(elem.Name))
+			}
+		}
+	}
+	return b.String()
+}
+
+// Clone returns a copy of p that does not share its backing array.
+func (p Path) Clone() Path {
+	return slices.Clone(p)
+}
+
+// quotePathName returns name unchanged when it is a plain identifier and
+// backquotes it otherwise, so a leading digit always means a field ordinal.
+func quotePathName(name string) string {
+	if isPlainPathName(name) {
+		return name
+	}
+	return "`" + googleSQLIdentifierEscaper.Replace(name) + "`"
+}
+
+func isPlainPathName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PathError records the [Path] of the nested value that caused Err.
+// [Walk], [Transform], [Get], and [Set] wrap failures in PathError, and
+// [*FormatConfig.FormatColumn] wraps failures of nested ARRAY elements and
+// STRUCT fields formatted through [PluginForArray] and [PluginForStruct].
+// It unwraps to Err for [errors.Is] and [errors.As].
+type PathError struct {
+	Path Path
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Err.Error()
+	}
+	return e.Path.String() + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// wrapPathError wraps err with a copy of path. Errors that already carry a
+// path are returned unchanged so the innermost location wins.
+func wrapPathError(path Path, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return &PathError{Path: path.Clone(), Err: err}
+}
+
+// prependPathElem reports err as a failure of the child at elem. A
+// [*PathError] returned directly by the child gains elem as its first step;
+// any other error is wrapped in a new one-step [*PathError].
+func prependPathElem(elem PathElem, err error) error {
+	var pathErr *PathError
+	if errors.As(err, &pathErr) && error(pathErr) == err {
+		return &PathError{Path: append(Path{elem}, pathErr.Path...), Err: pathErr.Err}
+	}
+	return &PathError{Path: Path{elem}, Err: err}
+}
+
+// structFieldElem returns the [PathElemField] step for fields[i], addressing
+// it by ordinal when it is unnamed or its name is shared with a sibling.
+func structFieldElem(fields []*sppb.StructType_Field, i int) PathElem {
+	name := fields[i].GetName()
+	for j, field := range fields {
+		if j != i && field.GetName() == name {
+			return FieldElem("", i)
+		}
+	}
+	return FieldElem(name, i)
+}
+
+// structFieldElems returns one [PathElemField] step per field, leaving Name
+// empty for unnamed fields and for names shared with a sibling.
+func structFieldElems(names []string) []PathElem {
+	counts := make(map[string]int, len(names))
+	for _, name := range names {
+		counts[name]++
+	}
+	elems := make([]PathElem, len(names))
+	for i, name := range names {
+		if counts[name] > 1 {
+			name = ""
+		}
+		elems[i] = FieldElem(name, i)
+	}
+	return elems
+}
+
+var (
+	// ErrInvalidPathSyntax is returned by [ParsePath] for malformed path text.
+	ErrInvalidPathSyntax = errors.New("invalid path syntax")
+	// ErrPathNotFound is returned by [Get] and [Set] when a path step does
+	// not resolve: an index out of range, an unknown field name or ordinal,
+	// a step whose kind does not match the value (a field step on an ARRAY),
+	// or, for [Set], a step into a NULL ARRAY or STRUCT.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrAmbiguousPath is returned by [Get] and [Set] when a field name
+	// matches several fields of one STRUCT; address the field by ordinal.
+	ErrAmbiguousPath = errors.New("ambiguous path")
+	// ErrPathTypeMismatch is returned by [Set] when the new value's type
+	// differs from the type of the value it replaces.
+	ErrPathTypeMismatch = errors.New("path target type mismatch")
+)
+
+// ParsePath parses the syntax rendered by [Path.String]: field names
+// separated by dots (an optional leading dot is accepted), backquoted names
+// with GoogleSQL identifier escapes, decimal field ordinals for unnamed or
+// duplicate fields, and bracketed ARRAY indices or [*] for every element.
+// The empty string is the empty Path. Malformed input returns
+// [ErrInvalidPathSyntax].
+func ParsePath(s string) (Path, error) {
+	var path Path
+	i := 0
+	fail := func(format string, args ...any) (Path, error) {
+		return nil, fmt.Errorf("%w: %q at offset %d: %s", ErrInvalidPathSyntax, s, i, fmt.Sprintf(format, args...))
+	}
+	for i < len(s) {
+		switch {
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return fail("unterminated [")
+			}
+			inner := s[i+1 : i+end]
+			if inner == "*" {
+				path = append(path, AllIndicesElem())
+			} else {
+				index, err := parsePathIndex(inner)
+				if err != nil {
+					return fail("%v", err)
+				}
+				path = append(path, IndexElem(index))
+			}
+			i += end + 1
+			continue
+		case s[i] == '.':
+			i++
+		case i > 0:
+			return fail("want . or [")
+		}
+		elem, n, err := parsePathField(s[i:])
+		if err != nil {
+			return fail("%v", err)
+		}
+		path = append(path, elem)
+		i += n
+	}
+	return path, nil
+}
+
+func parsePathIndex(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("want index or *, got %q", s)
+	}
+	return strconv.Atoi(s)
+}
+
+// parsePathField parses one field step at the start of s and returns it with
+// the number of bytes consumed.
+func parsePathField(s string) (PathElem, int, error) {
+	if s == "" {
+		return PathElem{}, 0, errors.New("missing field")
+	}
+	if s[0] == '`' {
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '`':
+				return FieldElem(b.String(), 0), i + 1, nil
+			case '\\':
+				if i+1 == len(s) {
+					return PathElem{}, 0, errors.New("unterminated escape")
+				}
+				i++
+			}
+			b.WriteByte(s[i])
+		}
+		return PathElem{}, 0, errors.New("unterminated `")
+	}
+	n := strings.IndexAny(s, ".[")
+	if n < 0 {
+		n = len(s)
+	}
+	token := s[:n]
+	if token == "" {
+		return PathElem{}, 0, errors.New("missing field")
+	}
+	if '0' <= token[0] && token[0] <= '9' {
+		ordinal, err := parsePathIndex(token)
+		if err != nil {
+			return PathElem{}, 0, fmt.Errorf("want field ordinal, got %q", token)
+		}
+		return FieldElem("", ordinal), n, nil
+	}
+	if !isPlainPathName(token) {
+		return PathElem{}, 0, fmt.Errorf("want field name, got %q", token)
+	}
+	return FieldElem(token, 0), n, nil
+}
+
+// Get returns the values addressed by path inside value. Without a
+// [PathElemAllIndices] step the result has exactly one element; each [*]
+// step fans out over the elements of the ARRAY it reaches.
+//
+// Field steps match by Name when it is non-empty ([ErrAmbiguousPath] when
+// several fields share it) and by ordinal otherwise. Following SQL NULL
+// propagation, a field or index step into a NULL STRUCT or ARRAY yields a
+// NULL of the child type, and [*] over a NULL ARRAY yields nothing.
+// Unresolvable steps return [ErrPathNotFound]. Errors are wrapped in a
+// [*PathError] naming the failing step.
+func Get(value spanner.GenericColumnValue, path Path) ([]spanner.GenericColumnValue, error) {
+	var out []spanner.GenericColumnValue
+	if err := getValues(value, path, 0, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func getValues(value spanner.GenericColumnValue, path Path, depth int, out *[]spanner.GenericColumnValue) error {
+	if depth == len(path) {
+		*out = append(*out, value)
+		return nil
+	}
+	indices, children, err := resolvePathStep(value, path, depth)
+	if err != nil {
+		return err
+	}
+	for _, i := range indices {
+		if err := getValues(children[i], path, depth+1, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set returns a copy of value with the values addressed by path replaced by
+// newValue; [*] steps replace every element of the ARRAY they reach. path
+// steps resolve as in [Get], except that stepping into a NULL ARRAY or
+// STRUCT returns [ErrPathNotFound]; a [*] step leaves NULL elements it
+// would have to step into unchanged.
+//
+// Set preserves types: newValue must have the same type as each value it
+// replaces ([ErrPathTypeMismatch]), except that a NULL newValue of any type
+// is retyped to the replaced value's type. The empty path replaces value
+// itself under the same rule. Errors are wrapped in a [*PathError] naming
+// the failing step.
+func Set(value spanner.GenericColumnValue, path Path, newValue spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+	return setValue(value, path, 0, newValue)
+}
+
+func setValue(value spanner.GenericColumnValue, path Path, depth int, newValue spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+	if depth == len(path) {
+		if IsNull(newValue) {
+			return typeValueToGCV(value.Type, structpb.NewNullValue()), nil
+		}
+		if !proto.Equal(newValue.Type, value.Type) {
+			return spanner.GenericColumnValue{}, &PathError{
+				Path: path[:depth].Clone(),
+				Err: fmt.Errorf("%w: %v is not %v", ErrPathTypeMismatch,
+					spantype.FormatTypeMoreVerbose(newValue.Type), spantype.FormatTypeMoreVerbose(value.Type)),
+			}
+		}
+		return newValue, nil
+	}
+	if IsNull(value) && isComplexType(value.Type.GetCode()) {
+		return spanner.GenericColumnValue{}, &PathError{
+			Path: path[:depth+1].Clone(),
+			Err:  fmt.Errorf("%w: cannot set inside NULL %v", ErrPathNotFound, value.Type.GetCode()),
+		}
+	}
+	indices, children, err := resolvePathStep(value, path, depth)
+	if err != nil {
+		return spanner.GenericColumnValue{}, err
+	}
+	for _, i := range indices {
+		if path[depth].Kind == PathElemAllIndices && depth+1 < len(path) && IsNull(children[i]) {
+			continue
+		}
+		child, err := setValue(children[i], path, depth+1, newValue)
+		if err != nil {
+			return spanner.GenericColumnValue{}, err
+		}
+		children[i] = child
+	}
+	if value.Type.GetCode() == sppb.TypeCode_ARRAY {
+		return rebuildArray(path[:depth], value.Type, children)
+	}
+	return rebuildStruct(value.Type, children), nil
+}
+
+// resolvePathStep returns the typed children of value together with the
+// indices of the children selected by path[depth]. Children of a NULL
+// STRUCT or ARRAY are typed NULLs.
+func resolvePathStep(value spanner.GenericColumnValue, path Path, depth int) ([]int, []spanner.GenericColumnValue, error) {
+	elem := path[depth]
+	fail := func(err error) ([]int, []spanner.GenericColumnValue, error) {
+		return nil, nil, &PathError{Path: path[:depth+1].Clone(), Err: err}
+	}
+	code := value.Type.GetCode()
+	switch {
+	case elem.Kind == PathElemField && code != sppb.TypeCode_STRUCT,
+		elem.Kind != PathElemField && code != sppb.TypeCode_ARRAY:
+		return fail(fmt.Errorf("%w: cannot step into %v", ErrPathNotFound, code))
+	}
+
+	var children []spanner.GenericColumnValue
+	var names []string
+	if IsNull(value) {
+		if elem.Kind == PathElemAllIndices {
+			return nil, nil, nil
+		}
+		if code == sppb.TypeCode_ARRAY {
+			// A NULL ARRAY has no elements to count; any index yields NULL.
+			return []int{0}, []spanner.GenericColumnValue{typeValueToGCV(value.Type.GetArrayElementType(), structpb.NewNullValue())}, nil
+		}
+		for _, field := range value.Type.GetStructType().GetFields() {
+			names = append(names, field.GetName())
+			children = append(children, typeValueToGCV(field.GetType(), structpb.NewNullValue()))
+		}
+	} else {
+		var err error
+		_, children, err = childValues(value)
+		if err != nil {
+			return fail(err)
+		}
+		if code == sppb.TypeCode_STRUCT {
+			for _, field := range value.Type.GetStructType().GetFields() {
+				names = append(names, field.GetName())
+			}
+		}
+	}
+
+	switch elem.Kind {
+	case PathElemAllIndices:
+		indices := make([]int, len(children))
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices, children, nil
+	case PathElemIndex:
+		if elem.Index < 0 || elem.Index >= len(children) {
+			return fail(fmt.Errorf("%w: index %d out of range [0, %d)", ErrPathNotFound, elem.Index, len(children)))
+		}
+		return []int{elem.Index}, children, nil
+	}
+	if elem.Name == "" {
+		if elem.Index < 0 || elem.Index >= len(children) {
+			return fail(fmt.Errorf("%w: field ordinal %d out of range [0, %d)", ErrPathNotFound, elem.Index, len(children)))
+		}
+		return []int{elem.Index}, children, nil
+	}
+	match := -1
+	for i, name := range names {
+		if name != elem.Name {
+			continue
+		}
+		if match >= 0 {
+			return fail(fmt.Errorf("%w: field name %q matches fields %d and %d", ErrAmbiguousPath, elem.Name, match, i))
+		}
+		match = i
+	}
+	if match < 0 {
+		return fail(fmt.Errorf("%w: no field named %q", ErrPathNotFound, elem.Name))
+	}
+	return []int{match}, children, nil
+}
@@ -0,0 +1,228 @@
+package spanvalue_test
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func mustParsePath(t *testing.T, s string) spanvalue.Path {
+	t.Helper()
+	path, err := spanvalue.ParsePath(s)
+	if err != nil {
+		t.Fatalf("ParsePath(%q): %v", s, err)
+	}
+	return path
+}
+
+func formatLiterals(t *testing.T, values []spanner.GenericColumnValue) []string {
+	t.Helper()
+	out := make([]string, len(values))
+	for i, v := range values {
+		s, err := spanvalue.FormatColumnLiteral(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = s
+	}
+	return out
+}
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want spanvalue.Path
+		// canonical is the String() form when it differs from in.
+		canonical string
+	}{
+		{in: "", want: nil},
+		{in: "order.items[2].sku", want: spanvalue.Path{
+			spanvalue.FieldElem("order", 0), spanvalue.FieldElem("items", 0), spanvalue.IndexElem(2), spanvalue.FieldElem("sku", 0),
+		}},
+		{in: ".order", want: spanvalue.Path{spanvalue.FieldElem("order", 0)}, canonical: "order"},
+		{in: "[*].1", want: spanvalue.Path{spanvalue.AllIndicesElem(), spanvalue.FieldElem("", 1)}},
+		{in: "`first name`.`a\\`b\\\\c`", want: spanvalue.Path{spanvalue.FieldElem("first name", 0), spanvalue.FieldElem("a`b\\c", 0)}},
+		{in: "[0][10]", want: spanvalue.Path{spanvalue.IndexElem(0), spanvalue.IndexElem(10)}},
+	}
+	for _, tt := range tests {
+		got, err := spanvalue.ParsePath(tt.in)
+		if err != nil {
+			t.Errorf("ParsePath(%q): %v", tt.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ParsePath(%q) mismatch (-want +got):\n%s", tt.in, diff)
+		}
+		canonical := tt.canonical
+		if canonical == "" {
+			canonical = tt.in
+		}
+		if s := got.String(); s != canonical {
+			t.Errorf("ParsePath(%q).String() = %q, want %q", tt.in, s, canonical)
+		}
+	}
+
+	for _, in := range []string{"a..b", "a[", "a[x]", "a[-1]", "a b", "`open", "a]", "1x", "a.[0]"} {
+		if _, err := spanvalue.ParsePath(in); !errors.Is(err, spanvalue.ErrInvalidPathSyntax) {
+			t.Errorf("ParsePath(%q) error = %v, want ErrInvalidPathSyntax", in, err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	order := orderFixture()
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", []string{`STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("a-1", 1), ("b-2", 2), NULL], 42)`}},
+		{"id", []string{"7"}},
+		{"2", []string{"42"}},
+		{"items[1].sku", []string{`"b-2"`}},
+		{"items[*].qty", []string{"1", "2", "NULL"}},
+		// Field access into the NULL STRUCT element yields a NULL field.
+		{"items[2].sku", []string{"NULL"}},
+	}
+	for _, tt := range tests {
+		got, err := spanvalue.Get(order, mustParsePath(t, tt.path))
+		if err != nil {
+			t.Errorf("Get(%q): %v", tt.path, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, formatLiterals(t, got)); diff != "" {
+			t.Errorf("Get(%q) mismatch (-want +got):\n%s", tt.path, diff)
+		}
+	}
+
+	nullItems := gcvctor.NullOf(order.Type.GetStructType().GetFields()[1].GetType())
+	got, err := spanvalue.Get(nullItems, mustParsePath(t, "[*]"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("Get(NULL ARRAY, [*]) = (%v, %v), want no values", got, err)
+	}
+	got, err = spanvalue.Get(nullItems, mustParsePath(t, "[3].qty"))
+	if err != nil || len(got) != 1 || !spanvalue.IsNull(got[0]) || got[0].Type.GetCode() != sppb.TypeCode_INT64 {
+		t.Errorf("Get(NULL ARRAY, [3].qty) = (%v, %v), want one NULL INT64", got, err)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	t.Parallel()
+
+	order := orderFixture()
+	tests := []struct {
+		path     string
+		wantErr  error
+		wantPath string
+	}{
+		{"items[3]", spanvalue.ErrPathNotFound, "items[3]"},
+		{"missing", spanvalue.ErrPathNotFound, "missing"},
+		{"9", spanvalue.ErrPathNotFound, "9"},
+		{"id.x", spanvalue.ErrPathNotFound, "id.x"},
+		{"[0]", spanvalue.ErrPathNotFound, "[0]"},
+	}
+	for _, tt := range tests {
+		_, err := spanvalue.Get(order, mustParsePath(t, tt.path))
+		var pathErr *spanvalue.PathError
+		if !errors.Is(err, tt.wantErr) || !errors.As(err, &pathErr) {
+			t.Errorf("Get(%q) error = %v, want PathError wrapping %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got := pathErr.Path.String(); got != tt.wantPath {
+			t.Errorf("Get(%q) PathError.Path = %q, want %q", tt.path, got, tt.wantPath)
+		}
+	}
+
+	dup := gcvctor.MustStructValueOf([]string{"a", "a"}, []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.Int64Value(2)})
+	if _, err := spanvalue.Get(dup, mustParsePath(t, "a")); !errors.Is(err, spanvalue.ErrAmbiguousPath) {
+		t.Errorf("Get(duplicate name) error = %v, want ErrAmbiguousPath", err)
+	}
+	got, err := spanvalue.Get(dup, mustParsePath(t, "1"))
+	if err != nil || len(got) != 1 || got[0].Value.GetStringValue() != "2" {
+		t.Errorf("Get(ordinal 1) = (%v, %v), want 2", got, err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	order := orderFixture()
+	tests := []struct {
+		path  string
+		value spanner.GenericColumnValue
+		want  string
+	}{
+		{"items[0].sku", gcvctor.StringValue("z-9"), `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("z-9", 1), ("b-2", 2), NULL], 42)`},
+		{"items[*].qty", gcvctor.Int64Value(0), `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("a-1", 0), ("b-2", 0), NULL], 42)`},
+		// A NULL of another type is retyped to the replaced value's type.
+		{"2", gcvctor.NullFromCode(sppb.TypeCode_STRING), `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("a-1", 1), ("b-2", 2), NULL], NULL)`},
+	}
+	for _, tt := range tests {
+		got, err := spanvalue.Set(order, mustParsePath(t, tt.path), tt.value)
+		if err != nil {
+			t.Errorf("Set(%q): %v", tt.path, err)
+			continue
+		}
+		s, err := spanvalue.FormatColumnLiteral(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != tt.want {
+			t.Errorf("Set(%q) = %s, want %s", tt.path, s, tt.want)
+		}
+	}
+	if s, _ := spanvalue.FormatColumnLiteral(order); s != `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("a-1", 1), ("b-2", 2), NULL], 42)` {
+		t.Errorf("Set mutated its input: %s", s)
+	}
+
+	if _, err := spanvalue.Set(order, mustParsePath(t, "id"), gcvctor.StringValue("7")); !errors.Is(err, spanvalue.ErrPathTypeMismatch) {
+		t.Errorf("Set(id, STRING) error = %v, want ErrPathTypeMismatch", err)
+	}
+	if _, err := spanvalue.Set(order, mustParsePath(t, "items[2].sku"), gcvctor.StringValue("x")); !errors.Is(err, spanvalue.ErrPathNotFound) {
+		t.Errorf("Set inside NULL STRUCT error = %v, want ErrPathNotFound", err)
+	}
+}
+
+func TestFormatErrorPathFeedsGet(t *testing.T) {
+	t.Parallel()
+
+	// A BOOL field whose wire kind is a string is malformed; the format
+	// error names its path, and that path resolves with Get.
+	boolType := typector.CodeToSimpleType(sppb.TypeCode_BOOL)
+	elemType := typector.MustNameTypeSlicesToStructType([]string{"ok"}, []*sppb.Type{boolType})
+	bad := spanner.GenericColumnValue{
+		Type: typector.ElemTypeToArrayType(elemType),
+		Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewBoolValue(true)}}),
+			structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("yes")}}),
+		}}),
+	}
+	for _, fc := range []*spanvalue.FormatConfig{spanvalue.LiteralFormatConfig(), spanvalue.SimpleFormatConfig(), spanvalue.JSONFormatConfig()} {
+		_, err := fc.FormatToplevelColumn(bad)
+		var pathErr *spanvalue.PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("format error = %v, want PathError", err)
+		}
+		if got := pathErr.Path.String(); got != "[1].ok" {
+			t.Errorf("PathError.Path = %q, want [1].ok", got)
+		}
+		reparsed := mustParsePath(t, pathErr.Path.String())
+		got, err := spanvalue.Get(bad, reparsed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Value.GetStringValue() != "yes" {
+			t.Errorf("Get(%v) = %v, want the malformed value", reparsed, got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Package rowproto encodes Spanner rows as protobuf messages whose descriptor is
+// generated from the row type, for shipping results over gRPC, Kafka, or other
+// protobuf transports.
+//
+// [NewCodec] builds a proto3 [google.golang.org/protobuf/reflect/protoreflect.MessageDescriptor]
+// from a [cloud.google.com/go/spanner/apiv1/spannerpb.StructType]. [Codec.Message]
+// converts one row of [cloud.google.com/go/spanner.GenericColumnValue] to a
+// [google.golang.org/protobuf/types/dynamicpb.Message], [Codec.Marshal] serializes it
+// as binary protobuf, protojson, or prototext, and [Codec.Values] and [Codec.Unmarshal]
+// convert messages back to values of the row type. [Codec.ProtoFile] returns the
+// schema as .proto source so consumers can generate code for it.
+//
+// Columns map to fields numbered in column order:
+//
+//   - BOOL, INT64, FLOAT32, FLOAT64, STRING, and BYTES: the matching scalar
+//   - NUMERIC, DATE, JSON, UUID, and INTERVAL: string in the Spanner wire form
+//   - TIMESTAMP: google.protobuf.Timestamp
+//   - PROTO and ENUM: the message or enum type found by [Options.Resolver], or
+//     bytes and int64 without a resolver or when the type is not found
+//   - STRUCT: a nested message
+//   - ARRAY: a repeated field; see [Options.NullableElements] for NULL elements
+//
+// The file uses proto3 syntax, or edition 2023 when a resolved ENUM type is a
+// closed (proto2) enum, which proto3 files cannot reference. Singular fields
+// have explicit presence either way, so NULL is an unset field. Protobuf has no
+// NULL for repeated fields, so a NULL ARRAY is encoded like an empty one.
+// Elements that are arrays themselves, or that may be NULL, are wrapped in a
+// message with a single field named value.
+//
+// Column names are used as field names, with characters outside [A-Za-z0-9_]
+// replaced by underscores; unnamed and duplicate names are numbered, as are
+// names whose lowerCamel JSON name repeats an earlier field's, such as fooBar
+// after foo_bar.
+package rowproto
@@ -0,0 +1,62 @@
+package rowproto
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ProtoFile returns the generated schema as .proto source. Message and enum
+// types are referenced by fully qualified names, so the source compiles with
+// protoc given the imported files.
+func (c *Codec) ProtoFile() string {
+	var b strings.Builder
+	fdp := c.fileDesc
+	b.WriteString("// Code generated by rowproto. DO NOT EDIT.\n\n")
+	if fdp.GetSyntax() == "editions" {
+		b.WriteString(`edition = "2023";`)
+	} else {
+		fmt.Fprintf(&b, "syntax = %q;", fdp.GetSyntax())
+	}
+	fmt.Fprintf(&b, "\n\npackage %s;\n", fdp.GetPackage())
+	if len(fdp.GetDependency()) > 0 {
+		b.WriteByte('\n')
+		for _, dep := range fdp.GetDependency() {
+			fmt.Fprintf(&b, "import %q;\n", dep)
+		}
+	}
+	for _, msg := range fdp.GetMessageType() {
+		b.WriteByte('\n')
+		writeProtoMessage(&b, msg, "")
+	}
+	return b.String()
+}
+
+func writeProtoMessage(b *strings.Builder, msg *descriptorpb.DescriptorProto, indent string) {
+	fmt.Fprintf(b, "%smessage %s {\n", indent, msg.GetName())
+	for _, fd := range msg.GetField() {
+		label := ""
+		switch {
+		case fd.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+			label = "repeated "
+		case fd.GetProto3Optional():
+			label = "optional "
+		}
+		fmt.Fprintf(b, "%s  %s%s %s = %d;\n", indent, label, protoTypeName(fd), fd.GetName(), fd.GetNumber())
+	}
+	for _, nested := range msg.GetNestedType() {
+		b.WriteByte('\n')
+		writeProtoMessage(b, nested, indent+"  ")
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// protoTypeName returns the .proto type of fd: the scalar keyword, or the
+// fully qualified message or enum name.
+func protoTypeName(fd *descriptorpb.FieldDescriptorProto) string {
+	if fd.GetTypeName() != "" {
+		return fd.GetTypeName()
+	}
+	return strings.ToLower(strings.TrimPrefix(fd.GetType().String(), "TYPE_"))
+}
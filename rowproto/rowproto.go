@@ -0,0 +1,757 @@
+package rowproto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+	"github.com/apstndb/spanvalue/internal"
+	"github.com/apstndb/spanvalue/protofmt"
+)
+
+var (
+	// ErrUnsupportedType reports a column type [NewCodec] cannot map to protobuf.
+	ErrUnsupportedType = errors.New("unsupported column type")
+	// ErrTypeMismatch reports a value whose type differs from the column type
+	// of the codec.
+	ErrTypeMismatch = errors.New("column type mismatch")
+	// ErrValueCount reports a row whose value count differs from the column
+	// count of the codec.
+	ErrValueCount = errors.New("mismatched value count")
+	// ErrNullElement reports a NULL ARRAY element when
+	// [Options.NullableElements] is false.
+	ErrNullElement = errors.New("NULL array element")
+)
+
+// Options configures [NewCodec]. The zero value is usable.
+type Options struct {
+	// Package is the protobuf package of the generated file (default
+	// "spanvalue.rowproto").
+	Package string
+	// MessageName is the name of the row message (default "Row").
+	MessageName string
+	// FileName is the path of the generated file (default the package with
+	// dots replaced by slashes, then "/" and the snake_case message name and
+	// ".proto").
+	FileName string
+	// Resolver, when non-nil, maps PROTO and ENUM columns to their message and
+	// enum types, which the generated file then imports. Types it does not
+	// find fall back to bytes and int64.
+	Resolver protofmt.ProtoEnumResolver
+	// NullableElements wraps every ARRAY element in a message so NULL
+	// elements can be encoded as an unset value. When false, elements are
+	// encoded directly and NULL elements return [ErrNullElement].
+	NullableElements bool
+	// UnnamedFieldNamer names unnamed columns and STRUCT fields (default
+	// [spanvalue.IndexedUnnamedFieldNamer]).
+	UnnamedFieldNamer spanvalue.UnnamedFieldNamer
+}
+
+// Format selects the serialization of [Codec.Marshal] and [Codec.Unmarshal].
+type Format int
+
+const (
+	// FormatBinary is the protobuf wire format.
+	FormatBinary Format = iota
+	// FormatJSON is the protobuf JSON mapping (protojson).
+	FormatJSON
+	// FormatText is the protobuf text format (prototext), which is not stable
+	// and suited only for display.
+	FormatText
+)
+
+// String returns the Go constant name for f, or "Format(n)" for unknown values.
+func (f Format) String() string {
+	switch f {
+	case FormatBinary:
+		return "FormatBinary"
+	case FormatJSON:
+		return "FormatJSON"
+	case FormatText:
+		return "FormatText"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// Codec converts rows of one row type to and from dynamic protobuf messages.
+// A Codec is safe for concurrent use.
+type Codec struct {
+	rowType  *sppb.StructType
+	fileDesc *descriptorpb.FileDescriptorProto
+	message  protoreflect.MessageDescriptor
+	opts     Options
+}
+
+// NewCodec returns a codec for rows of rowType.
+func NewCodec(rowType *sppb.StructType, opts Options) (*Codec, error) {
+	if opts.Package == "" {
+		opts.Package = "spanvalue.rowproto"
+	}
+	if opts.MessageName == "" {
+		opts.MessageName = "Row"
+	}
+	if opts.FileName == "" {
+		opts.FileName = strings.ReplaceAll(opts.Package, ".", "/") + "/" + snakeCase(opts.MessageName) + ".proto"
+	}
+	if opts.UnnamedFieldNamer == nil {
+		opts.UnnamedFieldNamer = spanvalue.IndexedUnnamedFieldNamer
+	}
+	b := &schemaBuilder{
+		opts:  opts,
+		files: new(protoregistry.Files),
+		deps:  make(map[string]bool),
+	}
+	msg, err := b.message(opts.MessageName, "."+opts.Package, rowType.GetFields())
+	if err != nil {
+		return nil, err
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(opts.FileName),
+		Package:     proto.String(opts.Package),
+		Dependency:  b.depList,
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+		Syntax:      proto.String("proto3"),
+	}
+	if b.closedEnums {
+		useEdition2023(fdp)
+	}
+	file, err := protodesc.NewFile(fdp, b.files)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{
+		rowType:  rowType,
+		fileDesc: fdp,
+		message:  file.Messages().Get(0),
+		opts:     opts,
+	}, nil
+}
+
+// Descriptor returns the descriptor of the row message.
+func (c *Codec) Descriptor() protoreflect.MessageDescriptor {
+	return c.message
+}
+
+// FileDescriptorProto returns the generated file, which imports the files of
+// google.protobuf.Timestamp and of resolved PROTO and ENUM types as needed.
+// Callers must not modify it.
+func (c *Codec) FileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	return c.fileDesc
+}
+
+// MessageFromRow converts row to a message; see [Codec.Message].
+func (c *Codec) MessageFromRow(row *spanner.Row) (*dynamicpb.Message, error) {
+	values := make([]spanner.GenericColumnValue, row.Size())
+	for i := range values {
+		if err := row.Column(i, &values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return c.Message(values)
+}
+
+// Message converts one row of values, which must match the column types of
+// the codec, to a message.
+func (c *Codec) Message(values []spanner.GenericColumnValue) (*dynamicpb.Message, error) {
+	fields := c.rowType.GetFields()
+	if len(values) != len(fields) {
+		return nil, fmt.Errorf("%w: got %d values, want %d", ErrValueCount, len(values), len(fields))
+	}
+	m := dynamicpb.NewMessage(c.message)
+	for i, v := range values {
+		fd := c.message.Fields().Get(i)
+		if !internal.TypesEquivalent(fields[i].GetType(), v.Type) {
+			return nil, fmt.Errorf("%w: column %q has type %s, want %s", ErrTypeMismatch, fd.Name(), v.Type, fields[i].GetType())
+		}
+		if err := c.setField(m, fd, fields[i].GetType(), v.Value); err != nil {
+			return nil, fmt.Errorf("column %q: %w", fd.Name(), err)
+		}
+	}
+	return m, nil
+}
+
+// Marshal converts one row of values to a message serialized in format.
+func (c *Codec) Marshal(format Format, values []spanner.GenericColumnValue) ([]byte, error) {
+	m, err := c.Message(values)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatBinary:
+		return proto.Marshal(m)
+	case FormatJSON:
+		return protojson.MarshalOptions{Resolver: c.opts.Resolver}.Marshal(m)
+	case FormatText:
+		return prototext.MarshalOptions{Resolver: c.opts.Resolver}.Marshal(m)
+	default:
+		return nil, fmt.Errorf("unknown format %v", format)
+	}
+}
+
+// Unmarshal parses a message serialized in format and returns its values.
+func (c *Codec) Unmarshal(format Format, data []byte) ([]spanner.GenericColumnValue, error) {
+	m := dynamicpb.NewMessage(c.message)
+	var err error
+	switch format {
+	case FormatBinary:
+		err = proto.UnmarshalOptions{Resolver: c.opts.Resolver}.Unmarshal(data, m)
+	case FormatJSON:
+		err = protojson.UnmarshalOptions{Resolver: c.opts.Resolver}.Unmarshal(data, m)
+	case FormatText:
+		err = prototext.UnmarshalOptions{Resolver: c.opts.Resolver}.Unmarshal(data, m)
+	default:
+		err = fmt.Errorf("unknown format %v", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.Values(m)
+}
+
+// Values converts a row message back to values typed by the row type of the
+// codec. Unset fields become NULL.
+func (c *Codec) Values(m protoreflect.Message) ([]spanner.GenericColumnValue, error) {
+	if m.Descriptor().FullName() != c.message.FullName() {
+		return nil, fmt.Errorf("%w: message %s, want %s", ErrTypeMismatch, m.Descriptor().FullName(), c.message.FullName())
+	}
+	fields := c.rowType.GetFields()
+	values := make([]spanner.GenericColumnValue, len(fields))
+	for i, field := range fields {
+		fd := c.message.Fields().Get(i)
+		v, err := c.fieldValue(m, fd, field.GetType())
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", fd.Name(), err)
+		}
+		values[i] = spanner.GenericColumnValue{Type: field.GetType(), Value: v}
+	}
+	return values, nil
+}
+
+// wrapsElements reports whether elements of an ARRAY of typ are wrapped in a
+// message with a single value field.
+func (c *Codec) wrapsElements(elem *sppb.Type) bool {
+	return c.opts.NullableElements || elem.GetCode() == sppb.TypeCode_ARRAY
+}
+
+// setField stores the wire value v of typ in field fd of m; NULL leaves the
+// field unset.
+func (c *Codec) setField(m protoreflect.Message, fd protoreflect.FieldDescriptor, typ *sppb.Type, v *structpb.Value) error {
+	if isNull(v) {
+		return nil
+	}
+	if typ.GetCode() != sppb.TypeCode_ARRAY {
+		val, err := c.singular(func() protoreflect.Message { return m.NewField(fd).Message() }, fd, typ, v)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, val)
+		return nil
+	}
+	elemType := typ.GetArrayElementType()
+	list := m.Mutable(fd).List()
+	for i, elem := range v.GetListValue().GetValues() {
+		if c.wrapsElements(elemType) {
+			wrapper := list.NewElement().Message()
+			if err := c.setField(wrapper, wrapper.Descriptor().Fields().Get(0), elemType, elem); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+			list.Append(protoreflect.ValueOfMessage(wrapper))
+			continue
+		}
+		if isNull(elem) {
+			return fmt.Errorf("element %d: %w", i, ErrNullElement)
+		}
+		val, err := c.singular(func() protoreflect.Message { return list.NewElement().Message() }, fd, elemType, elem)
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		list.Append(val)
+	}
+	return nil
+}
+
+// singular converts the non-NULL, non-ARRAY wire value v of typ to a value of
+// field fd; newMessage allocates messages for message fields.
+func (c *Codec) singular(newMessage func() protoreflect.Message, fd protoreflect.FieldDescriptor, typ *sppb.Type, v *structpb.Value) (protoreflect.Value, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_STRUCT:
+		sm := newMessage()
+		values := v.GetListValue().GetValues()
+		fields := typ.GetStructType().GetFields()
+		if len(values) != len(fields) {
+			return protoreflect.Value{}, fmt.Errorf("%w: got %d struct values, want %d", ErrValueCount, len(values), len(fields))
+		}
+		for i, field := range fields {
+			sfd := sm.Descriptor().Fields().Get(i)
+			if err := c.setField(sm, sfd, field.GetType(), values[i]); err != nil {
+				return protoreflect.Value{}, fmt.Errorf("field %q: %w", sfd.Name(), err)
+			}
+		}
+		return protoreflect.ValueOfMessage(sm), nil
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		tm := newMessage()
+		tm.Set(tm.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		tm.Set(tm.Descriptor().Fields().ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+		return protoreflect.ValueOfMessage(tm), nil
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("BOOL wire kind %T", v.GetKind())
+		}
+		return protoreflect.ValueOfBool(b.BoolValue), nil
+	case protoreflect.Int64Kind:
+		n, err := strconv.ParseInt(v.GetStringValue(), 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.EnumKind:
+		n, err := strconv.ParseInt(v.GetStringValue(), 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.FloatKind:
+		f, err := internal.FloatFromWire(v)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := internal.FloatFromWire(v)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(v.GetStringValue()), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.MessageKind:
+		// A PROTO column with a resolved message type.
+		b, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		pm := newMessage()
+		if err := (proto.UnmarshalOptions{Resolver: c.opts.Resolver}).Unmarshal(b, pm.Interface()); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(pm), nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("%w: %s", ErrUnsupportedType, typ.GetCode())
+	}
+}
+
+// fieldValue returns the wire value of typ stored in field fd of m.
+func (c *Codec) fieldValue(m protoreflect.Message, fd protoreflect.FieldDescriptor, typ *sppb.Type) (*structpb.Value, error) {
+	if typ.GetCode() != sppb.TypeCode_ARRAY {
+		if !m.Has(fd) {
+			return structpb.NewNullValue(), nil
+		}
+		return c.wireValue(fd, typ, m.Get(fd))
+	}
+	elemType := typ.GetArrayElementType()
+	list := m.Get(fd).List()
+	values := make([]*structpb.Value, list.Len())
+	for i := range list.Len() {
+		var (
+			v   *structpb.Value
+			err error
+		)
+		if c.wrapsElements(elemType) {
+			wrapper := list.Get(i).Message()
+			v, err = c.fieldValue(wrapper, wrapper.Descriptor().Fields().Get(0), elemType)
+		} else {
+			v, err = c.wireValue(fd, elemType, list.Get(i))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		values[i] = v
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+// wireValue converts val of field fd, holding a non-ARRAY value of typ, to
+// its wire value.
+func (c *Codec) wireValue(fd protoreflect.FieldDescriptor, typ *sppb.Type, val protoreflect.Value) (*structpb.Value, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_STRUCT:
+		sm := val.Message()
+		fields := typ.GetStructType().GetFields()
+		values := make([]*structpb.Value, len(fields))
+		for i, field := range fields {
+			sfd := sm.Descriptor().Fields().Get(i)
+			v, err := c.fieldValue(sm, sfd, field.GetType())
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", sfd.Name(), err)
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case sppb.TypeCode_TIMESTAMP:
+		tm := val.Message()
+		seconds := tm.Get(tm.Descriptor().Fields().ByName("seconds")).Int()
+		nanos := tm.Get(tm.Descriptor().Fields().ByName("nanos")).Int()
+		t := time.Unix(seconds, nanos).UTC()
+		return structpb.NewStringValue(t.Format(time.RFC3339Nano)), nil
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return structpb.NewBoolValue(val.Bool()), nil
+	case protoreflect.Int64Kind:
+		return structpb.NewStringValue(strconv.FormatInt(val.Int(), 10)), nil
+	case protoreflect.EnumKind:
+		return structpb.NewStringValue(strconv.FormatInt(int64(val.Enum()), 10)), nil
+	case protoreflect.FloatKind:
+		return gcvctor.Float32Value(float32(val.Float())).Value, nil
+	case protoreflect.DoubleKind:
+		return gcvctor.Float64Value(val.Float()).Value, nil
+	case protoreflect.StringKind:
+		return structpb.NewStringValue(val.String()), nil
+	case protoreflect.BytesKind:
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString(val.Bytes())), nil
+	case protoreflect.MessageKind:
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(val.Message().Interface())
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString(b)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, typ.GetCode())
+	}
+}
+
+func isNull(v *structpb.Value) bool {
+	_, isNull := v.GetKind().(*structpb.Value_NullValue)
+	return v == nil || isNull
+}
+
+// schemaBuilder builds the descriptor of a row type, collecting the files the
+// generated file imports.
+type schemaBuilder struct {
+	opts    Options
+	files   *protoregistry.Files
+	deps    map[string]bool
+	depList []string
+	// closedEnums records a resolved closed (proto2) enum, which proto3
+	// files cannot reference.
+	closedEnums bool
+}
+
+// message builds the message fullName, named name, with a field per field of
+// fields.
+func (b *schemaBuilder) message(name, parent string, fields []*sppb.StructType_Field) (*descriptorpb.DescriptorProto, error) {
+	fullName := parent + "." + name
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.GetName()
+	}
+	names, err := internal.ResolveColumnNames(names, b.opts.UnnamedFieldNamer)
+	if err != nil {
+		return nil, err
+	}
+	// Fields, nested messages, and synthetic oneofs share one namespace;
+	// field JSON names must be unique as well.
+	used := map[string]bool{}
+	jsonNames := map[string]bool{}
+	for i, name := range names {
+		names[i] = uniqueFieldName(used, jsonNames, fieldNameOf(name))
+	}
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	for i, f := range fields {
+		if err := b.field(msg, fullName, used, names[i], int32(i+1), f.GetType()); err != nil {
+			return nil, fmt.Errorf("field %q: %w", names[i], err)
+		}
+	}
+	for _, fd := range msg.Field {
+		if fd.GetProto3Optional() {
+			oneof := "_" + fd.GetName()
+			for used[oneof] {
+				oneof = "X" + oneof
+			}
+			used[oneof] = true
+			fd.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(oneof)})
+		}
+	}
+	return msg, nil
+}
+
+// field adds field name of typ to msg, nesting messages for STRUCT types and
+// wrapped ARRAY elements under names not yet in used.
+func (b *schemaBuilder) field(msg *descriptorpb.DescriptorProto, fullName string, used map[string]bool, name string, number int32, typ *sppb.Type) error {
+	fd := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if typ.GetCode() == sppb.TypeCode_ARRAY {
+		fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		elem := typ.GetArrayElementType()
+		if b.opts.NullableElements || elem.GetCode() == sppb.TypeCode_ARRAY {
+			wrapperName := uniqueName(used, camelCase(name)+"Element")
+			wrapper := &descriptorpb.DescriptorProto{Name: proto.String(wrapperName)}
+			if err := b.field(wrapper, fullName+"."+wrapperName, map[string]bool{"value": true, "_value": true}, "value", 1, elem); err != nil {
+				return err
+			}
+			if f := wrapper.Field[0]; f.GetProto3Optional() {
+				f.OneofIndex = proto.Int32(0)
+				wrapper.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("_value")}}
+			}
+			msg.NestedType = append(msg.NestedType, wrapper)
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fd.TypeName = proto.String(fullName + "." + wrapperName)
+			msg.Field = append(msg.Field, fd)
+			return nil
+		}
+		typ = elem
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+	case sppb.TypeCode_INT64:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+	case sppb.TypeCode_FLOAT32:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_FLOAT.Enum()
+	case sppb.TypeCode_FLOAT64:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+	case sppb.TypeCode_STRING, sppb.TypeCode_NUMERIC, sppb.TypeCode_DATE, sppb.TypeCode_JSON,
+		sppb.TypeCode_UUID, sppb.TypeCode_INTERVAL:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	case sppb.TypeCode_BYTES:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+	case sppb.TypeCode_TIMESTAMP:
+		md := (&timestamppb.Timestamp{}).ProtoReflect().Descriptor()
+		b.addDependency(md.ParentFile())
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		fd.TypeName = proto.String("." + string(md.FullName()))
+	case sppb.TypeCode_PROTO:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+		md, err := b.resolveMessage(typ.GetProtoTypeFqn())
+		if err != nil {
+			return err
+		}
+		if md != nil {
+			b.addDependency(md.ParentFile())
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fd.TypeName = proto.String("." + string(md.FullName()))
+		}
+	case sppb.TypeCode_ENUM:
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		ed, err := b.resolveEnum(typ.GetProtoTypeFqn())
+		if err != nil {
+			return err
+		}
+		if ed != nil {
+			b.closedEnums = b.closedEnums || ed.IsClosed()
+			b.addDependency(ed.ParentFile())
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+			fd.TypeName = proto.String("." + string(ed.FullName()))
+		}
+	case sppb.TypeCode_STRUCT:
+		nested, err := b.message(uniqueName(used, camelCase(name)), fullName, typ.GetStructType().GetFields())
+		if err != nil {
+			return err
+		}
+		msg.NestedType = append(msg.NestedType, nested)
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		fd.TypeName = proto.String(fullName + "." + nested.GetName())
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, typ.GetCode())
+	}
+	if fd.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL && fd.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		fd.Proto3Optional = proto.Bool(true)
+	}
+	msg.Field = append(msg.Field, fd)
+	return nil
+}
+
+func (b *schemaBuilder) resolveMessage(fqn string) (protoreflect.MessageDescriptor, error) {
+	if b.opts.Resolver == nil || fqn == "" {
+		return nil, nil
+	}
+	mt, err := b.opts.Resolver.FindMessageByName(protoreflect.FullName(fqn))
+	if errors.Is(err, protoregistry.NotFound) || (err == nil && mt == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mt.Descriptor(), nil
+}
+
+func (b *schemaBuilder) resolveEnum(fqn string) (protoreflect.EnumDescriptor, error) {
+	if b.opts.Resolver == nil || fqn == "" {
+		return nil, nil
+	}
+	et, err := b.opts.Resolver.FindEnumByName(protoreflect.FullName(fqn))
+	if errors.Is(err, protoregistry.NotFound) || (err == nil && et == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return et.Descriptor(), nil
+}
+
+// addDependency imports file into the generated file and registers it, with
+// its own imports, for building the descriptor.
+func (b *schemaBuilder) addDependency(file protoreflect.FileDescriptor) {
+	if !b.deps[file.Path()] {
+		b.deps[file.Path()] = true
+		b.depList = append(b.depList, file.Path())
+	}
+	b.register(file)
+}
+
+func (b *schemaBuilder) register(file protoreflect.FileDescriptor) {
+	if _, err := b.files.FindFileByPath(file.Path()); err == nil {
+		return
+	}
+	imports := file.Imports()
+	for i := range imports.Len() {
+		b.register(imports.Get(i).FileDescriptor)
+	}
+	// Registration only fails for conflicting files, which resolved
+	// descriptors of one resolver do not have; protodesc.NewFile reports
+	// any file left missing.
+	_ = b.files.RegisterFile(file)
+}
+
+// useEdition2023 converts a generated proto3 file to edition 2023, where
+// singular fields have explicit presence without proto3 optional.
+func useEdition2023(fdp *descriptorpb.FileDescriptorProto) {
+	fdp.Syntax = proto.String("editions")
+	fdp.Edition = descriptorpb.Edition_EDITION_2023.Enum()
+	var convert func(msg *descriptorpb.DescriptorProto)
+	convert = func(msg *descriptorpb.DescriptorProto) {
+		msg.OneofDecl = nil
+		for _, fd := range msg.Field {
+			fd.Proto3Optional = nil
+			fd.OneofIndex = nil
+		}
+		for _, nested := range msg.NestedType {
+			convert(nested)
+		}
+	}
+	for _, msg := range fdp.MessageType {
+		convert(msg)
+	}
+}
+
+// fieldNameOf returns name with characters invalid in protobuf identifiers
+// replaced by underscores.
+func fieldNameOf(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// uniqueName returns name, or name with the smallest numeric suffix not yet in
+// used, and records it.
+func uniqueName(used map[string]bool, name string) string {
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = name + "_" + strconv.Itoa(n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// uniqueFieldName is uniqueName for field names, which must also differ from
+// jsonNames in their lowerCamel JSON names: protojson and protoc reject fields
+// such as foo_bar and fooBar in one message.
+func uniqueFieldName(used, jsonNames map[string]bool, name string) string {
+	candidate := name
+	for n := 2; used[candidate] || jsonNames[lowerCamelCase(candidate)]; n++ {
+		candidate = name + "_" + strconv.Itoa(n)
+	}
+	used[candidate] = true
+	jsonNames[lowerCamelCase(candidate)] = true
+	return candidate
+}
+
+// lowerCamelCase converts a field name such as "foo_bar" or "Foo_bar" to the
+// JSON name protoc compares, "fooBar".
+func lowerCamelCase(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+			continue
+		case sb.Len() == 0 && r >= 'A' && r <= 'Z':
+			r += 'a' - 'A'
+		case upper && r >= 'a' && r <= 'z':
+			r -= 'a' - 'A'
+		}
+		sb.WriteRune(r)
+		upper = false
+	}
+	return sb.String()
+}
+
+// camelCase converts a field name such as "home_address" to "HomeAddress".
+func camelCase(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		sb.WriteRune(r)
+		upper = false
+	}
+	if sb.Len() == 0 || sb.String()[0] < 'A' || sb.String()[0] > 'Z' {
+		return "Field" + sb.String()
+	}
+	return sb.String()
+}
+
+// snakeCase converts a message name such as "SingerRow" to "singer_row".
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,325 @@
+package rowproto_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+	"github.com/apstndb/spanvalue/rowproto"
+)
+
+const (
+	durationFQN  = "google.protobuf.Duration"
+	fieldTypeFQN = "google.protobuf.FieldDescriptorProto.Type"
+)
+
+func rowTypeOf(values []spanner.GenericColumnValue, names ...string) *sppb.StructType {
+	types := make([]*sppb.Type, len(values))
+	for i, v := range values {
+		types[i] = v.Type
+	}
+	return typector.MustNameTypeSlicesToStructType(names, types).GetStructType()
+}
+
+func mustNewCodec(t *testing.T, rowType *sppb.StructType, opts rowproto.Options) *rowproto.Codec {
+	t.Helper()
+	c, err := rowproto.NewCodec(rowType, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCodec_protoFile(t *testing.T) {
+	t.Parallel()
+
+	address := gcvctor.MustStructValueOf([]string{"city", ""}, []spanner.GenericColumnValue{gcvctor.StringValue("Tokyo"), gcvctor.Int64Value(1)})
+	values := []spanner.GenericColumnValue{
+		gcvctor.Int64Value(1),
+		gcvctor.TimestampValue(time.Unix(0, 0)),
+		gcvctor.MustArrayValue(gcvctor.StringValue("a")),
+		address,
+		gcvctor.MustArrayValue(address),
+		gcvctor.ProtoValue(durationFQN, nil),
+		gcvctor.EnumValue(fieldTypeFQN, 1),
+	}
+	c := mustNewCodec(t, rowTypeOf(values, "id", "created-at", "tags", "home_address", "addresses", "p", "e"), rowproto.Options{
+		Package:     "example.music",
+		MessageName: "SingerRow",
+	})
+
+	want := `// Code generated by rowproto. DO NOT EDIT.
+
+syntax = "proto3";
+
+package example.music;
+
+import "google/protobuf/timestamp.proto";
+
+message SingerRow {
+  optional int64 id = 1;
+  .google.protobuf.Timestamp created_at = 2;
+  repeated string tags = 3;
+  .example.music.SingerRow.HomeAddress home_address = 4;
+  repeated .example.music.SingerRow.Addresses addresses = 5;
+  optional bytes p = 6;
+  optional int64 e = 7;
+
+  message HomeAddress {
+    optional string city = 1;
+    optional int64 _0 = 2;
+  }
+
+  message Addresses {
+    optional string city = 1;
+    optional int64 _0 = 2;
+  }
+}
+`
+	if diff := cmp.Diff(want, c.ProtoFile()); diff != "" {
+		t.Errorf("ProtoFile mismatch (-want +got):\n%s", diff)
+	}
+	if got := c.FileDescriptorProto().GetName(); got != "example/music/singer_row.proto" {
+		t.Errorf("file name = %q, want example/music/singer_row.proto", got)
+	}
+	if got := c.Descriptor().FullName(); got != "example.music.SingerRow" {
+		t.Errorf("message = %s, want example.music.SingerRow", got)
+	}
+}
+
+func TestCodec_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	st := gcvctor.MustStructValueOf([]string{"x", "y"}, []spanner.GenericColumnValue{gcvctor.Int64Value(7), gcvctor.NullFromCode(sppb.TypeCode_STRING)})
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(1),
+			gcvctor.BoolValue(true),
+			gcvctor.Float32Value(1.5),
+			gcvctor.Float64Value(-2.25),
+			gcvctor.StringValue("s"),
+			gcvctor.BytesValue([]byte("hi")),
+			gcvctor.NumericValue(big.NewRat(12345, 100)),
+			gcvctor.MustDateStringValue("2024-01-02"),
+			gcvctor.TimestampValue(time.Date(1969, 12, 31, 23, 59, 59, 500, time.UTC)),
+			gcvctor.MustJSONStringValue(`{"a":1}`),
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.Int64Value(2)),
+			st,
+		},
+		{
+			gcvctor.NullFromCode(sppb.TypeCode_INT64),
+			gcvctor.NullFromCode(sppb.TypeCode_BOOL),
+			gcvctor.Float32Value(float32(math.Inf(1))),
+			gcvctor.Float64Value(0),
+			gcvctor.StringValue(""),
+			gcvctor.NullFromCode(sppb.TypeCode_BYTES),
+			gcvctor.NullFromCode(sppb.TypeCode_NUMERIC),
+			gcvctor.NullFromCode(sppb.TypeCode_DATE),
+			gcvctor.NullFromCode(sppb.TypeCode_TIMESTAMP),
+			gcvctor.NullFromCode(sppb.TypeCode_JSON),
+			gcvctor.EmptyArrayFromCode(sppb.TypeCode_INT64),
+			gcvctor.NullOf(st.Type),
+		},
+	}
+	c := mustNewCodec(t, rowTypeOf(rows[0], "id", "b", "f32", "f64", "s", "by", "n", "d", "ts", "j", "arr", "st"), rowproto.Options{})
+
+	for _, format := range []rowproto.Format{rowproto.FormatBinary, rowproto.FormatJSON, rowproto.FormatText} {
+		for i, row := range rows {
+			data, err := c.Marshal(format, row)
+			if err != nil {
+				t.Fatalf("%v row %d: %v", format, i, err)
+			}
+			got, err := c.Unmarshal(format, data)
+			if err != nil {
+				t.Fatalf("%v row %d: %v", format, i, err)
+			}
+			if diff := cmp.Diff(row, got, protocmp.Transform()); diff != "" {
+				t.Errorf("%v row %d mismatch (-want +got):\n%s", format, i, diff)
+			}
+		}
+	}
+
+	data, err := c.Marshal(rowproto.FormatJSON, rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"id":"1"`, `"ts":"1969-12-31T23:59:59.000000500Z"`, `"st":{"x":"7"}`} {
+		if !strings.Contains(strings.ReplaceAll(string(data), " ", ""), s) {
+			t.Errorf("protojson %s lacks %s", data, s)
+		}
+	}
+}
+
+func TestCodec_jsonNameCollision(t *testing.T) {
+	t.Parallel()
+
+	// foo_bar, fooBar, and Foo_bar share the lowerCamel JSON name fooBar, so
+	// the later fields are renamed.
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.Int64Value(2), gcvctor.Int64Value(3)}
+	c := mustNewCodec(t, rowTypeOf(row, "foo_bar", "fooBar", "Foo_bar"), rowproto.Options{})
+	var names []string
+	fields := c.Descriptor().Fields()
+	for i := range fields.Len() {
+		names = append(names, fields.Get(i).JSONName())
+	}
+	if diff := cmp.Diff([]string{"fooBar", "fooBar2", "FooBar3"}, names); diff != "" {
+		t.Errorf("JSON names mismatch (-want +got):\n%s", diff)
+	}
+	data, err := c.Marshal(rowproto.FormatJSON, row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Unmarshal(rowproto.FormatJSON, data)
+	if err != nil {
+		t.Fatalf("Unmarshal(%s): %v", data, err)
+	}
+	if diff := cmp.Diff(row, got, protocmp.Transform()); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCodec_resolver(t *testing.T) {
+	t.Parallel()
+
+	payload, err := proto.Marshal(durationpb.New(1500 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []spanner.GenericColumnValue{
+		gcvctor.ProtoValue(durationFQN, payload),
+		gcvctor.EnumValue(fieldTypeFQN, int64(descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+		gcvctor.MustArrayValue(gcvctor.EnumValue(fieldTypeFQN, 1)),
+		gcvctor.ProtoValue("example.Unknown", []byte("x")),
+	}
+	c := mustNewCodec(t, rowTypeOf(row, "p", "e", "es", "u"), rowproto.Options{Resolver: protoregistry.GlobalTypes})
+
+	fields := c.Descriptor().Fields()
+	if got := fields.Get(0).Message().FullName(); got != durationFQN {
+		t.Errorf("p message = %s, want %s", got, durationFQN)
+	}
+	if got := fields.Get(1).Enum().FullName(); got != fieldTypeFQN {
+		t.Errorf("e enum = %s, want %s", got, fieldTypeFQN)
+	}
+	if got := fields.Get(3).Kind(); got != protoreflect.BytesKind {
+		t.Errorf("unresolved kind = %v, want bytes", got)
+	}
+	if diff := cmp.Diff([]string{"google/protobuf/duration.proto", "google/protobuf/descriptor.proto"}, c.FileDescriptorProto().GetDependency()); diff != "" {
+		t.Errorf("dependencies mismatch (-want +got):\n%s", diff)
+	}
+	// FieldDescriptorProto.Type is a closed proto2 enum.
+	if got := c.FileDescriptorProto().GetEdition(); got != descriptorpb.Edition_EDITION_2023 {
+		t.Errorf("edition = %v, want EDITION_2023", got)
+	}
+	if !strings.Contains(c.ProtoFile(), "edition = \"2023\";\n\npackage spanvalue.rowproto;\n\nimport \"google/protobuf/duration.proto\";\nimport \"google/protobuf/descriptor.proto\";\n\nmessage Row {\n  .google.protobuf.Duration p = 1;\n  .google.protobuf.FieldDescriptorProto.Type e = 2;\n") {
+		t.Errorf("ProtoFile:\n%s", c.ProtoFile())
+	}
+
+	m, err := c.Message(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		t.Fatal(err)
+	}
+	got := s.AsMap()
+	want := map[string]any{"p": "1.500s", "e": "TYPE_STRING", "es": []any{"TYPE_DOUBLE"}, "u": "eA=="}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("protojson mismatch (-want +got):\n%s", diff)
+	}
+
+	values, err := c.Values(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(row, values, protocmp.Transform()); diff != "" {
+		t.Errorf("Values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCodec_arrayElements(t *testing.T) {
+	t.Parallel()
+
+	nested := spanner.GenericColumnValue{
+		Type: typector.ElemTypeToArrayType(typector.ElemCodeToArrayType(sppb.TypeCode_INT64)),
+		Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.Int64Value(2)).Value,
+			gcvctor.EmptyArrayFromCode(sppb.TypeCode_INT64).Value,
+		}}),
+	}
+	withNull := gcvctor.MustArrayValue(gcvctor.StringValue("a"), gcvctor.NullFromCode(sppb.TypeCode_STRING))
+	row := []spanner.GenericColumnValue{nested, withNull}
+	rowType := rowTypeOf(row, "grid", "tags")
+
+	c := mustNewCodec(t, rowType, rowproto.Options{})
+	if _, err := c.Message(row); !errors.Is(err, rowproto.ErrNullElement) {
+		t.Errorf("NULL element error = %v, want ErrNullElement", err)
+	}
+	if !strings.Contains(c.ProtoFile(), "  message GridElement {\n    repeated int64 value = 1;\n  }\n") {
+		t.Errorf("ProtoFile lacks the GridElement wrapper:\n%s", c.ProtoFile())
+	}
+
+	c = mustNewCodec(t, rowType, rowproto.Options{NullableElements: true})
+	if !strings.Contains(c.ProtoFile(), "  message TagsElement {\n    optional string value = 1;\n  }\n") {
+		t.Errorf("ProtoFile lacks the TagsElement wrapper:\n%s", c.ProtoFile())
+	}
+	data, err := c.Marshal(rowproto.FormatBinary, row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Unmarshal(rowproto.FormatBinary, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(row, got, protocmp.Transform()); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCodec_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := rowproto.NewCodec(typector.MustNameCodeSlicesToStructType([]string{"a"}, []sppb.TypeCode{sppb.TypeCode_TYPE_CODE_UNSPECIFIED}).GetStructType(), rowproto.Options{}); !errors.Is(err, rowproto.ErrUnsupportedType) {
+		t.Errorf("unsupported type error = %v, want ErrUnsupportedType", err)
+	}
+
+	rowType := typector.MustNameCodeSlicesToStructType([]string{"id", "id", "1st"}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_STRING, sppb.TypeCode_BOOL}).GetStructType()
+	c := mustNewCodec(t, rowType, rowproto.Options{})
+	var names []string
+	for i := range c.Descriptor().Fields().Len() {
+		names = append(names, string(c.Descriptor().Fields().Get(i).Name()))
+	}
+	if diff := cmp.Diff([]string{"id", "id_2", "_1st"}, names); diff != "" {
+		t.Errorf("field names mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := c.Message([]spanner.GenericColumnValue{gcvctor.Int64Value(1)}); !errors.Is(err, rowproto.ErrValueCount) {
+		t.Errorf("short row error = %v, want ErrValueCount", err)
+	}
+	if _, err := c.Message([]spanner.GenericColumnValue{gcvctor.StringValue("1"), gcvctor.StringValue("a"), gcvctor.BoolValue(true)}); !errors.Is(err, rowproto.ErrTypeMismatch) {
+		t.Errorf("mismatched value error = %v, want ErrTypeMismatch", err)
+	}
+	if _, err := c.Values(durationpb.New(time.Second).ProtoReflect()); !errors.Is(err, rowproto.ErrTypeMismatch) {
+		t.Errorf("foreign message error = %v, want ErrTypeMismatch", err)
+	}
+}
@@ -0,0 +1,220 @@
+package spanvalue
+
+import (
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	// ErrSkipSubtree is returned by a [WalkFunc] or [TransformFunc] to skip
+	// the children of the current value. [Walk] and [Transform] never return
+	// it themselves.
+	ErrSkipSubtree = errors.New("skip subtree")
+	// ErrMismatchedElementType is returned by [Transform] when the
+	// transformed non-NULL elements of one ARRAY do not share a single type.
+	ErrMismatchedElementType = errors.New("mismatched array element type")
+)
+
+// WalkFunc visits one value reached by [Walk]. path locates value inside the
+// top-level value; it is only valid during the call, so use [Path.Clone] to
+// retain it. Return [ErrSkipSubtree] to skip the children of value, or any
+// other error to stop the walk.
+type WalkFunc func(path Path, value spanner.GenericColumnValue) error
+
+// Walk calls fn for value and then, depth first in order, for every ARRAY
+// element and STRUCT field nested inside it. Each child is passed as a typed
+// [cloud.google.com/go/spanner.GenericColumnValue] (the element type for
+// ARRAY, the field type for STRUCT). NULL ARRAY and STRUCT values are visited
+// but have no children.
+//
+// Errors returned by fn, and malformed complex values
+// ([ErrUnexpectedComplexValueKind], [ErrMismatchedFields],
+// [ErrNilStructField]), are wrapped in a [*PathError] naming the value.
+func Walk(value spanner.GenericColumnValue, fn WalkFunc) error {
+	return walkValue(make(Path, 0, 8), value, fn)
+}
+
+func walkValue(path Path, value spanner.GenericColumnValue, fn WalkFunc) error {
+	err := fn(path, value)
+	if errors.Is(err, ErrSkipSubtree) {
+		return nil
+	}
+	if err != nil {
+		return wrapPathError(path, err)
+	}
+	elems, children, err := childValues(value)
+	if err != nil {
+		return wrapPathError(path, err)
+	}
+	for i, child := range children {
+		if err := walkValue(append(path, elems[i]), child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransformFunc returns the replacement for one value reached by
+// [Transform]. Return value unchanged to keep it. path follows the
+// [WalkFunc] contract. Return the replacement together with [ErrSkipSubtree]
+// to use it as-is without transforming its children; any other error stops
+// the transform.
+type TransformFunc func(path Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error)
+
+// Transform rebuilds value by calling fn top down: fn sees each value before
+// its children, and [Transform] then descends into the children of the
+// value fn returned. Parents whose children all come back unchanged are
+// returned as-is, so an identity fn allocates nothing.
+//
+// Rebuilt parents keep their types consistent with their children:
+//
+//   - A STRUCT takes the type of each transformed field; a field whose
+//     replacement has a nil Type keeps its original field type.
+//   - An ARRAY takes the type of its first non-NULL transformed element as
+//     its element type, and every other non-NULL element must have the same
+//     type ([ErrMismatchedElementType]). NULL elements and empty arrays keep
+//     the original element type.
+//
+// A type-changing fn should therefore be total over the type it rewrites,
+// otherwise rows of one column can end up with different types.
+// Errors follow the [Walk] contract.
+func Transform(value spanner.GenericColumnValue, fn TransformFunc) (spanner.GenericColumnValue, error) {
+	return transformValue(make(Path, 0, 8), value, fn)
+}
+
+func transformValue(path Path, value spanner.GenericColumnValue, fn TransformFunc) (spanner.GenericColumnValue, error) {
+	out, err := fn(path, value)
+	if errors.Is(err, ErrSkipSubtree) {
+		return out, nil
+	}
+	if err != nil {
+		return spanner.GenericColumnValue{}, wrapPathError(path, err)
+	}
+	return transformChildren(path, out, fn)
+}
+
+func transformChildren(path Path, value spanner.GenericColumnValue, fn TransformFunc) (spanner.GenericColumnValue, error) {
+	elems, children, err := childValues(value)
+	if err != nil {
+		return spanner.GenericColumnValue{}, wrapPathError(path, err)
+	}
+	changed := false
+	for i, child := range children {
+		got, err := transformValue(append(path, elems[i]), child, fn)
+		if err != nil {
+			return spanner.GenericColumnValue{}, err
+		}
+		if got.Type != child.Type || got.Value != child.Value {
+			changed = true
+		}
+		children[i] = got
+	}
+	if !changed {
+		return value, nil
+	}
+	if value.Type.GetCode() == sppb.TypeCode_ARRAY {
+		return rebuildArray(path, value.Type, children)
+	}
+	return rebuildStruct(value.Type, children), nil
+}
+
+// childValues returns the direct children of a non-NULL ARRAY or STRUCT
+// value as typed GCVs, with one path step per child. Scalars and NULLs have
+// no children.
+func childValues(value spanner.GenericColumnValue) ([]PathElem, []spanner.GenericColumnValue, error) {
+	code := value.Type.GetCode()
+	if !isComplexType(code) || IsNull(value) {
+		return nil, nil, nil
+	}
+	listValue, err := getComplexListValue(code, value.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := listValue.GetValues()
+	children := make([]spanner.GenericColumnValue, len(values))
+	if code == sppb.TypeCode_ARRAY {
+		elemType := value.Type.GetArrayElementType()
+		elems := make([]PathElem, len(values))
+		for i, v := range values {
+			elems[i] = IndexElem(i)
+			children[i] = typeValueToGCV(elemType, v)
+		}
+		return elems, children, nil
+	}
+
+	fields := value.Type.GetStructType().GetFields()
+	if len(values) != len(fields) {
+		return nil, nil, fmt.Errorf("%w: got %d values, want %d", ErrMismatchedFields, len(values), len(fields))
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		fieldType, err := structFieldType(field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("struct field %d: %w", i, err)
+		}
+		names[i] = field.GetName()
+		children[i] = typeValueToGCV(fieldType, values[i])
+	}
+	return structFieldElems(names), children, nil
+}
+
+func rebuildArray(path Path, typ *sppb.Type, elems []spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+	elemType := typ.GetArrayElementType()
+	for _, elem := range elems {
+		if !IsNull(elem) {
+			elemType = elem.Type
+			break
+		}
+	}
+	values := make([]*structpb.Value, len(elems))
+	for i, elem := range elems {
+		if !IsNull(elem) && !proto.Equal(elem.Type, elemType) {
+			return spanner.GenericColumnValue{}, &PathError{
+				Path: append(path.Clone(), IndexElem(i)),
+				Err: fmt.Errorf("%w: %v is not %v", ErrMismatchedElementType,
+					spantype.FormatTypeMoreVerbose(elem.Type), spantype.FormatTypeMoreVerbose(elemType)),
+			}
+		}
+		values[i] = wireValueOrNull(elem.Value)
+	}
+	if elemType != typ.GetArrayElementType() && !proto.Equal(elemType, typ.GetArrayElementType()) {
+		typ = proto.CloneOf(typ)
+		typ.ArrayElementType = elemType
+	}
+	return typeValueToGCV(typ, structpb.NewListValue(&structpb.ListValue{Values: values})), nil
+}
+
+func rebuildStruct(typ *sppb.Type, fields []spanner.GenericColumnValue) spanner.GenericColumnValue {
+	values := make([]*structpb.Value, len(fields))
+	var cloned *sppb.Type
+	for i, field := range fields {
+		values[i] = wireValueOrNull(field.Value)
+		fieldType := typ.GetStructType().GetFields()[i].GetType()
+		if field.Type == nil || field.Type == fieldType || proto.Equal(field.Type, fieldType) {
+			continue
+		}
+		if cloned == nil {
+			cloned = proto.CloneOf(typ)
+		}
+		cloned.GetStructType().GetFields()[i].Type = field.Type
+	}
+	if cloned != nil {
+		typ = cloned
+	}
+	return typeValueToGCV(typ, structpb.NewListValue(&structpb.ListValue{Values: values}))
+}
+
+// wireValueOrNull replaces a nil wire value with an explicit protobuf NULL so
+// rebuilt list values never contain nil elements.
+func wireValueOrNull(v *structpb.Value) *structpb.Value {
+	if v == nil {
+		return structpb.NewNullValue()
+	}
+	return v
+}
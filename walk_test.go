@@ -0,0 +1,260 @@
+package spanvalue_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"github.com/apstndb/spantype/typector"
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// orderFixture is STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>.
+func orderFixture() spanner.GenericColumnValue {
+	item := func(sku string, qty int64) spanner.GenericColumnValue {
+		return gcvctor.MustStructValueOf([]string{"sku", "qty"}, []spanner.GenericColumnValue{
+			gcvctor.StringValue(sku), gcvctor.Int64Value(qty),
+		})
+	}
+	first := item("a-1", 1)
+	return gcvctor.MustStructValueOf([]string{"id", "items", ""}, []spanner.GenericColumnValue{
+		gcvctor.Int64Value(7),
+		gcvctor.MustArrayValueOf(first.Type, first, item("b-2", 2), gcvctor.NullOf(first.Type)),
+		gcvctor.Int64Value(42),
+	})
+}
+
+func TestPathString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path spanvalue.Path
+		want string
+	}{
+		{nil, ""},
+		{spanvalue.Path{spanvalue.IndexElem(3)}, "[3]"},
+		{spanvalue.Path{spanvalue.FieldElem("order", 0), spanvalue.FieldElem("items", 1), spanvalue.IndexElem(2), spanvalue.FieldElem("sku", 0)}, "order.items[2].sku"},
+		{spanvalue.Path{spanvalue.IndexElem(0), spanvalue.FieldElem("", 1)}, "[0].1"},
+		{spanvalue.Path{spanvalue.FieldElem("first name", 0)}, "`first name`"},
+		{spanvalue.Path{spanvalue.FieldElem("0", 0)}, "`0`"},
+		{spanvalue.Path{spanvalue.FieldElem("a`b\\c", 0)}, "`a\\`b\\\\c`"},
+	}
+	for _, tt := range tests {
+		if got := tt.path.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	err := spanvalue.Walk(orderFixture(), func(path spanvalue.Path, value spanner.GenericColumnValue) error {
+		got = append(got, path.String()+" "+spantype.FormatTypeSimplest(value.Type))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		" STRUCT",
+		"id INT64",
+		"items ARRAY",
+		"items[0] STRUCT",
+		"items[0].sku STRING",
+		"items[0].qty INT64",
+		"items[1] STRUCT",
+		"items[1].sku STRING",
+		"items[1].qty INT64",
+		"items[2] STRUCT",
+		"2 INT64",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Walk visits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWalkSkipSubtree(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	err := spanvalue.Walk(orderFixture(), func(path spanvalue.Path, value spanner.GenericColumnValue) error {
+		got = append(got, path.String())
+		if value.Type.GetCode() == sppb.TypeCode_ARRAY {
+			return spanvalue.ErrSkipSubtree
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"", "id", "items", "2"}, got); diff != "" {
+		t.Errorf("Walk visits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWalkDuplicateFieldNamesUseOrdinal(t *testing.T) {
+	t.Parallel()
+
+	value := gcvctor.MustStructValueOf([]string{"a", "a", "b"}, []spanner.GenericColumnValue{
+		gcvctor.Int64Value(1), gcvctor.Int64Value(2), gcvctor.Int64Value(3),
+	})
+	var got []string
+	err := spanvalue.Walk(value, func(path spanvalue.Path, _ spanner.GenericColumnValue) error {
+		got = append(got, path.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"", "0", "1", "b"}, got); diff != "" {
+		t.Errorf("Walk visits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	t.Parallel()
+
+	errStop := errors.New("stop")
+	err := spanvalue.Walk(orderFixture(), func(path spanvalue.Path, _ spanner.GenericColumnValue) error {
+		if path.String() == "items[1].qty" {
+			return errStop
+		}
+		return nil
+	})
+	var pathErr *spanvalue.PathError
+	if !errors.As(err, &pathErr) || !errors.Is(err, errStop) {
+		t.Fatalf("Walk error = %v, want PathError wrapping errStop", err)
+	}
+	if got := pathErr.Path.String(); got != "items[1].qty" {
+		t.Errorf("PathError.Path = %q, want items[1].qty", got)
+	}
+	if got, want := err.Error(), "items[1].qty: stop"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	malformed := spanner.GenericColumnValue{
+		Type: typector.MustNameCodeSlicesToStructType([]string{"xs"}, []sppb.TypeCode{sppb.TypeCode_INT64}),
+		Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewStringValue("1"), structpb.NewStringValue("2"),
+		}}),
+	}
+	err = spanvalue.Walk(malformed, func(spanvalue.Path, spanner.GenericColumnValue) error { return nil })
+	if !errors.Is(err, spanvalue.ErrMismatchedFields) {
+		t.Errorf("malformed STRUCT: Walk error = %v, want ErrMismatchedFields", err)
+	}
+}
+
+func TestTransformIdentityKeepsValue(t *testing.T) {
+	t.Parallel()
+
+	in := orderFixture()
+	out, err := spanvalue.Transform(in, func(_ spanvalue.Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Type != in.Type || out.Value != in.Value {
+		t.Error("identity Transform rebuilt the value; want the input returned as-is")
+	}
+}
+
+func TestTransformMasksStrings(t *testing.T) {
+	t.Parallel()
+
+	out, err := spanvalue.Transform(orderFixture(), func(_ spanvalue.Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+		if value.Type.GetCode() == sppb.TypeCode_STRING && !spanvalue.IsNull(value) {
+			return gcvctor.StringValue(strings.Repeat("*", len(value.Value.GetStringValue()))), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := spanvalue.FormatColumnLiteral(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [("***", 1), ("***", 2), NULL], 42)`
+	if got != want {
+		t.Errorf("Transform result = %s, want %s", got, want)
+	}
+}
+
+func TestTransformRetypesParents(t *testing.T) {
+	t.Parallel()
+
+	// Rewrite every qty INT64 to STRING: the STRUCT element type and the
+	// ARRAY element type follow, including for the NULL element.
+	out, err := spanvalue.Transform(orderFixture(), func(path spanvalue.Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+		if len(path) == 3 && path[2].Name == "qty" {
+			return gcvctor.StringValue(value.Value.GetStringValue()), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := spanvalue.FormatColumnLiteral(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty STRING>>, INT64>(7, [("a-1", "1"), ("b-2", "2"), NULL], 42)`
+	if got != want {
+		t.Errorf("Transform result = %s, want %s", got, want)
+	}
+}
+
+func TestTransformMismatchedElementType(t *testing.T) {
+	t.Parallel()
+
+	in := gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.Int64Value(2))
+	_, err := spanvalue.Transform(in, func(path spanvalue.Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+		if len(path) == 1 && path[0].Index == 1 {
+			return gcvctor.StringValue("two"), nil
+		}
+		return value, nil
+	})
+	var pathErr *spanvalue.PathError
+	if !errors.Is(err, spanvalue.ErrMismatchedElementType) || !errors.As(err, &pathErr) {
+		t.Fatalf("Transform error = %v, want PathError wrapping ErrMismatchedElementType", err)
+	}
+	if got := pathErr.Path.String(); got != "[1]" {
+		t.Errorf("PathError.Path = %q, want [1]", got)
+	}
+}
+
+func TestTransformSkipSubtree(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	replacement := gcvctor.EmptyArrayOf(orderFixture().Type.GetStructType().GetFields()[1].GetType().GetArrayElementType())
+	out, err := spanvalue.Transform(orderFixture(), func(_ spanvalue.Path, value spanner.GenericColumnValue) (spanner.GenericColumnValue, error) {
+		calls++
+		if value.Type.GetCode() == sppb.TypeCode_ARRAY {
+			return replacement, spanvalue.ErrSkipSubtree
+		}
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("fn called %d times, want 4 (root, id, items, unnamed field)", calls)
+	}
+	got, err := spanvalue.FormatColumnLiteral(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `STRUCT<id INT64, items ARRAY<STRUCT<sku STRING, qty INT64>>, INT64>(7, [], 42)`; got != want {
+		t.Errorf("Transform result = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,648 @@
+package writer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/extensions"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// ArrowOption configures an ArrowWriter created by [NewArrowWriter] or
+// [NewArrowRecordWriter].
+type ArrowOption interface {
+	applyArrowOption(*ArrowWriter) error
+}
+
+type arrowOptionFunc func(*ArrowWriter) error
+
+func (f arrowOptionFunc) applyArrowOption(w *ArrowWriter) error {
+	return f(w)
+}
+
+func applyArrowOptions(w *ArrowWriter, options ...ArrowOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyArrowOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArrowIPCFormat selects the Arrow IPC format written by [NewArrowWriter].
+type ArrowIPCFormat int
+
+const (
+	// ArrowIPCStream writes the IPC streaming format (the default), readable
+	// incrementally from pipes.
+	ArrowIPCStream ArrowIPCFormat = iota
+	// ArrowIPCFile writes the IPC file format (Feather v2), which supports
+	// random access to record batches.
+	ArrowIPCFile
+)
+
+// String returns the Go constant name for f, or "ArrowIPCFormat(n)" for unknown values.
+func (f ArrowIPCFormat) String() string {
+	switch f {
+	case ArrowIPCStream:
+		return "ArrowIPCStream"
+	case ArrowIPCFile:
+		return "ArrowIPCFile"
+	default:
+		return fmt.Sprintf("ArrowIPCFormat(%d)", int(f))
+	}
+}
+
+// WithArrowIPCFormat selects the IPC format of [NewArrowWriter] (default
+// [ArrowIPCStream]). Unknown formats return [ErrInvalidArrowIPCFormat].
+// Writers from [NewArrowRecordWriter] ignore it.
+func WithArrowIPCFormat(format ArrowIPCFormat) ArrowOption {
+	return arrowOptionFunc(func(w *ArrowWriter) error {
+		if format < ArrowIPCStream || format > ArrowIPCFile {
+			return fmt.Errorf("%w: %v", ErrInvalidArrowIPCFormat, format)
+		}
+		w.format = format
+		return nil
+	})
+}
+
+// defaultArrowBatchRows is the record batch size unless [WithArrowBatchRows] is set.
+const defaultArrowBatchRows = 1024
+
+// WithArrowBatchRows sets the number of rows per record batch (default
+// 1024); a batch is emitted as soon as it is full. n == 0 selects the
+// default; negative n returns [ErrInvalidBatchRows].
+func WithArrowBatchRows(n int) ArrowOption {
+	return arrowOptionFunc(func(w *ArrowWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidBatchRows, n)
+		}
+		if n == 0 {
+			n = defaultArrowBatchRows
+		}
+		w.batchRows = n
+		return nil
+	})
+}
+
+// WithArrowAllocator sets the allocator of record batch buffers (default
+// [memory.DefaultAllocator]).
+func WithArrowAllocator(mem memory.Allocator) ArrowOption {
+	return arrowOptionFunc(func(w *ArrowWriter) error {
+		if mem == nil {
+			mem = memory.DefaultAllocator
+		}
+		w.mem = mem
+		return nil
+	})
+}
+
+// ArrowWriter builds Apache Arrow record batches from rows, for DuckDB,
+// Polars, pandas, and other Arrow consumers. The Arrow schema follows the row
+// type, with every field nullable:
+//
+//   - BOOL: bool; INT64 and ENUM: int64; FLOAT32: float32; FLOAT64: float64
+//   - STRING, INTERVAL, and PostgreSQL NUMERIC: utf8
+//   - BYTES and PROTO: binary
+//   - NUMERIC: decimal128(38, 9)
+//   - TIMESTAMP: timestamp[ns, tz=UTC]; DATE: date32
+//   - JSON: the arrow.json extension type over utf8
+//   - UUID: the arrow.uuid extension type over fixed_size_binary(16)
+//   - ARRAY: list of the element type; STRUCT: struct
+//
+// Unnamed columns and STRUCT fields are named with
+// [spanvalue.IndexedUnnamedFieldNamer] unless [WithUnnamedFieldNamer] is set.
+// Field types come from the registered row type, or from the first row when
+// only names are registered. Values are written typed, so [WithFormatter] has
+// no effect. Timestamps outside the int64 nanosecond range (years 1678 to
+// 2261) return an error.
+//
+// Rows are collected into batches of [WithArrowBatchRows] rows. A writer
+// from [NewArrowWriter] serializes each batch as Arrow IPC, and
+// [ArrowWriter.Flush] writes the partial batch and ends the stream or file, so
+// later Write* calls return [ErrWriterClosed]. A writer from
+// [NewArrowRecordWriter] hands each batch to a callback instead, and Flush
+// only emits the partial batch. A registered zero-column schema writes nothing.
+//
+// After the first output failure, every later Write*/Flush call returns that
+// error; discard the writer (see package doc "Write errors").
+type ArrowWriter struct {
+	stickyWriteError
+	// unnamedFieldNamer resolves empty column and STRUCT field names.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	format            ArrowIPCFormat
+	batchRows         int
+	mem               memory.Allocator
+
+	schema      columnSchema
+	types       []*sppb.Type
+	arrowSchema *arrow.Schema
+	builder     *array.RecordBuilder
+	rows        int
+	closed      bool
+	// emit receives each completed batch; ipcWriter is set for IPC output.
+	emit      func(arrow.Record) error
+	ipcWriter interface {
+		Write(arrow.Record) error
+		Close() error
+	}
+	out io.Writer
+}
+
+// NewArrowWriter returns a writer that serializes record batches to out in
+// the Arrow IPC format selected by [WithArrowIPCFormat].
+func NewArrowWriter(out io.Writer, options ...ArrowOption) (*ArrowWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := newArrowWriter()
+	w.out = out
+	if err := applyArrowOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// NewArrowRecordWriter returns a writer that passes each record batch to
+// emit instead of serializing it. The batch is released after emit returns;
+// call Retain on it to keep it longer. An error from emit is latched like an
+// output error. A nil emit returns [ErrNilOutputWriter].
+func NewArrowRecordWriter(emit func(arrow.Record) error, options ...ArrowOption) (*ArrowWriter, error) {
+	if emit == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := newArrowWriter()
+	w.emit = emit
+	if err := applyArrowOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func newArrowWriter() *ArrowWriter {
+	return &ArrowWriter{
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		batchRows:         defaultArrowBatchRows,
+		mem:               memory.DefaultAllocator,
+	}
+}
+
+// Schema returns the Arrow schema of the batches, or nil before field types
+// are known.
+func (w *ArrowWriter) Schema() *arrow.Schema {
+	return w.arrowSchema
+}
+
+// WriteRow appends one row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *ArrowWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *ArrowWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *ArrowWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues appends one row; see [DelimitedWriter.WriteValues].
+func (w *ArrowWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues appends one row; see [DelimitedWriter.WriteStructValues].
+func (w *ArrowWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs appends one row, emitting a batch once it is full; see
+// [DelimitedWriter.WriteGCVs]. Values must match the column types of the
+// schema. A value that fails to convert leaves the batch unchanged.
+func (w *ArrowWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	if w.builder == nil {
+		types := w.schema.types
+		if len(types) == 0 {
+			types = make([]*sppb.Type, len(values))
+			for i, v := range values {
+				types[i] = v.Type
+			}
+		}
+		if err := w.open(types); err != nil {
+			return err
+		}
+	}
+	// Convert every value before appending so a bad value cannot leave the
+	// column builders with different lengths.
+	natives := make([]any, len(values))
+	for i, v := range values {
+		name := w.arrowSchema.Field(i).Name
+		if !internal.TypesEquivalent(w.types[i], v.Type) {
+			return fmt.Errorf("%w: column %q has type %s, want %s", ErrArrowTypeMismatch, name, v.Type, w.types[i])
+		}
+		native, err := arrowNative(w.types[i], v.Value)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+		natives[i] = native
+	}
+	for i, native := range natives {
+		if err := appendArrowNative(w.builder.Field(i), native); err != nil {
+			// The columns may now differ in length, so the batch is unusable.
+			return w.latchWriteErr(fmt.Errorf("column %q: %w", w.arrowSchema.Field(i).Name, err))
+		}
+	}
+	w.rows++
+	if w.rows >= w.batchRows {
+		return w.emitBatch()
+	}
+	return nil
+}
+
+// Flush emits the partial batch. For IPC output it then writes the
+// end-of-stream marker or file footer, finishing the output; Flush after that
+// returns nil. A registered schema with no rows yields a stream or file
+// without batches. With no registered schema, Flush returns
+// [ErrMissingColumnNames]; with registered names but no field types and no
+// rows, it returns [ErrMissingFieldTypes]. After a write failure, Flush returns
+// the latched error (see package doc "Write errors").
+func (w *ArrowWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return nil
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	if w.builder == nil {
+		if len(w.schema.types) == 0 {
+			return ErrMissingFieldTypes
+		}
+		if err := w.open(w.schema.types); err != nil {
+			return err
+		}
+	}
+	if w.rows > 0 {
+		if err := w.emitBatch(); err != nil {
+			return err
+		}
+	}
+	if w.ipcWriter == nil {
+		return nil
+	}
+	w.closed = true
+	w.builder.Release()
+	return w.latchWriteErr(w.ipcWriter.Close())
+}
+
+// emitBatch hands the built rows to emit as one record and releases it.
+func (w *ArrowWriter) emitBatch() error {
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.rows = 0
+	return w.latchWriteErr(w.emit(rec))
+}
+
+// open builds the Arrow schema from types and prepares the batch builder
+// and the IPC writer.
+func (w *ArrowWriter) open(types []*sppb.Type) error {
+	fields := make([]*sppb.StructType_Field, len(w.schema.names))
+	for i, name := range w.schema.names {
+		fields[i] = &sppb.StructType_Field{Name: name, Type: types[i]}
+	}
+	arrowFields, err := w.arrowFields(fields)
+	if err != nil {
+		return err
+	}
+	schema := arrow.NewSchema(arrowFields, nil)
+	if w.out != nil {
+		var iw interface {
+			Write(arrow.Record) error
+			Close() error
+		}
+		if w.format == ArrowIPCFile {
+			fw, err := ipc.NewFileWriter(w.out, ipc.WithSchema(schema), ipc.WithAllocator(w.mem))
+			if err != nil {
+				return w.latchWriteErr(err)
+			}
+			iw = fw
+		} else {
+			iw = ipc.NewWriter(w.out, ipc.WithSchema(schema), ipc.WithAllocator(w.mem))
+		}
+		w.ipcWriter = iw
+		w.emit = iw.Write
+	}
+	w.types = types
+	w.arrowSchema = schema
+	w.builder = array.NewRecordBuilder(w.mem, schema)
+	return nil
+}
+
+func (w *ArrowWriter) arrowFields(fields []*sppb.StructType_Field) ([]arrow.Field, error) {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.GetName()
+	}
+	names, err := internal.ResolveColumnNames(names, w.unnamedFieldNamer)
+	if err != nil {
+		return nil, err
+	}
+	arrowFields := make([]arrow.Field, len(fields))
+	for i, f := range fields {
+		dt, err := w.arrowType(f.GetType())
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", names[i], err)
+		}
+		arrowFields[i] = arrow.Field{Name: names[i], Type: dt, Nullable: true}
+	}
+	return arrowFields, nil
+}
+
+// arrowType maps typ to its Arrow data type.
+func (w *ArrowWriter) arrowType(typ *sppb.Type) (arrow.DataType, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return arrow.PrimitiveTypes.Int64, nil
+	case sppb.TypeCode_FLOAT32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case sppb.TypeCode_FLOAT64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case sppb.TypeCode_STRING, sppb.TypeCode_INTERVAL:
+		return arrow.BinaryTypes.String, nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return arrow.BinaryTypes.Binary, nil
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return arrow.BinaryTypes.String, nil
+		}
+		return &arrow.Decimal128Type{Precision: 38, Scale: 9}, nil
+	case sppb.TypeCode_TIMESTAMP:
+		return &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}, nil
+	case sppb.TypeCode_DATE:
+		return arrow.FixedWidthTypes.Date32, nil
+	case sppb.TypeCode_JSON:
+		return extensions.NewJSONType(arrow.BinaryTypes.String)
+	case sppb.TypeCode_UUID:
+		return extensions.NewUUIDType(), nil
+	case sppb.TypeCode_ARRAY:
+		elem, err := w.arrowType(typ.GetArrayElementType())
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOf(elem), nil
+	case sppb.TypeCode_STRUCT:
+		fields, err := w.arrowFields(typ.GetStructType().GetFields())
+		if err != nil {
+			return nil, err
+		}
+		return arrow.StructOf(fields...), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedArrowType, typ.GetCode())
+	}
+}
+
+func (w *ArrowWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *ArrowWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *ArrowWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// arrowNull marks a NULL in the values produced by arrowNative.
+type arrowNull struct{}
+
+// arrowNative converts a wire value of typ to the value appended by
+// appendArrowNative: a Go scalar, []any for ARRAY and STRUCT, or arrowNull.
+func arrowNative(typ *sppb.Type, v *structpb.Value) (any, error) {
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); v == nil || isNull {
+		return arrowNull{}, nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return nil, fmt.Errorf("BOOL wire kind %T", v.GetKind())
+		}
+		return b.BoolValue, nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return strconv.ParseInt(v.GetStringValue(), 10, 64)
+	case sppb.TypeCode_FLOAT32:
+		f, err := internal.FloatFromWire(v)
+		return float32(f), err
+	case sppb.TypeCode_FLOAT64:
+		return internal.FloatFromWire(v)
+	case sppb.TypeCode_STRING, sppb.TypeCode_INTERVAL:
+		return v.GetStringValue(), nil
+	case sppb.TypeCode_JSON:
+		// JSON is appended through its extension builder from text.
+		s := v.GetStringValue()
+		if !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("invalid JSON %q", s)
+		}
+		return s, nil
+	case sppb.TypeCode_UUID:
+		return uuid.Parse(v.GetStringValue())
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return base64.StdEncoding.DecodeString(v.GetStringValue())
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return v.GetStringValue(), nil
+		}
+		r, ok := new(big.Rat).SetString(v.GetStringValue())
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC %q", v.GetStringValue())
+		}
+		r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(9), nil)))
+		if !r.IsInt() {
+			return nil, fmt.Errorf("NUMERIC %q has more than 9 fractional digits", v.GetStringValue())
+		}
+		return decimal128.FromBigInt(r.Num()), nil
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		if err != nil {
+			return nil, err
+		}
+		ns := t.UnixNano()
+		if !time.Unix(0, ns).Equal(t) {
+			return nil, fmt.Errorf("TIMESTAMP %q out of nanosecond range", v.GetStringValue())
+		}
+		return arrow.Timestamp(ns), nil
+	case sppb.TypeCode_DATE:
+		d, err := civil.ParseDate(v.GetStringValue())
+		if err != nil {
+			return nil, err
+		}
+		return arrow.Date32(d.DaysSince(civil.Date{Year: 1970, Month: time.January, Day: 1})), nil
+	case sppb.TypeCode_ARRAY:
+		values := v.GetListValue().GetValues()
+		elems := make([]any, len(values))
+		for i, elem := range values {
+			native, err := arrowNative(typ.GetArrayElementType(), elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elems[i] = native
+		}
+		return elems, nil
+	case sppb.TypeCode_STRUCT:
+		fields := typ.GetStructType().GetFields()
+		values := v.GetListValue().GetValues()
+		if len(values) != len(fields) {
+			return nil, fmt.Errorf("%w: got %d struct values, want %d", ErrMismatchedStructValueCount, len(values), len(fields))
+		}
+		natives := make([]any, len(values))
+		for i, field := range values {
+			native, err := arrowNative(fields[i].GetType(), field)
+			if err != nil {
+				return nil, fmt.Errorf("field %d: %w", i, err)
+			}
+			natives[i] = native
+		}
+		return natives, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedArrowType, typ.GetCode())
+	}
+}
+
+// appendArrowNative appends a value from arrowNative to b, whose type was
+// built by [ArrowWriter.arrowType] for the same Spanner type.
+func appendArrowNative(b array.Builder, native any) error {
+	if _, isNull := native.(arrowNull); isNull {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		b.Append(native.(bool))
+	case *array.Int64Builder:
+		b.Append(native.(int64))
+	case *array.Float32Builder:
+		b.Append(native.(float32))
+	case *array.Float64Builder:
+		b.Append(native.(float64))
+	case *array.StringBuilder:
+		b.Append(native.(string))
+	case *array.BinaryBuilder:
+		b.Append(native.([]byte))
+	case *array.Decimal128Builder:
+		b.Append(native.(decimal128.Num))
+	case *array.TimestampBuilder:
+		b.Append(native.(arrow.Timestamp))
+	case *array.Date32Builder:
+		b.Append(native.(arrow.Date32))
+	case *extensions.UUIDBuilder:
+		b.Append(native.(uuid.UUID))
+	case *array.ListBuilder:
+		b.Append(true)
+		for _, elem := range native.([]any) {
+			if err := appendArrowNative(b.ValueBuilder(), elem); err != nil {
+				return err
+			}
+		}
+	case *array.StructBuilder:
+		b.Append(true)
+		for i, field := range native.([]any) {
+			if err := appendArrowNative(b.FieldBuilder(i), field); err != nil {
+				return err
+			}
+		}
+	default:
+		// The JSON extension builder parses the text that arrowNative
+		// validated.
+		return b.AppendValueFromString(native.(string))
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*ArrowWriter)(nil)
+
+// arrowRecordStrings renders each batch as rows of ValueStr cells.
+func arrowRecordStrings(recs []arrow.Record) [][][]string {
+	var batches [][][]string
+	for _, rec := range recs {
+		var rows [][]string
+		for i := range int(rec.NumRows()) {
+			var row []string
+			for _, col := range rec.Columns() {
+				row = append(row, col.ValueStr(i))
+			}
+			rows = append(rows, row)
+		}
+		batches = append(batches, rows)
+	}
+	return batches
+}
+
+// readArrowStream returns the schema and batches of an IPC stream.
+func readArrowStream(t *testing.T, b []byte) (*arrow.Schema, [][][]string) {
+	t.Helper()
+	r, err := ipc.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	var recs []arrow.Record
+	for r.Next() {
+		rec := r.Record()
+		rec.Retain()
+		defer rec.Release()
+		recs = append(recs, rec)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return r.Schema(), arrowRecordStrings(recs)
+}
+
+func TestArrowWriter_values(t *testing.T) {
+	t.Parallel()
+
+	st := gcvctor.MustStructValueOf([]string{"x", ""}, []spanner.GenericColumnValue{gcvctor.Int64Value(7), gcvctor.StringValue("s")})
+	names := []string{"id", "b", "f32", "num", "ts", "d", "by", "j", "u", "iv", "arr", "st"}
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(1),
+			gcvctor.BoolValue(true),
+			gcvctor.Float32Value(1.5),
+			gcvctor.NumericValue(big.NewRat(-12345, 100)),
+			gcvctor.TimestampValue(time.Unix(1, 2500)),
+			gcvctor.MustDateStringValue("1970-01-03"),
+			gcvctor.BytesValue([]byte("hi")),
+			gcvctor.MustJSONStringValue(`{"a":1}`),
+			gcvctor.MustUUIDStringValue("5b2d5c3c-8d9e-4f6a-9b1c-2d3e4f5a6b7c"),
+			gcvctor.MustIntervalStringValue("P1Y2M3DT4H"),
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.NullFromCode(sppb.TypeCode_INT64)),
+			st,
+		},
+		{
+			gcvctor.NullFromCode(sppb.TypeCode_INT64),
+			gcvctor.NullFromCode(sppb.TypeCode_BOOL),
+			gcvctor.NullFromCode(sppb.TypeCode_FLOAT32),
+			gcvctor.NullFromCode(sppb.TypeCode_NUMERIC),
+			gcvctor.NullFromCode(sppb.TypeCode_TIMESTAMP),
+			gcvctor.NullFromCode(sppb.TypeCode_DATE),
+			gcvctor.NullFromCode(sppb.TypeCode_BYTES),
+			gcvctor.NullFromCode(sppb.TypeCode_JSON),
+			gcvctor.NullFromCode(sppb.TypeCode_UUID),
+			gcvctor.NullFromCode(sppb.TypeCode_INTERVAL),
+			gcvctor.NullArrayFromCode(sppb.TypeCode_INT64),
+			gcvctor.NullOf(st.Type),
+		},
+	}
+
+	var out bytes.Buffer
+	w := mustNewArrowWriter(t, &out)
+	for _, row := range rows {
+		if err := w.WriteValues(names, row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, got := readArrowStream(t, out.Bytes())
+	wantSchema := "schema:\n  fields: 12\n" +
+		"    - id: type=int64, nullable\n" +
+		"    - b: type=bool, nullable\n" +
+		"    - f32: type=float32, nullable\n" +
+		"    - num: type=decimal(38, 9), nullable\n" +
+		"    - ts: type=timestamp[ns, tz=UTC], nullable\n" +
+		"    - d: type=date32, nullable\n" +
+		"    - by: type=binary, nullable\n" +
+		"    - j: type=extension<arrow.json[storage_type=utf8]>, nullable\n" +
+		"    - u: type=extension<arrow.uuid>, nullable\n" +
+		"    - iv: type=utf8, nullable\n" +
+		"    - arr: type=list<item: int64, nullable>, nullable\n" +
+		"    - st: type=struct<x: int64, _0: utf8>, nullable"
+	if diff := cmp.Diff(wantSchema, schema.String()); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	want := [][][]string{{
+		{
+			"1", "true", "1.5", "-123.45", "1970-01-01 00:00:01.0000025Z", "1970-01-03", "aGk=",
+			`{"a":1}`, "5b2d5c3c-8d9e-4f6a-9b1c-2d3e4f5a6b7c", "P1Y2M3DT4H", "[1,null]", `{"_0":"s","x":7}`,
+		},
+		// The JSON extension array renders NULL as JSON null.
+		{"(null)", "(null)", "(null)", "(null)", "(null)", "(null)", "(null)", "null", "(null)", "(null)", "(null)", "(null)"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestArrowWriter_rowIterator(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []ArrowIPCFormat{ArrowIPCStream, ArrowIPCFile} {
+		t.Run(format.String(), func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewArrowWriter(t, &out, WithArrowIPCFormat(format), WithArrowBatchRows(2))
+			names := []string{"id", "name"}
+			md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+			rows := RowSeq(
+				mustNewSpannerRow(t, names, []any{int64(1), "a"}),
+				mustNewSpannerRow(t, names, []any{int64(2), spanner.NullString{}}),
+				mustNewSpannerRow(t, names, []any{int64(3), "c"}),
+			)
+			if _, err := WriteRowSeq(md, rows, w); err != nil {
+				t.Fatal(err)
+			}
+
+			var got [][][]string
+			if format == ArrowIPCFile {
+				r, err := ipc.NewFileReader(bytes.NewReader(out.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer r.Close()
+				var recs []arrow.Record
+				for i := range r.NumRecords() {
+					rec, err := r.RecordAt(i)
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer rec.Release()
+					recs = append(recs, rec)
+				}
+				got = arrowRecordStrings(recs)
+			} else {
+				_, got = readArrowStream(t, out.Bytes())
+			}
+			want := [][][]string{{{"1", "a"}, {"2", "(null)"}}, {{"3", "c"}}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("batches mismatch (-want +got):\n%s", diff)
+			}
+			if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(4), gcvctor.StringValue("d")}); !errors.Is(err, ErrWriterClosed) {
+				t.Errorf("WriteGCVs after Flush error = %v, want ErrWriterClosed", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Errorf("second Flush error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestArrowWriter_records(t *testing.T) {
+	t.Parallel()
+
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	var recs []arrow.Record
+	w, err := NewArrowRecordWriter(func(rec arrow.Record) error {
+		rec.Retain()
+		recs = append(recs, rec)
+		return nil
+	}, WithRowType(tableTestRowType()), WithArrowBatchRows(2), WithArrowAllocator(mem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(int64(i)), gcvctor.StringValue("x")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := [][][]string{{{"0", "x"}}, {{"1", "x"}}, {{"2", "x"}}}
+	if diff := cmp.Diff(want, arrowRecordStrings(recs)); diff != "" {
+		t.Errorf("batches mismatch (-want +got):\n%s", diff)
+	}
+	if got := w.Schema().Field(0).Name; got != "id" {
+		t.Errorf("Schema field 0 = %q, want id", got)
+	}
+	for _, rec := range recs {
+		rec.Release()
+	}
+}
+
+func TestArrowWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewArrowWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewArrowWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewArrowRecordWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewArrowRecordWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewArrowWriter(&bytes.Buffer{}, WithArrowIPCFormat(ArrowIPCFormat(9))); !errors.Is(err, ErrInvalidArrowIPCFormat) {
+		t.Errorf("invalid format error = %v, want ErrInvalidArrowIPCFormat", err)
+	}
+	if _, err := NewArrowWriter(&bytes.Buffer{}, WithArrowBatchRows(-1)); !errors.Is(err, ErrInvalidBatchRows) {
+		t.Errorf("negative batch rows error = %v, want ErrInvalidBatchRows", err)
+	}
+
+	t.Run("lifecycle", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewArrowWriter(t, &bytes.Buffer{})
+		if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+			t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+		}
+		w = mustNewArrowWriter(t, &bytes.Buffer{}, WithColumnNames([]string{"id"}))
+		if err := w.Flush(); !errors.Is(err, ErrMissingFieldTypes) {
+			t.Errorf("Flush names-only error = %v, want ErrMissingFieldTypes", err)
+		}
+		w = mustNewArrowWriter(t, &bytes.Buffer{}, WithRowType(typector.MustNameCodeSlicesToStructType([]string{"a"}, []sppb.TypeCode{sppb.TypeCode_TYPE_CODE_UNSPECIFIED}).GetStructType()))
+		if err := w.Flush(); !errors.Is(err, ErrUnsupportedArrowType) {
+			t.Errorf("unsupported type error = %v, want ErrUnsupportedArrowType", err)
+		}
+		w = mustNewArrowWriter(t, &bytes.Buffer{}, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.StringValue("1"), gcvctor.StringValue("a")}); !errors.Is(err, ErrArrowTypeMismatch) {
+			t.Errorf("mismatched value error = %v, want ErrArrowTypeMismatch", err)
+		}
+		w = mustNewArrowWriter(t, &bytes.Buffer{}, WithColumnNames([]string{"ts"}))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.MustTimestampStringValue("2300-01-01T00:00:00Z")}); err == nil {
+			t.Error("out-of-range TIMESTAMP error = nil, want error")
+		}
+
+		var out bytes.Buffer
+		w = mustNewArrowWriter(t, &out, WithRowType(tableTestRowType()))
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		schema, got := readArrowStream(t, out.Bytes())
+		if schema.NumFields() != 2 || len(got) != 0 {
+			t.Errorf("empty stream = %d fields, %d batches, want 2 fields, 0 batches", schema.NumFields(), len(got))
+		}
+
+		out.Reset()
+		w = mustNewArrowWriter(t, &out, WithRowType(nil))
+		if err := w.WriteGCVs(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("zero-column output = %q, want empty", out.String())
+		}
+	})
+
+	t.Run("invalid text", func(t *testing.T) {
+		t.Parallel()
+		// A rejected UUID or JSON value leaves the batch unchanged.
+		rowType := typector.MustNameCodeSlicesToStructType(
+			[]string{"id", "u", "j"},
+			[]sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_UUID, sppb.TypeCode_JSON},
+		).GetStructType()
+		var out bytes.Buffer
+		w := mustNewArrowWriter(t, &out, WithRowType(rowType))
+		for _, row := range [][]spanner.GenericColumnValue{
+			{gcvctor.Int64Value(1), {Type: rowType.GetFields()[1].GetType(), Value: structpb.NewStringValue("not-a-uuid")}, gcvctor.NullFromCode(sppb.TypeCode_JSON)},
+			{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_UUID), {Type: rowType.GetFields()[2].GetType(), Value: structpb.NewStringValue("{")}},
+		} {
+			if err := w.WriteGCVs(row); err == nil {
+				t.Errorf("WriteGCVs(%v) error = nil, want error", row[0].Value)
+			}
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(3), gcvctor.NullFromCode(sppb.TypeCode_UUID), gcvctor.NullFromCode(sppb.TypeCode_JSON)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		_, got := readArrowStream(t, out.Bytes())
+		if diff := cmp.Diff([][][]string{{{"3", "(null)", "null"}}}, got); diff != "" {
+			t.Errorf("batches mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewArrowWriter(t, fw, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Errorf("second Flush error = %v, want errInjected", err)
+		}
+	})
+}
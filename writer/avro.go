@@ -0,0 +1,623 @@
+package writer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// AvroOption configures an AvroWriter created by [NewAvroWriter].
+type AvroOption interface {
+	applyAvroOption(*AvroWriter) error
+}
+
+type avroOptionFunc func(*AvroWriter) error
+
+func (f avroOptionFunc) applyAvroOption(w *AvroWriter) error {
+	return f(w)
+}
+
+func applyAvroOptions(w *AvroWriter, options ...AvroOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyAvroOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AvroCompression selects the block compression codec of [AvroWriter].
+type AvroCompression int
+
+const (
+	// AvroDeflate compresses blocks with deflate (the default).
+	AvroDeflate AvroCompression = iota
+	// AvroSnappy compresses blocks with Snappy.
+	AvroSnappy
+	// AvroUncompressed writes uncompressed ("null" codec) blocks.
+	AvroUncompressed
+)
+
+// String returns the Go constant name for c, or "AvroCompression(n)" for unknown values.
+func (c AvroCompression) String() string {
+	switch c {
+	case AvroDeflate:
+		return "AvroDeflate"
+	case AvroSnappy:
+		return "AvroSnappy"
+	case AvroUncompressed:
+		return "AvroUncompressed"
+	default:
+		return fmt.Sprintf("AvroCompression(%d)", int(c))
+	}
+}
+
+func (c AvroCompression) label() string {
+	switch c {
+	case AvroSnappy:
+		return goavro.CompressionSnappyLabel
+	case AvroUncompressed:
+		return goavro.CompressionNullLabel
+	default:
+		return goavro.CompressionDeflateLabel
+	}
+}
+
+// WithAvroCompression selects the block compression codec (default
+// [AvroDeflate]). Unknown codecs return [ErrInvalidAvroCompression].
+func WithAvroCompression(c AvroCompression) AvroOption {
+	return avroOptionFunc(func(w *AvroWriter) error {
+		if c < AvroDeflate || c > AvroUncompressed {
+			return fmt.Errorf("%w: %v", ErrInvalidAvroCompression, c)
+		}
+		w.compression = c
+		return nil
+	})
+}
+
+// WithAvroPrimaryKey records the primary key columns of the table in the
+// schema ("spannerPrimaryKey_0", ...), which the import template uses to
+// create the table. Keys are ascending and quoted for the [WithSQLDialect]
+// dialect. Without it no primary key is recorded.
+func WithAvroPrimaryKey(columns ...string) AvroOption {
+	return avroOptionFunc(func(w *AvroWriter) error {
+		w.primaryKey = columns
+		return nil
+	})
+}
+
+const (
+	// avroNamespace is the record namespace used by the export template.
+	avroNamespace = "spannerexport"
+	// avroBlockRows is the number of rows buffered into one OCF block.
+	avroBlockRows = 1000
+)
+
+// AvroWriter writes rows as an Avro object container file in the format of
+// the Cloud Spanner Dataflow export template ("Cloud Spanner to Avro"), so
+// the file can be loaded by the matching import template. The schema is one
+// record named after the table, with "spannerName" and optional
+// "spannerPrimaryKey_N" properties, and one nullable field per column
+// carrying the column type in "sqlType":
+//
+//   - BOOL: boolean; INT64 and ENUM: long; FLOAT32: float; FLOAT64: double
+//   - STRING, JSON, TIMESTAMP, DATE, UUID, and INTERVAL: string, holding the
+//     Spanner wire text (RFC 3339 timestamps and YYYY-MM-DD dates)
+//   - BYTES and PROTO: bytes
+//   - NUMERIC: bytes with logical type decimal(38,9); PostgreSQL NUMERIC:
+//     bytes holding the decimal text
+//   - ARRAY: array of the nullable element type
+//
+// STRUCT columns cannot be stored in a table and return
+// [ErrUnsupportedAvroType]. sqlType uses GoogleSQL type names, or PostgreSQL
+// names with [WithSQLDialect]. Since a query result carries no column
+// lengths, STRING and BYTES are declared with MAX length. Unnamed columns are
+// named with [spanvalue.IndexedUnnamedFieldNamer] unless
+// [WithUnnamedFieldNamer] is set; names that are not valid Avro names return
+// [ErrInvalidAvroName] and duplicate names return [ErrDuplicateColumnName].
+// Values are written typed, so [WithFormatter] has no effect.
+//
+// The import template also reads a spanner-export.json and per-table
+// manifest listing the data files; AvroWriter writes only the data file.
+//
+// The file header is written once field types are known (from the row type,
+// or the first row when only names are registered). Rows are encoded in
+// blocks of up to 1000 rows; [AvroWriter.Flush] writes the pending block and
+// the writer stays usable, since an object container file needs no footer. A
+// registered zero-column schema writes nothing.
+//
+// After the first output failure, every later Write*/Flush call returns that
+// error; discard the writer (see package doc "Write errors").
+type AvroWriter struct {
+	stickyWriteError
+	// unnamedFieldNamer resolves empty column names.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	table             string
+	dialect           databasepb.DatabaseDialect
+	compression       AvroCompression
+	primaryKey        []string
+
+	schema  columnSchema
+	names   []string
+	types   []*sppb.Type
+	ocf     *goavro.OCFWriter
+	pending []any
+	out     *avroOutput
+}
+
+// avroOutput records the first error of the underlying writer, since goavro
+// reports write errors as text without wrapping them.
+type avroOutput struct {
+	w   io.Writer
+	err error
+}
+
+func (o *avroOutput) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+	return n, err
+}
+
+// latchOutputErr latches err, preferring the underlying output error it reports.
+func (w *AvroWriter) latchOutputErr(err error) error {
+	if err != nil && w.out.err != nil {
+		err = w.out.err
+	}
+	return w.latchWriteErr(err)
+}
+
+// NewAvroWriter returns an Avro writer for rows of table configured by options.
+// table must be non-empty after trimming whitespace; otherwise it returns
+// [ErrEmptyTableName].
+func NewAvroWriter(out io.Writer, table string, options ...AvroOption) (*AvroWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	if strings.TrimSpace(table) == "" {
+		return nil, ErrEmptyTableName
+	}
+	w := &AvroWriter{
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		table:             table,
+		out:               &avroOutput{w: out},
+	}
+	if err := applyAvroOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *AvroWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *AvroWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *AvroWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one row; see [DelimitedWriter.WriteValues].
+func (w *AvroWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one row; see [DelimitedWriter.WriteStructValues].
+func (w *AvroWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one row; see [DelimitedWriter.WriteGCVs]. Values must
+// match the column types of the file.
+func (w *AvroWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	if w.ocf == nil {
+		types := w.schema.types
+		if len(types) == 0 {
+			types = make([]*sppb.Type, len(values))
+			for i, v := range values {
+				types[i] = v.Type
+			}
+		}
+		if err := w.open(types); err != nil {
+			return err
+		}
+	}
+	record := make(map[string]any, len(values))
+	for i, v := range values {
+		if !internal.TypesEquivalent(w.types[i], v.Type) {
+			return fmt.Errorf("%w: column %q has type %s, want %s", ErrAvroTypeMismatch, w.names[i], v.Type, w.types[i])
+		}
+		native, err := avroNative(w.types[i], v.Value)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", w.names[i], err)
+		}
+		record[w.names[i]] = native
+	}
+	w.pending = append(w.pending, record)
+	if len(w.pending) >= avroBlockRows {
+		return w.writeBlock()
+	}
+	return nil
+}
+
+// Flush writes pending rows as one block, and the file header when nothing
+// was written yet, so a registered schema with no rows yields a valid file.
+// With no registered schema, Flush returns [ErrMissingColumnNames]; with
+// registered names but no field types and no rows, it returns
+// [ErrMissingFieldTypes]. After a write failure, Flush returns the latched
+// error (see package doc "Write errors").
+func (w *AvroWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	if w.ocf == nil {
+		if len(w.schema.types) == 0 {
+			return ErrMissingFieldTypes
+		}
+		if err := w.open(w.schema.types); err != nil {
+			return err
+		}
+	}
+	return w.writeBlock()
+}
+
+func (w *AvroWriter) writeBlock() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	err := w.ocf.Append(w.pending)
+	w.pending = w.pending[:0]
+	return w.latchOutputErr(err)
+}
+
+// open builds the Avro schema from types and writes the file header.
+func (w *AvroWriter) open(types []*sppb.Type) error {
+	names, err := internal.ResolveColumnNames(w.schema.names, w.unnamedFieldNamer)
+	if err != nil {
+		return err
+	}
+	schema, err := avroSchema(w.table, w.dialect, w.primaryKey, names, types)
+	if err != nil {
+		return err
+	}
+	ocf, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:               w.out,
+		Schema:          schema,
+		CompressionName: w.compression.label(),
+	})
+	if err != nil {
+		return w.latchOutputErr(err)
+	}
+	w.names = names
+	w.types = types
+	w.ocf = ocf
+	return nil
+}
+
+func (w *AvroWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *AvroWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *AvroWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// avroSchema returns the JSON Avro schema of the export template for a table.
+func avroSchema(table string, dialect databasepb.DatabaseDialect, primaryKey, names []string, types []*sppb.Type) (string, error) {
+	record := map[string]any{
+		"type":                "record",
+		"name":                avroRecordName(table),
+		"namespace":           avroNamespace,
+		"googleStorage":       "CloudSpanner",
+		"googleFormatVersion": "1.0.0",
+		"spannerName":         table,
+	}
+	for i, key := range primaryKey {
+		record["spannerPrimaryKey_"+strconv.Itoa(i)] = spanvalue.QuoteIdentifier(dialect, key) + " ASC"
+	}
+	seen := make(map[string]bool, len(names))
+	fields := make([]map[string]any, len(names))
+	for i, name := range names {
+		if !isAvroName(name) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidAvroName, name)
+		}
+		if seen[name] {
+			return "", fmt.Errorf("%w: %q", ErrDuplicateColumnName, name)
+		}
+		seen[name] = true
+		avroType, err := avroFieldType(types[i])
+		if err != nil {
+			return "", fmt.Errorf("column %q: %w", name, err)
+		}
+		sqlType, err := avroSQLType(dialect, types[i])
+		if err != nil {
+			return "", fmt.Errorf("column %q: %w", name, err)
+		}
+		fields[i] = map[string]any{
+			"name":    name,
+			"type":    []any{"null", avroType},
+			"sqlType": sqlType,
+			"notNull": "false",
+		}
+	}
+	record["fields"] = fields
+	b, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// avroFieldType returns the Avro type of a non-NULL value of typ.
+func avroFieldType(typ *sppb.Type) (any, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return "boolean", nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		return "long", nil
+	case sppb.TypeCode_FLOAT32:
+		return "float", nil
+	case sppb.TypeCode_FLOAT64:
+		return "double", nil
+	case sppb.TypeCode_STRING, sppb.TypeCode_JSON, sppb.TypeCode_TIMESTAMP,
+		sppb.TypeCode_DATE, sppb.TypeCode_UUID, sppb.TypeCode_INTERVAL:
+		return "string", nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		return "bytes", nil
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return "bytes", nil
+		}
+		return map[string]any{"type": "bytes", "logicalType": "decimal", "precision": 38, "scale": 9}, nil
+	case sppb.TypeCode_ARRAY:
+		elem, err := avroFieldType(typ.GetArrayElementType())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": []any{"null", elem}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAvroType, typ.GetCode())
+	}
+}
+
+// avroSQLType returns the column type of typ as the export template writes
+// it to "sqlType".
+func avroSQLType(dialect databasepb.DatabaseDialect, typ *sppb.Type) (string, error) {
+	pg := dialect == databasepb.DatabaseDialect_POSTGRESQL
+	pick := func(googleSQL, postgreSQL string) string {
+		if pg {
+			return postgreSQL
+		}
+		return googleSQL
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return pick("BOOL", "boolean"), nil
+	case sppb.TypeCode_INT64:
+		return pick("INT64", "bigint"), nil
+	case sppb.TypeCode_FLOAT32:
+		return pick("FLOAT32", "real"), nil
+	case sppb.TypeCode_FLOAT64:
+		return pick("FLOAT64", "double precision"), nil
+	case sppb.TypeCode_STRING:
+		return pick("STRING(MAX)", "character varying"), nil
+	case sppb.TypeCode_BYTES:
+		return pick("BYTES(MAX)", "bytea"), nil
+	case sppb.TypeCode_TIMESTAMP:
+		return pick("TIMESTAMP", "timestamp with time zone"), nil
+	case sppb.TypeCode_DATE:
+		return pick("DATE", "date"), nil
+	case sppb.TypeCode_NUMERIC:
+		return pick("NUMERIC", "numeric"), nil
+	case sppb.TypeCode_JSON:
+		return pick("JSON", "jsonb"), nil
+	case sppb.TypeCode_UUID:
+		return pick("UUID", "uuid"), nil
+	case sppb.TypeCode_INTERVAL:
+		return pick("INTERVAL", "interval"), nil
+	case sppb.TypeCode_PROTO, sppb.TypeCode_ENUM:
+		if pg {
+			return "", fmt.Errorf("%w: %s in PostgreSQL dialect", ErrUnsupportedAvroType, typ.GetCode())
+		}
+		return typ.GetCode().String() + "<" + typ.GetProtoTypeFqn() + ">", nil
+	case sppb.TypeCode_ARRAY:
+		elem, err := avroSQLType(dialect, typ.GetArrayElementType())
+		if err != nil {
+			return "", err
+		}
+		return pick("ARRAY<"+elem+">", elem+"[]"), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedAvroType, typ.GetCode())
+	}
+}
+
+// avroNative converts a wire value of typ to the goavro native form of its
+// nullable field: nil for NULL, else a union holding the value.
+func avroNative(typ *sppb.Type, v *structpb.Value) (any, error) {
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); v == nil || isNull {
+		return nil, nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return nil, fmt.Errorf("BOOL wire kind %T", v.GetKind())
+		}
+		return goavro.Union("boolean", b.BoolValue), nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		n, err := strconv.ParseInt(v.GetStringValue(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return goavro.Union("long", n), nil
+	case sppb.TypeCode_FLOAT32:
+		f, err := internal.FloatFromWire(v)
+		if err != nil {
+			return nil, err
+		}
+		return goavro.Union("float", float32(f)), nil
+	case sppb.TypeCode_FLOAT64:
+		f, err := internal.FloatFromWire(v)
+		if err != nil {
+			return nil, err
+		}
+		return goavro.Union("double", f), nil
+	case sppb.TypeCode_STRING, sppb.TypeCode_JSON, sppb.TypeCode_TIMESTAMP,
+		sppb.TypeCode_DATE, sppb.TypeCode_UUID, sppb.TypeCode_INTERVAL:
+		return goavro.Union("string", v.GetStringValue()), nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		b, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+		if err != nil {
+			return nil, err
+		}
+		return goavro.Union("bytes", b), nil
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return goavro.Union("bytes", []byte(v.GetStringValue())), nil
+		}
+		r, ok := new(big.Rat).SetString(v.GetStringValue())
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC %q", v.GetStringValue())
+		}
+		return goavro.Union("bytes.decimal", r), nil
+	case sppb.TypeCode_ARRAY:
+		values := v.GetListValue().GetValues()
+		elems := make([]any, len(values))
+		for i, elem := range values {
+			native, err := avroNative(typ.GetArrayElementType(), elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elems[i] = native
+		}
+		return goavro.Union("array", elems), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAvroType, typ.GetCode())
+	}
+}
+
+// avroRecordName turns table into a valid Avro record name by replacing
+// characters outside [A-Za-z0-9_]; the table name itself is kept in
+// "spannerName".
+func avroRecordName(table string) string {
+	var b strings.Builder
+	for i, r := range table {
+		switch {
+		case r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+			b.WriteRune(r)
+		case '0' <= r && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// isAvroName reports whether name matches [A-Za-z_][A-Za-z0-9_]*.
+func isAvroName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
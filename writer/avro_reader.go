@@ -0,0 +1,361 @@
+package writer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+// AvroReader decodes an Avro object container file written by [AvroWriter]
+// or the Cloud Spanner Dataflow export template back to Spanner values.
+// Column types come from each field's "sqlType" (GoogleSQL or PostgreSQL
+// names); fields without a recognized sqlType fall back to their Avro type
+// (boolean, int, long, float, double, string, bytes, decimal, and arrays of
+// those).
+type AvroReader struct {
+	ocf     *goavro.OCFReader
+	table   string
+	names   []string
+	rowType *sppb.StructType
+}
+
+// NewAvroReader reads the file header from in and derives the row type from
+// the schema. Schemas that are not records return [ErrInvalidAvroSchema];
+// fields whose type cannot be mapped return [ErrUnsupportedAvroType].
+func NewAvroReader(in io.Reader) (*AvroReader, error) {
+	ocf, err := goavro.NewOCFReader(in)
+	if err != nil {
+		return nil, err
+	}
+	var schema struct {
+		Type        string `json:"type"`
+		Name        string `json:"name"`
+		SpannerName string `json:"spannerName"`
+		Fields      []struct {
+			Name    string          `json:"name"`
+			Type    json.RawMessage `json:"type"`
+			SQLType string          `json:"sqlType"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(ocf.MetaData()["avro.schema"], &schema); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAvroSchema, err)
+	}
+	if schema.Type != "record" {
+		return nil, fmt.Errorf("%w: top-level type %q is not a record", ErrInvalidAvroSchema, schema.Type)
+	}
+	r := &AvroReader{ocf: ocf, table: schema.SpannerName}
+	if r.table == "" {
+		r.table = schema.Name
+	}
+	fields := make([]*sppb.StructType_Field, len(schema.Fields))
+	for i, f := range schema.Fields {
+		typ, ok := typeFromSQLType(f.SQLType)
+		if !ok {
+			var avroType any
+			if err := json.Unmarshal(f.Type, &avroType); err != nil {
+				return nil, fmt.Errorf("%w: field %q: %w", ErrInvalidAvroSchema, f.Name, err)
+			}
+			typ, err = typeFromAvroType(avroType)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name, err)
+			}
+		}
+		r.names = append(r.names, f.Name)
+		fields[i] = typector.NameTypeToStructTypeField(f.Name, typ)
+	}
+	r.rowType = &sppb.StructType{Fields: fields}
+	return r, nil
+}
+
+// TableName returns the table of the file: "spannerName", or the record
+// name when it is absent.
+func (r *AvroReader) TableName() string {
+	return r.table
+}
+
+// RowType returns the row type derived from the schema.
+func (r *AvroReader) RowType() *sppb.StructType {
+	return r.rowType
+}
+
+// Metadata returns result set metadata holding [AvroReader.RowType], for
+// [WriteRowSeq] and [RunRowSeq].
+func (r *AvroReader) Metadata() *sppb.ResultSetMetadata {
+	return &sppb.ResultSetMetadata{RowType: r.rowType}
+}
+
+// ReadGCVs decodes the next record into one value per column. It returns
+// [io.EOF] after the last record.
+func (r *AvroReader) ReadGCVs() ([]spanner.GenericColumnValue, error) {
+	if !r.ocf.Scan() {
+		if err := r.ocf.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	datum, err := r.ocf.Read()
+	if err != nil {
+		return nil, err
+	}
+	record, ok := datum.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: record datum is %T", ErrInvalidAvroSchema, datum)
+	}
+	values := make([]spanner.GenericColumnValue, len(r.names))
+	for i, name := range r.names {
+		typ := r.rowType.GetFields()[i].GetType()
+		v, err := wireFromAvroNative(typ, record[name])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+		values[i] = spanner.GenericColumnValue{Type: typ, Value: v}
+	}
+	return values, nil
+}
+
+// Rows returns the remaining records as rows, for [WriteRowSeq] and
+// [RunRowSeq]. Iteration stops after the first error.
+func (r *AvroReader) Rows() iter.Seq2[*spanner.Row, error] {
+	return func(yield func(*spanner.Row, error) bool) {
+		for {
+			values, err := r.ReadGCVs()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			row, err := spanner.NewRow(r.names, gcvsAsAny(values))
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func gcvsAsAny(values []spanner.GenericColumnValue) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// typeFromSQLType parses a GoogleSQL or PostgreSQL column type as written to
+// "sqlType" by the export template.
+func typeFromSQLType(sqlType string) (*sppb.Type, bool) {
+	s := strings.TrimSpace(sqlType)
+	if elem, ok := strings.CutSuffix(s, "[]"); ok {
+		elemType, ok := typeFromSQLType(elem)
+		if !ok {
+			return nil, false
+		}
+		return typector.ElemTypeToArrayType(elemType), true
+	}
+	switch {
+	case s == "boolean":
+		return typector.Bool(), true
+	case s == "bigint":
+		return typector.Int64(), true
+	case s == "real":
+		return typector.Float32(), true
+	case s == "double precision":
+		return typector.Float64(), true
+	case s == "text" || s == "character varying" || strings.HasPrefix(s, "character varying("):
+		return typector.String(), true
+	case s == "bytea":
+		return typector.Bytes(), true
+	case s == "timestamp with time zone" || s == "spanner.commit_timestamp":
+		return typector.Timestamp(), true
+	case s == "numeric":
+		return typector.PGNumeric(), true
+	case s == "jsonb":
+		return typector.PGJSONB(), true
+	}
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.HasPrefix(upper, "ARRAY<") && strings.HasSuffix(s, ">"):
+		elemType, ok := typeFromSQLType(s[len("ARRAY<") : len(s)-1])
+		if !ok {
+			return nil, false
+		}
+		return typector.ElemTypeToArrayType(elemType), true
+	case strings.HasPrefix(upper, "PROTO<") && strings.HasSuffix(s, ">"):
+		return typector.FQNToProtoType(s[len("PROTO<") : len(s)-1]), true
+	case strings.HasPrefix(upper, "ENUM<") && strings.HasSuffix(s, ">"):
+		return typector.FQNToEnumType(s[len("ENUM<") : len(s)-1]), true
+	case strings.HasPrefix(upper, "STRING("):
+		return typector.String(), true
+	case strings.HasPrefix(upper, "BYTES("):
+		return typector.Bytes(), true
+	}
+	switch upper {
+	case "BOOL":
+		return typector.Bool(), true
+	case "INT64":
+		return typector.Int64(), true
+	case "FLOAT32":
+		return typector.Float32(), true
+	case "FLOAT64":
+		return typector.Float64(), true
+	case "TIMESTAMP":
+		return typector.Timestamp(), true
+	case "DATE":
+		return typector.Date(), true
+	case "NUMERIC":
+		return typector.Numeric(), true
+	case "JSON":
+		return typector.JSON(), true
+	case "UUID":
+		return typector.UUID(), true
+	case "INTERVAL":
+		return typector.Interval(), true
+	}
+	return nil, false
+}
+
+// typeFromAvroType maps a parsed Avro type, ignoring "null" union branches.
+func typeFromAvroType(avroType any) (*sppb.Type, error) {
+	switch t := avroType.(type) {
+	case string:
+		switch t {
+		case "boolean":
+			return typector.Bool(), nil
+		case "int", "long":
+			return typector.Int64(), nil
+		case "float":
+			return typector.Float32(), nil
+		case "double":
+			return typector.Float64(), nil
+		case "string":
+			return typector.String(), nil
+		case "bytes":
+			return typector.Bytes(), nil
+		}
+	case []any:
+		var branches []any
+		for _, branch := range t {
+			if branch != "null" {
+				branches = append(branches, branch)
+			}
+		}
+		if len(branches) == 1 {
+			return typeFromAvroType(branches[0])
+		}
+	case map[string]any:
+		switch {
+		case t["type"] == "bytes" && t["logicalType"] == "decimal":
+			return typector.Numeric(), nil
+		case t["type"] == "array":
+			elem, err := typeFromAvroType(t["items"])
+			if err != nil {
+				return nil, err
+			}
+			return typector.ElemTypeToArrayType(elem), nil
+		case t["logicalType"] == nil:
+			return typeFromAvroType(t["type"])
+		}
+	}
+	return nil, fmt.Errorf("%w: Avro type %v", ErrUnsupportedAvroType, avroType)
+}
+
+// wireFromAvroNative converts a goavro native value to the Spanner wire value of typ.
+func wireFromAvroNative(typ *sppb.Type, native any) (*structpb.Value, error) {
+	if union, ok := native.(map[string]any); ok && len(union) == 1 {
+		for _, v := range union {
+			native = v
+		}
+	}
+	if native == nil {
+		return structpb.NewNullValue(), nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		if b, ok := native.(bool); ok {
+			return structpb.NewBoolValue(b), nil
+		}
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		switch n := native.(type) {
+		case int64:
+			return structpb.NewStringValue(strconv.FormatInt(n, 10)), nil
+		case int32:
+			return structpb.NewStringValue(strconv.FormatInt(int64(n), 10)), nil
+		}
+	case sppb.TypeCode_FLOAT32:
+		if f, ok := native.(float32); ok {
+			return gcvctor.Float32Value(f).Value, nil
+		}
+	case sppb.TypeCode_FLOAT64:
+		switch f := native.(type) {
+		case float64:
+			return gcvctor.Float64Value(f).Value, nil
+		case float32:
+			return gcvctor.Float64Value(float64(f)).Value, nil
+		}
+	case sppb.TypeCode_STRING, sppb.TypeCode_JSON, sppb.TypeCode_UUID, sppb.TypeCode_INTERVAL:
+		if s, ok := native.(string); ok {
+			return structpb.NewStringValue(s), nil
+		}
+	case sppb.TypeCode_TIMESTAMP:
+		switch t := native.(type) {
+		case string:
+			return structpb.NewStringValue(t), nil
+		case time.Time:
+			return structpb.NewStringValue(t.UTC().Format(time.RFC3339Nano)), nil
+		}
+	case sppb.TypeCode_DATE:
+		switch t := native.(type) {
+		case string:
+			return structpb.NewStringValue(t), nil
+		case time.Time:
+			return structpb.NewStringValue(t.UTC().Format(time.DateOnly)), nil
+		}
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		if b, ok := native.([]byte); ok {
+			return structpb.NewStringValue(base64.StdEncoding.EncodeToString(b)), nil
+		}
+	case sppb.TypeCode_NUMERIC:
+		switch n := native.(type) {
+		case *big.Rat:
+			if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+				return structpb.NewStringValue(n.FloatString(9)), nil
+			}
+			return structpb.NewStringValue(spanner.NumericString(n)), nil
+		case []byte:
+			return structpb.NewStringValue(string(n)), nil
+		case string:
+			return structpb.NewStringValue(n), nil
+		}
+	case sppb.TypeCode_ARRAY:
+		if elems, ok := native.([]any); ok {
+			values := make([]*structpb.Value, len(elems))
+			for i, elem := range elems {
+				v, err := wireFromAvroNative(typ.GetArrayElementType(), elem)
+				if err != nil {
+					return nil, fmt.Errorf("element %d: %w", i, err)
+				}
+				values[i] = v
+			}
+			return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAvroType, typ.GetCode())
+	}
+	return nil, fmt.Errorf("unexpected Avro value %T for %s", native, typ.GetCode())
+}
@@ -0,0 +1,311 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*AvroWriter)(nil)
+
+// avroSchemaOf returns the parsed "avro.schema" header of an object container file.
+func avroSchemaOf(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	ocf, err := goavro.NewOCFReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(ocf.MetaData()["avro.schema"], &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func readAllAvroGCVs(t *testing.T, r *AvroReader) [][]spanner.GenericColumnValue {
+	t.Helper()
+	var rows [][]spanner.GenericColumnValue
+	for {
+		values, err := r.ReadGCVs()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, values)
+	}
+}
+
+func TestAvroWriter_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"id", "b", "f32", "f64", "s", "by", "ts", "d", "n", "j", "u", "iv", "p", "e", "arr"},
+		[]*sppb.Type{
+			typector.Int64(), typector.Bool(), typector.Float32(), typector.Float64(),
+			typector.String(), typector.Bytes(), typector.Timestamp(), typector.Date(),
+			typector.Numeric(), typector.JSON(), typector.UUID(), typector.Interval(),
+			typector.FQNToProtoType("pkg.Msg"), typector.FQNToEnumType("pkg.Enum"),
+			typector.ElemCodeToArrayType(sppb.TypeCode_INT64),
+		},
+	).GetStructType()
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(1), gcvctor.BoolValue(true), gcvctor.Float32Value(1.5), gcvctor.Float64Value(-2.25),
+			gcvctor.StringValue("héllo"), gcvctor.BytesValue([]byte{0, 1, 2}),
+			gcvctor.TimestampValue(time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)),
+			gcvctor.MustDateStringValue("2024-01-02"),
+			gcvctor.NumericValue(big.NewRat(-12345, 100)),
+			gcvctor.MustJSONStringValue(`{"a":1}`),
+			gcvctor.MustUUIDStringValue("8f2c6a3e-7f4b-4d0e-9a51-2f3c4d5e6f70"),
+			gcvctor.MustIntervalStringValue("P1Y2M3DT4H5M6S"),
+			gcvctor.ProtoValue("pkg.Msg", []byte("pb")), gcvctor.EnumValue("pkg.Enum", 3),
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.NullFromCode(sppb.TypeCode_INT64)),
+		},
+		make([]spanner.GenericColumnValue, len(rowType.GetFields())),
+	}
+	for i, f := range rowType.GetFields() {
+		rows[1][i] = gcvctor.NullOf(f.GetType())
+	}
+
+	var out bytes.Buffer
+	w := mustNewAvroWriter(t, &out, "Singers", WithRowType(rowType), WithAvroPrimaryKey("id"))
+	for _, row := range rows {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := avroSchemaOf(t, out.Bytes())
+	for key, want := range map[string]any{
+		"name":                "Singers",
+		"namespace":           "spannerexport",
+		"spannerName":         "Singers",
+		"spannerPrimaryKey_0": "`id` ASC",
+		"googleStorage":       "CloudSpanner",
+	} {
+		if schema[key] != want {
+			t.Errorf("schema[%q] = %v, want %v", key, schema[key], want)
+		}
+	}
+	var sqlTypes []any
+	for _, f := range schema["fields"].([]any) {
+		sqlTypes = append(sqlTypes, f.(map[string]any)["sqlType"])
+	}
+	wantSQLTypes := []any{
+		"INT64", "BOOL", "FLOAT32", "FLOAT64", "STRING(MAX)", "BYTES(MAX)", "TIMESTAMP", "DATE",
+		"NUMERIC", "JSON", "UUID", "INTERVAL", "PROTO<pkg.Msg>", "ENUM<pkg.Enum>", "ARRAY<INT64>",
+	}
+	if diff := cmp.Diff(wantSQLTypes, sqlTypes); diff != "" {
+		t.Errorf("sqlType mismatch (-want +got):\n%s", diff)
+	}
+
+	r, err := NewAvroReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.TableName(); got != "Singers" {
+		t.Errorf("TableName = %q, want Singers", got)
+	}
+	if diff := cmp.Diff(rowType, r.RowType(), protocmp.Transform()); diff != "" {
+		t.Errorf("RowType mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(rows, readAllAvroGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAvroWriter_postgreSQL(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"id", "n", "j", "tags"},
+		[]*sppb.Type{typector.Int64(), typector.PGNumeric(), typector.PGJSONB(), typector.ElemCodeToArrayType(sppb.TypeCode_STRING)},
+	).GetStructType()
+	row := []spanner.GenericColumnValue{
+		gcvctor.Int64Value(1),
+		gcvctor.PGNumericValue(big.NewRat(1, 3)),
+		gcvctor.MustPGJSONBValue(map[string]int{"a": 1}),
+		gcvctor.MustArrayValue(gcvctor.StringValue("x")),
+	}
+
+	var out bytes.Buffer
+	w := mustNewAvroWriter(t, &out, "singers",
+		WithRowType(rowType),
+		WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL),
+		WithAvroPrimaryKey("id"),
+		WithAvroCompression(AvroSnappy),
+	)
+	if err := w.WriteGCVs(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := avroSchemaOf(t, out.Bytes())
+	if got := schema["spannerPrimaryKey_0"]; got != `"id" ASC` {
+		t.Errorf("spannerPrimaryKey_0 = %v, want \"id\" ASC", got)
+	}
+	var sqlTypes []any
+	for _, f := range schema["fields"].([]any) {
+		sqlTypes = append(sqlTypes, f.(map[string]any)["sqlType"])
+	}
+	if diff := cmp.Diff([]any{"bigint", "numeric", "jsonb", "character varying[]"}, sqlTypes); diff != "" {
+		t.Errorf("sqlType mismatch (-want +got):\n%s", diff)
+	}
+
+	r, err := NewAvroReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]spanner.GenericColumnValue{row}, readAllAvroGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAvroReader_transcode(t *testing.T) {
+	t.Parallel()
+
+	var avro bytes.Buffer
+	w := mustNewAvroWriter(t, &avro, "T")
+	names := []string{"id", "name"}
+	md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+	rows := RowSeq(
+		mustNewSpannerRow(t, names, []any{int64(1), "a"}),
+		mustNewSpannerRow(t, names, []any{int64(2), spanner.NullString{}}),
+	)
+	if _, err := WriteRowSeq(md, rows, w); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAvroReader(&avro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := WriteRowSeq(r.Metadata(), r.Rows(), mustNewJSONLWriter(t, &out)); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":null}\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestAvroReader_fallbackAvroTypes(t *testing.T) {
+	t.Parallel()
+
+	schema := `{"type":"record","name":"R","fields":[
+		{"name":"i","type":"int"},
+		{"name":"d","type":["null",{"type":"bytes","logicalType":"decimal","precision":10,"scale":2}]},
+		{"name":"a","type":{"type":"array","items":"string"}}]}`
+	var buf bytes.Buffer
+	ocf, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &buf, Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ocf.Append([]any{map[string]any{
+		"i": int32(7),
+		"d": goavro.Union("bytes.decimal", big.NewRat(314, 100)),
+		"a": []any{"x", "y"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAvroReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.TableName(); got != "R" {
+		t.Errorf("TableName = %q, want R", got)
+	}
+	want := [][]spanner.GenericColumnValue{{
+		gcvctor.Int64Value(7),
+		gcvctor.NumericValue(big.NewRat(314, 100)),
+		gcvctor.MustArrayValue(gcvctor.StringValue("x"), gcvctor.StringValue("y")),
+	}}
+	if diff := cmp.Diff(want, readAllAvroGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAvroWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewAvroWriter(nil, "T"); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("nil output error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewAvroWriter(&bytes.Buffer{}, " "); !errors.Is(err, ErrEmptyTableName) {
+		t.Errorf("empty table error = %v, want ErrEmptyTableName", err)
+	}
+	if _, err := NewAvroWriter(&bytes.Buffer{}, "T", WithAvroCompression(AvroCompression(9))); !errors.Is(err, ErrInvalidAvroCompression) {
+		t.Errorf("invalid compression error = %v, want ErrInvalidAvroCompression", err)
+	}
+
+	tests := []struct {
+		name    string
+		rowType *sppb.StructType
+		want    error
+	}{
+		{
+			name:    "invalid name",
+			rowType: typector.MustNameCodeSlicesToStructType([]string{"a-b"}, []sppb.TypeCode{sppb.TypeCode_INT64}).GetStructType(),
+			want:    ErrInvalidAvroName,
+		},
+		{
+			name:    "duplicate name",
+			rowType: typector.MustNameCodeSlicesToStructType([]string{"a", "a"}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_INT64}).GetStructType(),
+			want:    ErrDuplicateColumnName,
+		},
+		{
+			name: "struct",
+			rowType: typector.MustNameTypeSlicesToStructType([]string{"s"}, []*sppb.Type{
+				typector.NameCodeToStructType("x", sppb.TypeCode_INT64),
+			}).GetStructType(),
+			want: ErrUnsupportedAvroType,
+		},
+	}
+	for _, tt := range tests {
+		w := mustNewAvroWriter(t, &bytes.Buffer{}, "T", WithRowType(tt.rowType))
+		if err := w.Flush(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Flush error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	w := mustNewAvroWriter(t, &bytes.Buffer{}, "T", WithRowType(tableTestRowType()))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.StringValue("1"), gcvctor.StringValue("a")}); !errors.Is(err, ErrAvroTypeMismatch) {
+		t.Errorf("mismatched value error = %v, want ErrAvroTypeMismatch", err)
+	}
+	w = mustNewAvroWriter(t, &bytes.Buffer{}, "T", WithColumnNames([]string{"id"}))
+	if err := w.Flush(); !errors.Is(err, ErrMissingFieldTypes) {
+		t.Errorf("names-only Flush error = %v, want ErrMissingFieldTypes", err)
+	}
+
+	fw := &failNthWrite{n: 1}
+	w = mustNewAvroWriter(t, fw, "T", WithRowType(tableTestRowType()))
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Fatalf("Flush error = %v, want errInjected", err)
+	}
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); !errors.Is(err, errInjected) {
+		t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+	}
+}
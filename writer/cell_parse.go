@@ -0,0 +1,621 @@
+package writer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// cellParser converts one cell of text back to a Spanner wire value of a known type.
+type cellParser struct {
+	format DelimitedCellFormat
+	null   string
+}
+
+func (p cellParser) parse(typ *sppb.Type, s string) (*structpb.Value, error) {
+	if p.format == DelimitedCellLiteral {
+		if s == p.null {
+			return structpb.NewNullValue(), nil
+		}
+		l := &literalParser{src: s}
+		v, err := l.value(typ)
+		if err != nil {
+			return nil, err
+		}
+		l.skipSpace()
+		if l.pos < len(l.src) {
+			return nil, l.errorf("unexpected %q after value", l.src[l.pos:])
+		}
+		return v, nil
+	}
+	return p.text(typ, s)
+}
+
+// text parses the output of [spanvalue.SimpleFormatConfig] (DelimitedCellSimple) or
+// [spanvalue.SpannerCLICompatibleFormatConfig] (DelimitedCellSpannerCLI).
+func (p cellParser) text(typ *sppb.Type, s string) (*structpb.Value, error) {
+	if s == p.null {
+		return structpb.NewNullValue(), nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		inner, ok := trimEnclosing(s, '[', ']')
+		if !ok {
+			return nil, fmt.Errorf("%w: ARRAY %q is not enclosed in []", ErrInvalidCell, s)
+		}
+		elems := splitTextElements(inner)
+		values := make([]*structpb.Value, len(elems))
+		for i, elem := range elems {
+			v, err := p.text(typ.GetArrayElementType(), elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case sppb.TypeCode_STRUCT:
+		open, closing := byte('('), byte(')')
+		if p.format == DelimitedCellSpannerCLI {
+			open, closing = '[', ']'
+		}
+		inner, ok := trimEnclosing(s, open, closing)
+		if !ok {
+			return nil, fmt.Errorf("%w: STRUCT %q is not enclosed in %c%c", ErrInvalidCell, s, open, closing)
+		}
+		fields := typ.GetStructType().GetFields()
+		elems := splitTextElements(inner)
+		if len(elems) != len(fields) {
+			return nil, fmt.Errorf("%w: STRUCT %q has %d fields, want %d", ErrInvalidCell, s, len(elems), len(fields))
+		}
+		values := make([]*structpb.Value, len(fields))
+		for i, f := range fields {
+			elem := elems[i]
+			if p.format == DelimitedCellSimple && f.GetName() != "" {
+				var ok bool
+				elem, ok = strings.CutSuffix(elem, " AS "+f.GetName())
+				if !ok {
+					return nil, fmt.Errorf("%w: STRUCT field %d does not end with AS %s", ErrInvalidCell, i, f.GetName())
+				}
+			}
+			v, err := p.text(f.GetType(), elem)
+			if err != nil {
+				return nil, fmt.Errorf("field %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		var b []byte
+		var err error
+		if p.format == DelimitedCellSpannerCLI {
+			b, err = base64.StdEncoding.DecodeString(s)
+		} else {
+			b, err = unescapeReadableBytes(s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v %q: %w", ErrInvalidCell, typ.GetCode(), s, err)
+		}
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString(b)), nil
+	default:
+		return scalarFromText(typ, s)
+	}
+}
+
+// scalarFromText parses the plain text of a scalar that is not BYTES or PROTO: the
+// wire string for most types, and the strconv forms for BOOL and floats.
+func scalarFromText(typ *sppb.Type, s string) (*structpb.Value, error) {
+	invalid := func(err error) error {
+		return fmt.Errorf("%w: %v %q: %w", ErrInvalidCell, typ.GetCode(), s, err)
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewBoolValue(b), nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(strconv.FormatInt(n, 10)), nil
+	case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		bits := 64
+		if typ.GetCode() == sppb.TypeCode_FLOAT32 {
+			bits = 32
+		}
+		f, err := strconv.ParseFloat(s, bits)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return floatWire(f), nil
+	case sppb.TypeCode_STRING:
+		return structpb.NewStringValue(s), nil
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			if s == "NaN" {
+				return structpb.NewStringValue(s), nil
+			}
+			if _, ok := new(big.Rat).SetString(s); !ok {
+				return nil, invalid(fmt.Errorf("not a decimal"))
+			}
+			return structpb.NewStringValue(s), nil
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, invalid(fmt.Errorf("not a decimal"))
+		}
+		return structpb.NewStringValue(spanner.NumericString(r)), nil
+	case sppb.TypeCode_DATE:
+		d, err := civil.ParseDate(s)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(d.String()), nil
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(t.UTC().Format(time.RFC3339Nano)), nil
+	case sppb.TypeCode_JSON:
+		if !json.Valid([]byte(s)) {
+			return nil, invalid(fmt.Errorf("not valid JSON"))
+		}
+		return structpb.NewStringValue(s), nil
+	case sppb.TypeCode_INTERVAL:
+		iv, err := spanner.ParseInterval(s)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(iv.String()), nil
+	case sppb.TypeCode_UUID:
+		u, err := uuid.Parse(s)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(u.String()), nil
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedCellType, typ.GetCode())
+	}
+}
+
+// floatWire encodes f the way Spanner sends floats: a number, or a string for
+// NaN and the infinities.
+func floatWire(f float64) *structpb.Value {
+	switch {
+	case math.IsNaN(f):
+		return structpb.NewStringValue("NaN")
+	case math.IsInf(f, 1):
+		return structpb.NewStringValue("Infinity")
+	case math.IsInf(f, -1):
+		return structpb.NewStringValue("-Infinity")
+	default:
+		return structpb.NewNumberValue(f)
+	}
+}
+
+func trimEnclosing(s string, open, closing byte) (string, bool) {
+	if len(s) < 2 || s[0] != open || s[len(s)-1] != closing {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// splitTextElements splits the inside of a text ARRAY or STRUCT at ", " separators
+// that are not nested in brackets, parentheses, or braces.
+func splitTextElements(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var elems []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 && i+1 < len(s) && s[i+1] == ' ' {
+				elems = append(elems, s[start:i])
+				start = i + 2
+				i++
+			}
+		}
+	}
+	return append(elems, s[start:])
+}
+
+// unescapeReadableBytes reverses the BYTES text of [spanvalue.SimpleFormatConfig]:
+// printable ASCII is kept as is, and other bytes and backslashes are escaped.
+func unescapeReadableBytes(s string) ([]byte, error) {
+	if !strings.Contains(s, `\`) {
+		return []byte(s), nil
+	}
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			out = append(out, c)
+			continue
+		}
+		if i+1 >= len(s) {
+			return nil, fmt.Errorf("trailing backslash")
+		}
+		i++
+		switch s[i] {
+		case 'x':
+			if i+2 >= len(s) {
+				return nil, fmt.Errorf(`short \x escape`)
+			}
+			n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid \x escape %q`, s[i-1:i+3])
+			}
+			out = append(out, byte(n))
+			i += 2
+		default:
+			out = append(out, s[i])
+		}
+	}
+	return out, nil
+}
+
+// literalParser reads the GoogleSQL literal expressions of [spanvalue.LiteralFormatConfig],
+// guided by the expected column type.
+type literalParser struct {
+	src string
+	pos int
+}
+
+func (l *literalParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: offset %d: %s", ErrInvalidCell, l.pos, fmt.Sprintf(format, args...))
+}
+
+func (l *literalParser) skipSpace() {
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case ' ', '\t', '\n', '\r':
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (l *literalParser) peek() byte {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos]
+}
+
+func (l *literalParser) expect(c byte) error {
+	if l.peek() != c {
+		return l.errorf("expected %q", c)
+	}
+	l.pos++
+	return nil
+}
+
+// keyword consumes word case-insensitively when it is the next identifier.
+func (l *literalParser) keyword(word string) bool {
+	l.skipSpace()
+	end := l.pos + len(word)
+	if end > len(l.src) || !strings.EqualFold(l.src[l.pos:end], word) {
+		return false
+	}
+	if end < len(l.src) && isIdentByte(l.src[end]) {
+		return false
+	}
+	l.pos = end
+	return true
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// skipType consumes a type name such as INT64, `pkg.Enum`, or ARRAY<STRUCT<x INT64>>.
+func (l *literalParser) skipType() error {
+	l.skipSpace()
+	start := l.pos
+	if l.pos < len(l.src) && l.src[l.pos] == '`' {
+		end := strings.IndexByte(l.src[l.pos+1:], '`')
+		if end < 0 {
+			return l.errorf("unterminated quoted identifier")
+		}
+		l.pos += end + 2
+		return nil
+	}
+	for l.pos < len(l.src) && (isIdentByte(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.pos++
+	}
+	if l.pos == start {
+		return l.errorf("expected type")
+	}
+	if l.peek() == '<' {
+		return l.skipAngle()
+	}
+	return nil
+}
+
+// skipAngle consumes a balanced <...> type parameter list.
+func (l *literalParser) skipAngle() error {
+	depth := 0
+	for ; l.pos < len(l.src); l.pos++ {
+		switch l.src[l.pos] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return nil
+			}
+		}
+	}
+	return l.errorf("unterminated type parameters")
+}
+
+func (l *literalParser) value(typ *sppb.Type) (*structpb.Value, error) {
+	if l.keyword("NULL") {
+		return structpb.NewNullValue(), nil
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_ARRAY:
+		return l.array(typ)
+	case sppb.TypeCode_STRUCT:
+		return l.structValue(typ)
+	}
+	if l.keyword("CAST") {
+		if err := l.expect('('); err != nil {
+			return nil, err
+		}
+		v, err := l.scalar(typ)
+		if err != nil {
+			return nil, err
+		}
+		if !l.keyword("AS") {
+			return nil, l.errorf("expected AS")
+		}
+		if err := l.skipType(); err != nil {
+			return nil, err
+		}
+		return v, l.expect(')')
+	}
+	return l.scalar(typ)
+}
+
+func (l *literalParser) array(typ *sppb.Type) (*structpb.Value, error) {
+	if l.keyword("ARRAY") && l.peek() == '<' {
+		if err := l.skipAngle(); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.expect('['); err != nil {
+		return nil, err
+	}
+	var values []*structpb.Value
+	for l.peek() != ']' {
+		if len(values) > 0 {
+			if err := l.expect(','); err != nil {
+				return nil, err
+			}
+		}
+		v, err := l.value(typ.GetArrayElementType())
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", len(values), err)
+		}
+		values = append(values, v)
+	}
+	l.pos++
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+func (l *literalParser) structValue(typ *sppb.Type) (*structpb.Value, error) {
+	if l.keyword("STRUCT") && l.peek() == '<' {
+		if err := l.skipAngle(); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.expect('('); err != nil {
+		return nil, err
+	}
+	fields := typ.GetStructType().GetFields()
+	values := make([]*structpb.Value, len(fields))
+	for i, f := range fields {
+		if i > 0 {
+			if err := l.expect(','); err != nil {
+				return nil, err
+			}
+		}
+		v, err := l.value(f.GetType())
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", i, err)
+		}
+		if l.keyword("AS") {
+			if err := l.skipType(); err != nil {
+				return nil, err
+			}
+		}
+		values[i] = v
+	}
+	if err := l.expect(')'); err != nil {
+		return nil, err
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+// scalar reads a scalar literal, skipping a type prefix such as DATE or NUMERIC
+// before a quoted payload.
+func (l *literalParser) scalar(typ *sppb.Type) (*structpb.Value, error) {
+	switch c := l.peek(); {
+	case c == 'b' || c == 'B':
+		if l.pos+1 < len(l.src) && (l.src[l.pos+1] == '"' || l.src[l.pos+1] == '\'') {
+			l.pos++
+			b, err := l.quoted(true)
+			if err != nil {
+				return nil, err
+			}
+			switch typ.GetCode() {
+			case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+				return structpb.NewStringValue(base64.StdEncoding.EncodeToString([]byte(b))), nil
+			}
+			return nil, l.errorf("bytes literal for %v", typ.GetCode())
+		}
+	case c == '"' || c == '\'':
+		s, err := l.quoted(false)
+		if err != nil {
+			return nil, err
+		}
+		switch typ.GetCode() {
+		case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO, sppb.TypeCode_BOOL:
+			return nil, l.errorf("string literal for %v", typ.GetCode())
+		case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+			// CAST("nan" AS FLOAT64) and friends.
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, l.errorf("%v %q: %v", typ.GetCode(), s, err)
+			}
+			return floatWire(f), nil
+		}
+		return scalarFromText(typ, s)
+	}
+	start := l.pos
+	for l.pos < len(l.src) && (isIdentByte(l.src[l.pos]) || strings.IndexByte("+-.", l.src[l.pos]) >= 0) {
+		l.pos++
+	}
+	token := l.src[start:l.pos]
+	if token == "" {
+		return nil, l.errorf("expected %v literal", typ.GetCode())
+	}
+	if c := l.peek(); c == '"' || c == '\'' {
+		// A type prefix such as TIMESTAMP "2024-01-02T03:04:05Z".
+		return l.scalar(typ)
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL, sppb.TypeCode_INT64, sppb.TypeCode_ENUM, sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		return scalarFromText(typ, strings.ToLower(token))
+	}
+	return nil, l.errorf("unexpected %q for %v", token, typ.GetCode())
+}
+
+// quoted reads a single- or double-quoted (optionally triple-quoted) string or bytes
+// literal body and resolves its escape sequences.
+func (l *literalParser) quoted(isBytes bool) (string, error) {
+	quote := l.src[l.pos]
+	delim := string(quote)
+	if strings.HasPrefix(l.src[l.pos:], strings.Repeat(delim, 3)) {
+		delim = strings.Repeat(delim, 3)
+	}
+	l.pos += len(delim)
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return "", l.errorf("unterminated literal")
+		}
+		if strings.HasPrefix(l.src[l.pos:], delim) {
+			l.pos += len(delim)
+			return b.String(), nil
+		}
+		c := l.src[l.pos]
+		if c != '\\' {
+			if len(delim) == 1 && (c == '\n' || c == '\r') {
+				return "", l.errorf("newline in literal")
+			}
+			b.WriteByte(c)
+			l.pos++
+			continue
+		}
+		if l.pos+1 >= len(l.src) {
+			return "", l.errorf("unterminated escape")
+		}
+		esc := l.src[l.pos+1]
+		l.pos += 2
+		switch esc {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '?', '"', '\'', '`':
+			b.WriteByte(esc)
+		case 'x', 'X':
+			n, err := l.hexDigits(2)
+			if err != nil {
+				return "", err
+			}
+			if isBytes {
+				b.WriteByte(byte(n))
+			} else {
+				b.WriteRune(rune(n))
+			}
+		case 'u', 'U':
+			width := 4
+			if esc == 'U' {
+				width = 8
+			}
+			n, err := l.hexDigits(width)
+			if err != nil {
+				return "", err
+			}
+			if !utf8.ValidRune(rune(n)) {
+				return "", l.errorf("invalid code point %#x", n)
+			}
+			b.WriteRune(rune(n))
+		case '0', '1', '2', '3':
+			if l.pos+2 > len(l.src) {
+				return "", l.errorf("short octal escape")
+			}
+			n, err := strconv.ParseUint(l.src[l.pos-1:l.pos+2], 8, 8)
+			if err != nil {
+				return "", l.errorf("invalid octal escape")
+			}
+			l.pos += 2
+			if isBytes {
+				b.WriteByte(byte(n))
+			} else {
+				b.WriteRune(rune(n))
+			}
+		default:
+			return "", l.errorf("invalid escape \\%c", esc)
+		}
+	}
+}
+
+func (l *literalParser) hexDigits(width int) (uint64, error) {
+	if l.pos+width > len(l.src) {
+		return 0, l.errorf("short hex escape")
+	}
+	n, err := strconv.ParseUint(l.src[l.pos:l.pos+width], 16, 32)
+	if err != nil {
+		return 0, l.errorf("invalid hex escape %q", l.src[l.pos:l.pos+width])
+	}
+	l.pos += width
+	return n, nil
+}
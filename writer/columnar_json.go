@@ -0,0 +1,358 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// ColumnarJSONOption configures a ColumnarJSONWriter created by [NewColumnarJSONWriter].
+type ColumnarJSONOption interface {
+	applyColumnarJSONOption(*ColumnarJSONWriter) error
+}
+
+type columnarJSONOptionFunc func(*ColumnarJSONWriter) error
+
+func (f columnarJSONOptionFunc) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	return f(w)
+}
+
+func applyColumnarJSONOptions(w *ColumnarJSONWriter, options ...ColumnarJSONOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyColumnarJSONOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ColumnarJSONLayout selects how [ColumnarJSONWriter] arranges values.
+type ColumnarJSONLayout int
+
+const (
+	// ColumnarJSONByColumn writes one array per column under "data", keyed by
+	// column name: {"columns":[...],"types":[...],"data":{"a":[...],"b":[...]}}.
+	// Duplicate column names produce duplicate keys.
+	ColumnarJSONByColumn ColumnarJSONLayout = iota
+	// ColumnarJSONByRow writes one array per row under "rows":
+	// {"columns":[...],"types":[...],"rows":[[...],[...]]}.
+	ColumnarJSONByRow
+)
+
+// String returns the Go constant name for l, or "ColumnarJSONLayout(n)" for unknown values.
+func (l ColumnarJSONLayout) String() string {
+	switch l {
+	case ColumnarJSONByColumn:
+		return "ColumnarJSONByColumn"
+	case ColumnarJSONByRow:
+		return "ColumnarJSONByRow"
+	default:
+		return fmt.Sprintf("ColumnarJSONLayout(%d)", int(l))
+	}
+}
+
+// WithColumnarJSONLayout selects the [ColumnarJSONWriter] layout (default
+// [ColumnarJSONByColumn]). Unknown layouts return [ErrInvalidColumnarJSONLayout].
+func WithColumnarJSONLayout(layout ColumnarJSONLayout) ColumnarJSONOption {
+	return columnarJSONOptionFunc(func(w *ColumnarJSONWriter) error {
+		if layout < ColumnarJSONByColumn || layout > ColumnarJSONByRow {
+			return fmt.Errorf("%w: %v", ErrInvalidColumnarJSONLayout, layout)
+		}
+		w.layout = layout
+		return nil
+	})
+}
+
+// WithColumnarJSONChunkRows switches [ColumnarJSONWriter] to chunked
+// streaming: every n rows are written as their own object, and
+// [ColumnarJSONWriter.Flush] writes the remaining partial chunk. n == 0 (the
+// default) buffers every row into one object written by Flush; negative n
+// returns [ErrInvalidChunkRows].
+func WithColumnarJSONChunkRows(n int) ColumnarJSONOption {
+	return columnarJSONOptionFunc(func(w *ColumnarJSONWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidChunkRows, n)
+		}
+		w.chunkRows = n
+		return nil
+	})
+}
+
+// ColumnarJSONWriter writes rows as column-oriented JSON objects for charting
+// front-ends, in the layout selected by [WithColumnarJSONLayout]. Each object
+// lists the column names and their [spantype.FormatTypeVerbose] types (null
+// when unknown) once, followed by the values without repeated keys. Values
+// use [spanvalue.JSONFormatConfig] unless [WithFormatter] is set.
+//
+// Unnamed columns are named with [spanvalue.IndexedUnnamedFieldNamer] ("_0",
+// "_1", ...) unless [WithUnnamedFieldNamer] is set; a nil namer keeps empty
+// names.
+//
+// By default every row is buffered and [ColumnarJSONWriter.Flush] writes one
+// object; [WithColumnarJSONChunkRows] streams one object per chunk instead.
+// Each object is followed by a newline, so chunked output is JSON Lines. When
+// nothing has been written since the last Flush, Flush still writes one
+// object with empty value arrays.
+//
+// Each object is emitted with a single Write. After the first output write
+// failure, every later Write*/Flush call returns that error; discard the
+// writer (see package doc "Write errors").
+type ColumnarJSONWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	layout            ColumnarJSONLayout
+	chunkRows         int
+
+	schema   columnSchema
+	rowTypes []*sppb.Type
+	// pending holds formatted JSON values of rows not yet written.
+	pending [][]string
+	// wroteChunk reports whether an object was written since the last Flush.
+	wroteChunk bool
+	out        io.Writer
+}
+
+// NewColumnarJSONWriter returns a columnar JSON writer configured by options.
+func NewColumnarJSONWriter(out io.Writer, options ...ColumnarJSONOption) (*ColumnarJSONWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &ColumnarJSONWriter{
+		formatter:         spanvalue.JSONFormatConfig(),
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		out:               out,
+	}
+	if err := applyColumnarJSONOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow buffers one row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *ColumnarJSONWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *ColumnarJSONWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *ColumnarJSONWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues buffers one row; see [DelimitedWriter.WriteValues].
+func (w *ColumnarJSONWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues buffers one row; see [DelimitedWriter.WriteStructValues].
+func (w *ColumnarJSONWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs buffers one row, writing a chunk once [WithColumnarJSONChunkRows]
+// rows are buffered; see [DelimitedWriter.WriteGCVs].
+func (w *ColumnarJSONWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 && len(values) != 0 {
+		return ErrMissingColumnNames
+	}
+	formattedValues, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	if w.rowTypes == nil {
+		w.rowTypes = make([]*sppb.Type, len(values))
+		for i, v := range values {
+			w.rowTypes[i] = v.Type
+		}
+	}
+	w.pending = append(w.pending, formattedValues)
+	if w.chunkRows > 0 && len(w.pending) >= w.chunkRows {
+		return w.writeChunk()
+	}
+	return nil
+}
+
+// Flush writes buffered rows as one object, or an object with empty value
+// arrays when no object was written since the last Flush. With no registered
+// schema, Flush returns [ErrMissingColumnNames]. After a write failure, Flush
+// returns the latched error (see package doc "Write errors").
+func (w *ColumnarJSONWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.pending) > 0 || !w.wroteChunk {
+		if err := w.writeChunk(); err != nil {
+			return err
+		}
+	}
+	w.wroteChunk = false
+	return nil
+}
+
+// FormatConfig returns the effective formatter used for JSON value encoding.
+// When no formatter is configured, this returns [spanvalue.JSONFormatConfig].
+// Configure it only via [NewColumnarJSONWriter] or [WithFormatter].
+func (w *ColumnarJSONWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.JSONFormatConfig()
+	}
+	return w.formatter
+}
+
+// writeChunk writes the pending rows as one object and clears them.
+func (w *ColumnarJSONWriter) writeChunk() error {
+	names, err := internal.ResolveColumnNames(w.schema.names, w.unnamedFieldNamer)
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	types := w.schema.types
+	if len(types) == 0 {
+		types = w.rowTypes
+	}
+	typeNames := make([]*string, len(names))
+	for i := range names {
+		if i < len(types) && types[i] != nil {
+			s := spantype.FormatTypeVerbose(types[i])
+			typeNames[i] = &s
+		}
+	}
+	columnsJSON, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	typesJSON, err := json.Marshal(typeNames)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`{"columns":`)
+	b.Write(columnsJSON)
+	b.WriteString(`,"types":`)
+	b.Write(typesJSON)
+	switch w.layout {
+	case ColumnarJSONByRow:
+		b.WriteString(`,"rows":[`)
+		for i, row := range w.pending {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('[')
+			b.WriteString(strings.Join(row, ","))
+			b.WriteByte(']')
+		}
+		b.WriteString("]}\n")
+	default:
+		keys, err := internal.MarshalJSONObjectKeys(names)
+		if err != nil {
+			return err
+		}
+		b.WriteString(`,"data":{`)
+		for col, key := range keys {
+			if col > 0 {
+				b.WriteByte(',')
+			}
+			b.Write(key)
+			b.WriteString(":[")
+			for i, row := range w.pending {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(row[col])
+			}
+			b.WriteByte(']')
+		}
+		b.WriteString("}}\n")
+	}
+	w.pending = nil
+	if _, err := io.WriteString(w.out, b.String()); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.wroteChunk = true
+	return nil
+}
+
+func (w *ColumnarJSONWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.rowTypes = nil
+}
+
+func (w *ColumnarJSONWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.rowTypes = nil
+}
+
+func (w *ColumnarJSONWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
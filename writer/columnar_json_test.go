@@ -0,0 +1,130 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*ColumnarJSONWriter)(nil)
+
+func TestColumnarJSONWriter_layouts(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("a")},
+		{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+		{gcvctor.Int64Value(3), gcvctor.StringValue("c")},
+	}
+	tests := []struct {
+		name    string
+		options []ColumnarJSONOption
+		want    string
+	}{
+		{
+			name: "by column",
+			want: `{"columns":["id","name"],"types":["INT64","STRING"],"data":{"id":[1,2,3],"name":["a",null,"c"]}}` + "\n",
+		},
+		{
+			name:    "by row",
+			options: []ColumnarJSONOption{WithColumnarJSONLayout(ColumnarJSONByRow)},
+			want:    `{"columns":["id","name"],"types":["INT64","STRING"],"rows":[[1,"a"],[2,null],[3,"c"]]}` + "\n",
+		},
+		{
+			name:    "chunked",
+			options: []ColumnarJSONOption{WithColumnarJSONChunkRows(2)},
+			want: `{"columns":["id","name"],"types":["INT64","STRING"],"data":{"id":[1,2],"name":["a",null]}}` + "\n" +
+				`{"columns":["id","name"],"types":["INT64","STRING"],"data":{"id":[3],"name":["c"]}}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewColumnarJSONWriter(t, &out, append([]ColumnarJSONOption{WithRowType(tableTestRowType())}, tt.options...)...)
+			for _, row := range rows {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output mismatch\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnarJSONWriter_chunkBoundaryAndEmpty(t *testing.T) {
+	t.Parallel()
+
+	// A Flush right after a full chunk writes nothing more.
+	var out bytes.Buffer
+	w := mustNewColumnarJSONWriter(t, &out, WithColumnarJSONChunkRows(1), WithColumnarJSONLayout(ColumnarJSONByRow))
+	if err := w.WriteValues([]string{"", "x"}, []spanner.GenericColumnValue{gcvctor.BoolValue(true), gcvctor.Int64Value(1)}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"columns":["_0","x"],"types":["BOOL","INT64"],"rows":[[true,1]]}` + "\n"
+	if got := out.String(); got != want {
+		t.Fatalf("output after first chunk = %s, want %s", got, want)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != want {
+		t.Errorf("Flush after full chunk wrote %q", got[len(want):])
+	}
+
+	out.Reset()
+	w = mustNewColumnarJSONWriter(t, &out, WithColumnNames([]string{"a"}))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `{"columns":["a"],"types":[null],"data":{"a":[]}}`+"\n"; got != want {
+		t.Errorf("empty output = %s, want %s", got, want)
+	}
+
+	out.Reset()
+	w = mustNewColumnarJSONWriter(t, &out, WithRowType(nil))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `{"columns":[],"types":[],"data":{}}`+"\n"; got != want {
+		t.Errorf("zero-column output = %s, want %s", got, want)
+	}
+}
+
+func TestColumnarJSONWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if _, err := NewColumnarJSONWriter(&out, WithColumnarJSONLayout(ColumnarJSONLayout(9))); !errors.Is(err, ErrInvalidColumnarJSONLayout) {
+		t.Errorf("invalid layout error = %v, want ErrInvalidColumnarJSONLayout", err)
+	}
+	if _, err := NewColumnarJSONWriter(&out, WithColumnarJSONChunkRows(-1)); !errors.Is(err, ErrInvalidChunkRows) {
+		t.Errorf("negative chunk rows error = %v, want ErrInvalidChunkRows", err)
+	}
+	if err := mustNewColumnarJSONWriter(t, &out).Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+
+	fw := &failNthWrite{n: 1}
+	w := mustNewColumnarJSONWriter(t, fw, WithColumnNames([]string{"a"}), WithColumnarJSONChunkRows(1))
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1)}
+	if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+		t.Fatalf("WriteGCVs error = %v, want errInjected", err)
+	}
+	if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+		t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+	}
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Errorf("Flush after failure error = %v, want errInjected", err)
+	}
+}
@@ -0,0 +1,331 @@
+package writer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+)
+
+// DelimitedCellFormat selects how [DelimitedReader] parses cell text.
+type DelimitedCellFormat int
+
+const (
+	// DelimitedCellSimple parses the text of [spanvalue.SimpleFormatConfig], the
+	// [DelimitedWriter] default: NULL is "<null>" and BYTES are readable escapes.
+	DelimitedCellSimple DelimitedCellFormat = iota
+	// DelimitedCellSpannerCLI parses the text of [spanvalue.SpannerCLICompatibleFormatConfig]:
+	// NULL is "NULL", BYTES are base64, and STRUCT values use brackets.
+	DelimitedCellSpannerCLI
+	// DelimitedCellLiteral parses GoogleSQL literals as written by
+	// [spanvalue.LiteralFormatConfig], such as "a'b", b"\x00", DATE "2024-01-02",
+	// CAST("nan" AS FLOAT64), and ARRAY<STRUCT<x INT64>>[(1)].
+	DelimitedCellLiteral
+)
+
+// String returns the Go constant name for f, or "DelimitedCellFormat(n)" for unknown values.
+func (f DelimitedCellFormat) String() string {
+	switch f {
+	case DelimitedCellSimple:
+		return "DelimitedCellSimple"
+	case DelimitedCellSpannerCLI:
+		return "DelimitedCellSpannerCLI"
+	case DelimitedCellLiteral:
+		return "DelimitedCellLiteral"
+	default:
+		return fmt.Sprintf("DelimitedCellFormat(%d)", int(f))
+	}
+}
+
+// nullString returns the NULL rendering of the preset that f parses.
+func (f DelimitedCellFormat) nullString() string {
+	if f == DelimitedCellSimple {
+		return "<null>"
+	}
+	return "NULL"
+}
+
+// DelimitedReaderOption configures a DelimitedReader created by [NewDelimitedReader]
+// or [NewCSVReader].
+type DelimitedReaderOption interface {
+	applyDelimitedReaderOption(*DelimitedReader) error
+}
+
+type delimitedReaderOptionFunc func(*DelimitedReader) error
+
+func (f delimitedReaderOptionFunc) applyDelimitedReaderOption(r *DelimitedReader) error {
+	return f(r)
+}
+
+func applyDelimitedReaderOptions(r *DelimitedReader, options ...DelimitedReaderOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyDelimitedReaderOption(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReaderOption configures any reader created by [NewDelimitedReader], [NewCSVReader],
+// or [NewJSONLReader].
+type ReaderOption interface {
+	DelimitedReaderOption
+	JSONLReaderOption
+}
+
+type readerRowTypeOption struct {
+	rowType *sppb.StructType
+}
+
+// WithReaderRowType sets the column names and types of the records.
+//
+// [DelimitedReader] records must have one field per column; a header, when
+// present, must list the column names in order (unnamed columns accept any header
+// name). It replaces [WithReaderColumnTypes]. [JSONLReader] matches object keys
+// against the column names; see [NewJSONLReader].
+func WithReaderRowType(rowType *sppb.StructType) ReaderOption {
+	return readerRowTypeOption{rowType: rowType}
+}
+
+func (o readerRowTypeOption) applyDelimitedReaderOption(r *DelimitedReader) error {
+	r.rowType = normalizeRowType(o.rowType)
+	r.columnTypes = nil
+	return nil
+}
+
+func (o readerRowTypeOption) applyJSONLReaderOption(r *JSONLReader) error {
+	r.rowType = normalizeRowType(o.rowType)
+	return nil
+}
+
+// WithReaderColumnTypes takes the column order from the header and the type of each
+// column from types, keyed by header name. The header is required, and a header
+// name missing from types returns [ErrMissingColumnType]. It replaces
+// [WithReaderRowType].
+func WithReaderColumnTypes(types map[string]*sppb.Type) DelimitedReaderOption {
+	return delimitedReaderOptionFunc(func(r *DelimitedReader) error {
+		r.columnTypes = types
+		r.rowType = nil
+		return nil
+	})
+}
+
+// WithReaderHeader sets whether the first record of [DelimitedReader] input is a
+// header (default true, matching [WithHeader]).
+func WithReaderHeader(header bool) DelimitedReaderOption {
+	return delimitedReaderOptionFunc(func(r *DelimitedReader) error {
+		r.header = header
+		return nil
+	})
+}
+
+// WithReaderCellFormat selects how [DelimitedReader] parses cells (default
+// [DelimitedCellSimple]). Unknown formats return [ErrInvalidDelimitedCellFormat].
+func WithReaderCellFormat(format DelimitedCellFormat) DelimitedReaderOption {
+	return delimitedReaderOptionFunc(func(r *DelimitedReader) error {
+		if format < DelimitedCellSimple || format > DelimitedCellLiteral {
+			return fmt.Errorf("%w: %v", ErrInvalidDelimitedCellFormat, format)
+		}
+		r.format = format
+		return nil
+	})
+}
+
+// WithReaderNullString sets the cell text that [DelimitedReader] reads as NULL,
+// overriding the NULL rendering of the cell format ("<null>" for
+// [DelimitedCellSimple], "NULL" otherwise). For text formats it also applies to
+// ARRAY elements and STRUCT fields; literal cells always spell nested NULLs as NULL.
+func WithReaderNullString(null string) DelimitedReaderOption {
+	return delimitedReaderOptionFunc(func(r *DelimitedReader) error {
+		r.null = &null
+		return nil
+	})
+}
+
+// DelimitedReadError reports a record or cell that [DelimitedReader] could not
+// read, with its position, while preserving the wrapped cause for [errors.Is]
+// and [errors.As].
+type DelimitedReadError struct {
+	// Row is the 1-based data record number, not counting the header.
+	Row int
+	// Line is the 1-based input line where the record or cell starts.
+	Line int
+	// Column is the 1-based column, or 0 when the error concerns the whole record.
+	Column int
+	// Name is the column name, if any.
+	Name string
+	Err  error
+}
+
+func (e *DelimitedReadError) Error() string {
+	switch {
+	case e.Column == 0:
+		return fmt.Sprintf("row %d (line %d): %v", e.Row, e.Line, e.Err)
+	case e.Name == "":
+		return fmt.Sprintf("row %d (line %d), column %d: %v", e.Row, e.Line, e.Column, e.Err)
+	default:
+		return fmt.Sprintf("row %d (line %d), column %d (%q): %v", e.Row, e.Line, e.Column, e.Name, e.Err)
+	}
+}
+
+func (e *DelimitedReadError) Unwrap() error {
+	return e.Err
+}
+
+// DelimitedReader parses CSV-style delimited text, such as [DelimitedWriter]
+// output or hand-written fixtures, back to Spanner values. Column types come
+// from [WithReaderRowType], or from the header and [WithReaderColumnTypes].
+//
+// Cells are parsed according to [WithReaderCellFormat]. Literal cells round-trip
+// every value exactly. Text cells of ARRAY and STRUCT values are split at
+// top-level ", " separators, so elements whose own text contains ", " or
+// unbalanced brackets cannot be recovered, and the Spanner CLI format rounds
+// floats to six decimals; use literal cells for lossless re-imports.
+type DelimitedReader struct {
+	csv         *csv.Reader
+	rowType     *sppb.StructType
+	columnTypes map[string]*sppb.Type
+	names       []string
+	header      bool
+	format      DelimitedCellFormat
+	null        *string
+	parser      cellParser
+	row         int
+}
+
+// NewCSVReader returns a comma-delimited CSV reader configured by options.
+// It is a thin helper for NewDelimitedReader(in, Comma, opts...).
+func NewCSVReader(in io.Reader, opts ...DelimitedReaderOption) (*DelimitedReader, error) {
+	return NewDelimitedReader(in, Comma, opts...)
+}
+
+// NewDelimitedReader returns a reader of delimiter-separated records configured by
+// options, following encoding/csv quoting rules. When the header is enabled (the
+// default), it reads and validates the header before returning. Without
+// [WithReaderRowType] or [WithReaderColumnTypes], or with column types but no
+// header, it returns [ErrMissingColumnNames].
+func NewDelimitedReader(in io.Reader, delimiter rune, options ...DelimitedReaderOption) (*DelimitedReader, error) {
+	if in == nil {
+		return nil, ErrNilInputReader
+	}
+	if !validDelimiter(delimiter) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDelimiter, delimiter)
+	}
+	r := &DelimitedReader{header: true}
+	if err := applyDelimitedReaderOptions(r, options...); err != nil {
+		return nil, err
+	}
+	if r.rowType == nil && (r.columnTypes == nil || !r.header) {
+		return nil, ErrMissingColumnNames
+	}
+	r.parser = cellParser{format: r.format, null: r.format.nullString()}
+	if r.null != nil {
+		r.parser.null = *r.null
+	}
+	r.csv = csv.NewReader(in)
+	r.csv.Comma = delimiter
+	r.csv.FieldsPerRecord = -1
+	if r.rowType != nil {
+		r.names = columnNamesFromRowType(r.rowType)
+	}
+	if !r.header {
+		return r, nil
+	}
+	header, err := r.csv.Read()
+	if errors.Is(err, io.EOF) && r.rowType != nil && len(r.names) == 0 {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if r.rowType == nil {
+		fields := make([]*sppb.StructType_Field, len(header))
+		for i, name := range header {
+			typ, ok := r.columnTypes[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrMissingColumnType, name)
+			}
+			fields[i] = typector.NameTypeToStructTypeField(name, typ)
+		}
+		r.rowType = &sppb.StructType{Fields: fields}
+		r.names = header
+		return r, nil
+	}
+	if len(header) != len(r.names) {
+		return nil, fmt.Errorf("%w: header %q, row type %q", ErrColumnNamesMismatch, header, r.names)
+	}
+	for i, name := range r.names {
+		if name != "" && header[i] != name {
+			return nil, fmt.Errorf("%w: header %q, row type %q", ErrColumnNamesMismatch, header, r.names)
+		}
+	}
+	return r, nil
+}
+
+// RowType returns the column names and types of the records.
+func (r *DelimitedReader) RowType() *sppb.StructType {
+	return r.rowType
+}
+
+// Metadata returns result set metadata holding [DelimitedReader.RowType], for
+// [WriteRowSeq].
+func (r *DelimitedReader) Metadata() *sppb.ResultSetMetadata {
+	return &sppb.ResultSetMetadata{RowType: r.rowType}
+}
+
+// ReadGCVs parses the next record into one value per column. It returns [io.EOF]
+// after the last record. Records with the wrong number of fields and cells that
+// do not parse return a [*DelimitedReadError]; the reader may continue with the
+// next record after either.
+func (r *DelimitedReader) ReadGCVs() ([]spanner.GenericColumnValue, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.row++
+	line, _ := r.csv.FieldPos(0)
+	fields := r.rowType.GetFields()
+	if len(record) != len(fields) {
+		return nil, &DelimitedReadError{Row: r.row, Line: line,
+			Err: fmt.Errorf("%w: got %d, want %d", ErrFieldCount, len(record), len(fields))}
+	}
+	values := make([]spanner.GenericColumnValue, len(fields))
+	for i, f := range fields {
+		v, err := r.parser.parse(f.GetType(), record[i])
+		if err != nil {
+			line, _ := r.csv.FieldPos(i)
+			return nil, &DelimitedReadError{Row: r.row, Line: line, Column: i + 1, Name: f.GetName(), Err: err}
+		}
+		values[i] = spanner.GenericColumnValue{Type: f.GetType(), Value: v}
+	}
+	return values, nil
+}
+
+// Rows returns the remaining records as rows, for [WriteRowSeq] and
+// [RunRowSeq]. Iteration stops after the first error.
+func (r *DelimitedReader) Rows() iter.Seq2[*spanner.Row, error] {
+	return func(yield func(*spanner.Row, error) bool) {
+		for {
+			values, err := r.ReadGCVs()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			row, err := spanner.NewRow(r.names, gcvsAsAny(values))
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+func readAllDelimitedGCVs(t *testing.T, r *DelimitedReader) [][]spanner.GenericColumnValue {
+	t.Helper()
+	var rows [][]spanner.GenericColumnValue
+	for {
+		values, err := r.ReadGCVs()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, values)
+	}
+}
+
+// delimitedReaderRoundTripRows returns a row of every scalar type plus ARRAY and
+// STRUCT columns, and a row of NULLs. Text cells must survive the ", " split, so
+// no STRING contains one.
+func delimitedReaderRoundTripRows() (*sppb.StructType, [][]spanner.GenericColumnValue) {
+	pointType := typector.MustNameTypeSlicesToStructType([]string{"x", ""}, []*sppb.Type{typector.Int64(), typector.String()})
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"id", "b", "f32", "f64", "s", "by", "ts", "d", "n", "j", "u", "iv", "p", "e", "arr", "st", "sts"},
+		[]*sppb.Type{
+			typector.Int64(), typector.Bool(), typector.Float32(), typector.Float64(),
+			typector.String(), typector.Bytes(), typector.Timestamp(), typector.Date(),
+			typector.Numeric(), typector.JSON(), typector.UUID(), typector.Interval(),
+			typector.FQNToProtoType("pkg.Msg"), typector.FQNToEnumType("pkg.Enum"),
+			typector.ElemCodeToArrayType(sppb.TypeCode_STRING), pointType,
+			typector.ElemTypeToArrayType(pointType),
+		},
+	).GetStructType()
+	point := gcvctor.MustStructValueOf([]string{"x", ""}, []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("east")})
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(-1), gcvctor.BoolValue(true), gcvctor.Float32Value(1.5), gcvctor.Float64Value(-2.25),
+			gcvctor.StringValue("it's \"q\""), gcvctor.BytesValue([]byte("a\x00\\")),
+			gcvctor.TimestampValue(time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)),
+			gcvctor.MustDateStringValue("2024-01-02"),
+			gcvctor.NumericValue(big.NewRat(-12345, 100)),
+			gcvctor.MustJSONStringValue(`{"a":[1,2]}`),
+			gcvctor.MustUUIDStringValue("8f2c6a3e-7f4b-4d0e-9a51-2f3c4d5e6f70"),
+			gcvctor.MustIntervalStringValue("P1Y2M3DT4H5M6S"),
+			gcvctor.ProtoValue("pkg.Msg", []byte("pb")), gcvctor.EnumValue("pkg.Enum", 3),
+			gcvctor.MustArrayValue(gcvctor.StringValue("a"), gcvctor.NullFromCode(sppb.TypeCode_STRING), gcvctor.StringValue("[b]")),
+			point, gcvctor.MustArrayValue(point, point),
+		},
+		make([]spanner.GenericColumnValue, len(rowType.GetFields())),
+	}
+	for i, f := range rowType.GetFields() {
+		rows[1][i] = gcvctor.NullOf(f.GetType())
+	}
+	return rowType, rows
+}
+
+func TestDelimitedReader_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		formatter *spanvalue.FormatConfig
+		format    DelimitedCellFormat
+	}{
+		{"simple", spanvalue.SimpleFormatConfig(), DelimitedCellSimple},
+		{"spanner CLI", spanvalue.SpannerCLICompatibleFormatConfig(), DelimitedCellSpannerCLI},
+		{"literal", spanvalue.LiteralFormatConfig(), DelimitedCellLiteral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rowType, rows := delimitedReaderRoundTripRows()
+			var out bytes.Buffer
+			w := mustNewDelimitedWriter(t, &out, '\t', WithRowType(rowType), WithFormatter(tt.formatter))
+			for _, row := range rows {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewDelimitedReader(&out, '\t', WithReaderRowType(rowType), WithReaderCellFormat(tt.format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(rows, readAllDelimitedGCVs(t, r), protocmp.Transform()); diff != "" {
+				t.Errorf("values mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelimitedReader_literal(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"s", "f", "arr"},
+		[]*sppb.Type{typector.String(), typector.Float64(), typector.ElemCodeToArrayType(sppb.TypeCode_STRING)},
+	).GetStructType()
+	in := "s,f,arr\n" +
+		`"'a, b\n'",CAST('-inf' AS FLOAT64),"[""x, y"", NULL, '''z''']"` + "\n" +
+		`"""""""a""b""""""",1e+21,ARRAY<STRING>[]` + "\n"
+	r, err := NewCSVReader(strings.NewReader(in), WithReaderRowType(rowType), WithReaderCellFormat(DelimitedCellLiteral))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.StringValue("a, b\n"), gcvctor.Float64Value(math.Inf(-1)),
+			gcvctor.MustArrayValue(gcvctor.StringValue("x, y"), gcvctor.NullFromCode(sppb.TypeCode_STRING), gcvctor.StringValue("z")),
+		},
+		{gcvctor.StringValue(`a"b`), gcvctor.Float64Value(1e21), gcvctor.EmptyArrayFromCode(sppb.TypeCode_STRING)},
+	}
+	if diff := cmp.Diff(want, readAllDelimitedGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDelimitedReader_columnTypes(t *testing.T) {
+	t.Parallel()
+
+	in := "name,id\nalice,1\n,2\n"
+	r, err := NewCSVReader(strings.NewReader(in), WithReaderColumnTypes(map[string]*sppb.Type{
+		"id":   typector.Int64(),
+		"name": typector.String(),
+	}), WithReaderNullString(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRowType := typector.MustNameTypeSlicesToStructType([]string{"name", "id"}, []*sppb.Type{typector.String(), typector.Int64()}).GetStructType()
+	if diff := cmp.Diff(wantRowType, r.RowType(), protocmp.Transform()); diff != "" {
+		t.Errorf("RowType mismatch (-want +got):\n%s", diff)
+	}
+
+	var out bytes.Buffer
+	if _, err := WriteRowSeq(r.Metadata(), r.Rows(), mustNewJSONLWriter(t, &out)); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"name\":\"alice\",\"id\":1}\n{\"name\":null,\"id\":2}\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestDelimitedReader_errors(t *testing.T) {
+	t.Parallel()
+
+	rowType := tableTestRowType()
+	t.Run("cell", func(t *testing.T) {
+		t.Parallel()
+		r, err := NewCSVReader(strings.NewReader("id,name\n1,a\n\"2\n\",b\nx,c\n"), WithReaderRowType(rowType))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadGCVs(); err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.ReadGCVs()
+		var readErr *DelimitedReadError
+		if !errors.As(err, &readErr) || !errors.Is(err, ErrInvalidCell) {
+			t.Fatalf("ReadGCVs() error = %v, want DelimitedReadError wrapping ErrInvalidCell", err)
+		}
+		if readErr.Row != 2 || readErr.Line != 3 || readErr.Column != 1 || readErr.Name != "id" {
+			t.Errorf("error position = %+v, want row 2, line 3, column 1 (id)", readErr)
+		}
+		// The reader continues with the next record.
+		_, err = r.ReadGCVs()
+		if !errors.As(err, &readErr) || readErr.Row != 3 || readErr.Line != 5 {
+			t.Errorf("ReadGCVs() error = %v, want row 3 at line 5", err)
+		}
+	})
+	t.Run("field count", func(t *testing.T) {
+		t.Parallel()
+		r, err := NewCSVReader(strings.NewReader("1\n"), WithReaderRowType(rowType), WithReaderHeader(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.ReadGCVs()
+		var readErr *DelimitedReadError
+		if !errors.As(err, &readErr) || !errors.Is(err, ErrFieldCount) || readErr.Column != 0 {
+			t.Errorf("ReadGCVs() error = %v, want ErrFieldCount for the record", err)
+		}
+	})
+	for _, tt := range []struct {
+		name string
+		in   string
+		opts []DelimitedReaderOption
+		want error
+	}{
+		{"no schema", "id\n", nil, ErrMissingColumnNames},
+		{"column types without header", "1\n", []DelimitedReaderOption{WithReaderColumnTypes(map[string]*sppb.Type{}), WithReaderHeader(false)}, ErrMissingColumnNames},
+		{"header mismatch", "id,title\n", []DelimitedReaderOption{WithReaderRowType(rowType)}, ErrColumnNamesMismatch},
+		{"unknown header name", "id\n", []DelimitedReaderOption{WithReaderColumnTypes(map[string]*sppb.Type{})}, ErrMissingColumnType},
+		{"cell format", "", []DelimitedReaderOption{WithReaderRowType(rowType), WithReaderCellFormat(DelimitedCellFormat(9))}, ErrInvalidDelimitedCellFormat},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewCSVReader(strings.NewReader(tt.in), tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewCSVReader() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewCSVReader(nil); !errors.Is(err, ErrNilInputReader) {
+		t.Errorf("NewCSVReader(nil) error = %v, want ErrNilInputReader", err)
+	}
+}
@@ -1,7 +1,9 @@
-// Package writer streams Spanner query results to delimited text, JSONL, or SQL INSERT
-// using [github.com/apstndb/spanvalue] formatters.
+// Package writer streams Spanner query results to quoted or raw delimited text, JSONL, SQL INSERT, UPDATE, or DELETE,
+// parameterized statements, text tables, PostgreSQL COPY input, Parquet, Avro, and XLSX files, Arrow record batches,
+// or Spanner mutations using [github.com/apstndb/spanvalue] formatters.
 //
-// Main types: [DelimitedWriter], [JSONLWriter], [SQLInsertWriter], and the [Writer] /
+// Main types: [DelimitedWriter], [JSONLWriter], [JSONWriter], [ColumnarJSONWriter], [SQLInsertWriter], [SQLUpdateWriter], [SQLDeleteWriter], [SQLStatementWriter], [TableWriter], [VerticalWriter], [MarkdownWriter],
+// [HTMLWriter], [ParquetWriter], [AvroWriter], [ArrowWriter], [XLSXWriter], [PGCopyWriter], [RawDelimitedWriter], [MutationWriter], [DelimitedReader], [JSONLReader], and the [Writer] /
 // [FlushWriter] interfaces. Register column schema with [WithColumnNames], [WithRowType],
 // or [WithMetadata] (or [DelimitedWriter.PrepareRowType] / [DelimitedWriter.PrepareColumnNames]
 // after construction). [DelimitedWriter] buffers through encoding/csv—call [Flusher.Flush]
@@ -13,7 +15,7 @@
 // # RowIterator
 //
 // [WriteRowIterator] targets built-in [RowIteratorWriter] implementations
-// ([DelimitedWriter], [JSONLWriter], [SQLInsertWriter]) via [RowIteratorHooksFromWriter].
+// ([DelimitedWriter], [JSONLWriter], [JSONWriter], [ColumnarJSONWriter], [SQLInsertWriter], [SQLUpdateWriter], [SQLDeleteWriter], [SQLStatementWriter], [TableWriter], [VerticalWriter], [MarkdownWriter], [HTMLWriter], [ParquetWriter], [AvroWriter], [ArrowWriter], [XLSXWriter], [PGCopyWriter], [RawDelimitedWriter], [MutationWriter]) via [RowIteratorHooksFromWriter].
 // [RunRowIterator] is the extension point for other sinks: supply [RowIteratorHooks] built with
 // [NewRowIteratorHooks] and the With* setters, or decorate with [WithRowOrdinal],
 // [ObserveWriteRow], and [AfterEachSuccessfulWriteRow]. Both helpers own the iterator they
@@ -33,7 +35,9 @@
 // [RunRowIterator] hook contract. When the row type is only known after producing begins
 // (merged concurrent sources such as partitioned-query fan-in), [RunRowSeqDeferredMetadata]
 // accepts a metadata func evaluated after the first pull, so producers publish the row type
-// before their first yield instead of holding rows back.
+// before their first yield instead of holding rows back. Go domain structs tagged with
+// `spanner:"name"` stream through [WriteGoValues], or [GoValueRowSeq] for the hook-based
+// entry points; see [github.com/apstndb/spanvalue/gcvctor.RowFromGo].
 //
 // # Direct writers vs hooks
 //
@@ -49,8 +53,21 @@
 // # Quoted delimited text vs raw tab-separated
 //
 // [DelimitedWriter] uses encoding/csv rules (RFC 4180-style). [NewDelimitedWriter] with
-// delimiter '\t' produces quoted TSV, not a raw join of formatted strings. Legacy raw TAB
-// export can implement [Writer] or [RowIteratorWriter] and join columns with '\t'.
+// delimiter '\t' produces quoted TSV, not a raw join of formatted strings. For raw TAB or
+// other unquoted output, use [NewRawTSVWriter] or [NewRawDelimitedWriter]: fields are
+// joined as-is, and [WithRawEscapePolicy] decides what happens to embedded delimiters and
+// line breaks—backslash escapes for MySQL LOAD DATA and Hive ([RawEscapeBackslash], the
+// default), replacement, rejection with [ErrRawDelimitedSpecialChar], or pass-through.
+// [WithRawNullString] and [WithRawLineTerminator] set the NULL token and line terminator.
+//
+// [NewDelimitedReader] and [NewCSVReader] read such text back. Column types come from
+// [WithReaderRowType], or from the header and [WithReaderColumnTypes];
+// [WithReaderCellFormat] selects the [spanvalue.SimpleFormatConfig],
+// [spanvalue.SpannerCLICompatibleFormatConfig], or literal cell syntax, and
+// [WithReaderNullString] the NULL token. Parse failures are [*DelimitedReadError]
+// values carrying the row, line, and column; [DelimitedReader.Rows] and
+// [DelimitedReader.Metadata] feed [WriteRowSeq]. Only literal cells round-trip
+// ARRAY and STRUCT values whose text contains ", ".
 //
 // # Column names and registered schema
 //
@@ -71,9 +88,89 @@
 // output is attempted (for example [ErrMissingColumnNames] or
 // [ErrColumnNamesMismatch]) are not latched.
 //
+// # JSON documents
+//
+// [NewJSONWriter] streams one JSON document: a top-level array of [JSONLWriter]-style row
+// objects, or with [WithJSONEnvelope] an object holding column metadata, the rows, and stats.
+// Flush closes the document, so zero rows still produce valid JSON. Writers implementing
+// [ResultFinisher] receive the [RowIteratorResult] from [RowIteratorHooksFromWriter], which
+// is how the envelope records query stats and row counts.
+//
+// [NewColumnarJSONWriter] writes column-oriented objects for charting front-ends: names
+// and types once, then one array per column ([ColumnarJSONByColumn]) or one compact array
+// per row ([ColumnarJSONByRow]). Rows are buffered into one object by default;
+// [WithColumnarJSONChunkRows] streams an object per chunk of rows.
+//
+// [NewJSONLReader] reads [JSONLWriter] output back for a row type registered with
+// [WithReaderRowType], matching object keys to column names (unnamed columns by
+// [WithUnnamedFieldNamer]). [WithJSONLMissingKeys] and [WithJSONLExtraKeys] choose
+// between rejecting and tolerating absent or unknown keys. JSON null values are
+// written like SQL NULL and read back as SQL NULL. [JSONLReader.Rows] and
+// [JSONLReader.Metadata] feed [WriteRowSeq], so JSONL converts to any other format
+// in a few lines.
+//
+// # Parquet
+//
+// [NewParquetWriter] writes an Apache Parquet file whose schema follows the row type:
+// NUMERIC becomes DECIMAL(38,9), TIMESTAMP a UTC timestamp in microseconds (or
+// nanoseconds with [WithParquetTimestampNanos]), ARRAY a LIST, and STRUCT a group. Values are
+// written typed rather than formatted. [WithParquetRowGroupRows] caps row group size and
+// [WithParquetCompression] selects the codec. [*ParquetWriter.Flush] writes the footer and
+// finishes the file; later writes return [ErrWriterClosed].
+//
+// # Avro
+//
+// [NewAvroWriter] writes an Avro object container file in the layout of the Cloud Spanner
+// Dataflow export template: a record named after the table whose fields carry the column
+// type in "sqlType", so the import template can load it. [WithSQLDialect] selects GoogleSQL
+// or PostgreSQL type names and [WithAvroPrimaryKey] records the key. [NewAvroReader]
+// decodes such files back to values and a row type; its [AvroReader.Rows] and
+// [AvroReader.Metadata] feed [WriteRowSeq] to convert Avro to any other format.
+//
+// # Arrow
+//
+// [NewArrowWriter] builds Apache Arrow record batches and writes them in the IPC stream format,
+// or the file format with [WithArrowIPCFormat], for DuckDB, Polars, and pandas. NUMERIC becomes
+// decimal128(38, 9), TIMESTAMP timestamp[ns, UTC], DATE date32, ARRAY a list, STRUCT a struct,
+// and JSON and UUID the canonical extension types. [WithArrowBatchRows] sets the batch size.
+// [*ArrowWriter.Flush] writes the last batch and ends the stream; later writes return
+// [ErrWriterClosed]. [NewArrowRecordWriter] hands the in-memory record batches
+// ([github.com/apache/arrow-go/v18/arrow.Record]) to a callback instead of serializing them.
+//
+// # XLSX
+//
+// [NewXLSXWriter] streams an Excel workbook with typed cells: numbers Excel holds exactly
+// (INT64 up to 15 digits, floats, short NUMERIC values) become number cells, BOOL boolean
+// cells, and DATE and TIMESTAMP date cells; other values are formatted text, so long IDs
+// and leading zeros survive. The bold header row is frozen and filtered
+// ([WithXLSXFreezeHeader], [WithXLSXAutoFilter]), and rows beyond the Excel limit or
+// [WithXLSXSheetRows] continue on new worksheets. [*XLSXWriter.Flush] finishes the
+// workbook; later writes return [ErrWriterClosed].
+//
+// # PostgreSQL COPY
+//
+// [NewPGCopyWriter] writes input for COPY ... FROM STDIN in the text format (\N for NULL,
+// backslash escapes) or, with [WithPGCopyFormat], the CSV format ([WithPGCopyNullString],
+// [WithPGCopyForceQuote]). Values use PostgreSQL input syntax rather than a formatter:
+// t/f booleans, \x bytea, timestamptz text in UTC, and array literals such as {1,2,"a b"}.
+// STRUCT columns return [ErrUnsupportedPGCopyType]. [*PGCopyWriter.CopyStatement] returns
+// the COPY command whose options match the writer.
+//
+// # Mutations
+//
+// [NewMutationWriter] turns rows into [*cloud.google.com/go/spanner.Mutation] values for a
+// table ([WithMutationKind]: insert, insert-or-update, replace, update, or delete by
+// [WithMutationKeyColumns]) and passes them in batches to a sink, for replaying an export
+// with Client.Apply or BatchWrite. [NewMutationJSONWriter] writes each batch as a REST
+// Commit request body instead. Batches stay within [WithMutationBatchCount] mutations and
+// [WithMutationBatchBytes] bytes (the Spanner commit limits by default); call
+// [*MutationWriter.Flush] to send the last batch.
+//
 // # SQL INSERT
 //
 // [NewSQLInsertWriter] accepts [WithSQLInsertKind], [WithSQLDialect], and [WithSQLBatchSize].
+// [WithSQLConflictTarget] writes [SQLInsertOrIgnore] and [SQLInsertOrUpdate] as ON CONFLICT
+// DO NOTHING or DO UPDATE clauses rather than INSERT OR prefixes; PostgreSQL upserts need it.
 // It rejects an empty table name (after strings.TrimSpace) at construction with [ErrEmptyTableName]
 // and an out-of-range [SQLInsertKind] with [ErrInvalidSQLInsertKind]. Qualified names with empty
 // segments are rejected on the first write with [ErrEmptyTableName].
@@ -81,4 +178,54 @@
 // statement completes. After any write error from [SQLInsertWriter], discard the writer; later
 // calls return the latched error (see "Write errors"). [*SQLInsertWriter.Flush]
 // closes a partial batch when batching.
+//
+// [WithSQLBatchBytes] and [WithSQLMaxMutations] (with [WithSQLIndexColumnCounts] for
+// secondary indexes) close batches before they exceed statement-size or per-transaction
+// mutation limits. For script output, [WithSQLTransactionSize] frames statements with
+// BEGIN and COMMIT, [WithSQLStatementSeparator] replaces the ";\n" after each statement,
+// and [WithSQLHeaderComment] records the source query or export time as "--" comments.
+//
+// [WithSQLColumnOverride] changes individual columns when replaying exports: [SQLOmitColumn]
+// for generated columns, [SQLPendingCommitTimestamp] for commit-timestamp columns,
+// [SQLDefaultColumn], [SQLColumnExpression], or [SQLColumnTransform] to rewrite the literal.
+//
+// # Parameterized statements
+//
+// [NewSQLStatementWriter] hands INSERT [cloud.google.com/go/spanner.Statement] values to a
+// sink, for example BatchUpdate, with every value bound as a parameter (@p1 or $1 per
+// [WithSQLDialect]) instead of an inlined literal. [WithSQLBatchSize] puts several rows in
+// one statement as multi-row VALUES or, with [WithSQLStatementBatchForm]([SQLBatchUnnest]),
+// as SELECT * FROM UNNEST(@rows) over one ARRAY<STRUCT> parameter; [WithSQLStatementsPerCall]
+// groups statements per sink call. Multi-row VALUES batches that would exceed Spanner's
+// [MaxSQLStatementParameters] return [ErrInvalidSQLBatchLimit]; UNNEST has no such limit.
+//
+// # SQL UPDATE and DELETE
+//
+// [NewSQLUpdateWriter] and [NewSQLDeleteWriter] take the table's primary key columns and
+// match each row by them: UPDATE sets every other column, DELETE ignores them. Key columns
+// missing from the registered schema return [ErrMissingKeyColumn]; a schema with no non-key
+// column returns [ErrNoUpdateColumns] for UPDATE. A NULL key value matches with IS NULL.
+// [WithSQLBatchSize] groups DELETE rows into one statement (IN UNNEST for a GoogleSQL
+// single-column key, IN (...) for PostgreSQL, OR-ed conditions for composite keys).
+// Quoting, literals, and write errors follow [SQLInsertWriter].
+//
+// # Text tables
+//
+// [NewTableWriter] renders the spanner-cli box layout with
+// [spanvalue.SpannerCLICompatibleFormatConfig] cells. [WithTableStyle] selects ASCII,
+// Unicode box-drawing, or borderless minimal output; [WithTypedHeader] adds column types
+// to the header ("id INT64"). Widths use East Asian display width and multi-line cells span
+// several lines. Rows are buffered until [*TableWriter.Flush] so widths fit every cell;
+// [WithTableSampleRows] instead fixes widths from the first rows and streams the rest,
+// wrapping wider cells.
+//
+// [NewVerticalWriter] prints one "N. row" block per row with right-aligned "name: value"
+// lines, like \G output in mysql and spanner-cli, and suits wide rows and long JSON
+// values. [WithTypedHeader] also adds types to its field names.
+//
+// [NewMarkdownWriter] writes a GitHub Flavored Markdown table, escaping pipes and
+// backslashes and turning newlines into <br>. [NewHTMLWriter] writes an HTML <table> with
+// escaped cells, column types in data-type attributes on <th>, and a CSS class on NULL
+// cells ([WithHTMLNullClass]); [WithHTMLStreaming] opens the table on Prepare* and closes
+// it on Flush instead of buffering.
 package writer
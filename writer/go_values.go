@@ -0,0 +1,69 @@
+package writer
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+// GoValueRowSeq lazily encodes Go structs (or pointers to structs) tagged
+// with `spanner:"name"` as rows using [gcvctor.RowFromGo], for [RunRowSeq]
+// and [WriteRowSeq]. An element that does not encode yields its error,
+// prefixed with its index.
+func GoValueRowSeq[T any](values ...T) iter.Seq2[*spanner.Row, error] {
+	return func(yield func(*spanner.Row, error) bool) {
+		for i, v := range values {
+			row, err := gcvctor.RowFromGo(v)
+			if err != nil {
+				err = fmt.Errorf("value %d: %w", i, err)
+			}
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
+
+// WriteGoValues writes each Go struct in values as one row to w, as
+// [WriteRowSeq] with [GoValueRowSeq]. The row type registered with
+// [RowIteratorWriter.PrepareRowType] comes from the first value, or from
+// the zero value of T when values is empty, so an empty slice still
+// produces a header-only delimited result. When neither is available (an
+// empty values with an interface T), the row type is nil.
+func WriteGoValues[T any](w RowIteratorWriter, values ...T) (*RowIteratorResult, error) {
+	if w == nil {
+		return nil, ErrNilWriter
+	}
+	var md *sppb.ResultSetMetadata
+	rows := GoValueRowSeq(values...)
+	if len(values) > 0 {
+		if rowType, _, err := gcvctor.RowValuesFromGo(values[0]); err == nil {
+			md = &sppb.ResultSetMetadata{RowType: rowType}
+		}
+	} else if rowType := zeroGoRowType[T](); rowType != nil {
+		md = &sppb.ResultSetMetadata{RowType: rowType}
+	}
+	return RunRowSeq(md, rows, RowIteratorHooksFromWriter(w))
+}
+
+// zeroGoRowType returns the row type of a zero T, allocating the struct
+// when T is a pointer type. It returns nil when T does not encode as a row.
+func zeroGoRowType[T any]() *sppb.StructType {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	rowType, _, err := gcvctor.RowValuesFromGo(reflect.New(t).Interface())
+	if err != nil {
+		return nil
+	}
+	return rowType
+}
@@ -0,0 +1,65 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+type goValueOrder struct {
+	ID    int64   `spanner:"id"`
+	Note  *string `spanner:"note"`
+	Items []goValueItem
+}
+
+type goValueItem struct {
+	SKU string `spanner:"sku"`
+}
+
+func TestWriteGoValues_csv(t *testing.T) {
+	t.Parallel()
+
+	note := "rush"
+	var out bytes.Buffer
+	w := mustNewCSVWriter(t, &out)
+	got, err := WriteGoValues(w,
+		goValueOrder{ID: 1, Note: &note, Items: []goValueItem{{SKU: "a"}}},
+		goValueOrder{ID: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RowsRead != 2 {
+		t.Errorf("RowsRead = %d, want 2", got.RowsRead)
+	}
+	want := "id,note,Items\n1,rush,[(a AS sku)]\n2,<null>,<null>\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteGoValues_emptyWritesHeader(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if _, err := WriteGoValues[*goValueOrder](mustNewCSVWriter(t, &out)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "id,note,Items\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWriteGoValues_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := WriteGoValues[goValueOrder](nil); !errors.Is(err, ErrNilWriter) {
+		t.Errorf("nil writer error = %v, want ErrNilWriter", err)
+	}
+	_, err := WriteGoValues(mustNewCSVWriter(t, &bytes.Buffer{}), &goValueOrder{ID: 1}, nil)
+	if !errors.Is(err, gcvctor.ErrNotGoStruct) {
+		t.Errorf("nil element error = %v, want ErrNotGoStruct", err)
+	}
+}
@@ -0,0 +1,350 @@
+package writer
+
+import (
+	"html"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// HTMLOption configures an HTMLWriter created by [NewHTMLWriter].
+type HTMLOption interface {
+	applyHTMLOption(*HTMLWriter) error
+}
+
+type htmlOptionFunc func(*HTMLWriter) error
+
+func (f htmlOptionFunc) applyHTMLOption(w *HTMLWriter) error {
+	return f(w)
+}
+
+func applyHTMLOptions(w *HTMLWriter, options ...HTMLOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyHTMLOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultHTMLNullClass is the class of NULL cells unless [WithHTMLNullClass] is set.
+const defaultHTMLNullClass = "null"
+
+// WithHTMLNullClass sets the class attribute of <td> cells holding SQL NULL
+// (default "null"). An empty class omits the attribute.
+func WithHTMLNullClass(class string) HTMLOption {
+	return htmlOptionFunc(func(w *HTMLWriter) error {
+		w.nullClass = class
+		return nil
+	})
+}
+
+// WithHTMLStreaming sets whether [HTMLWriter] streams (default false). When
+// streaming, the table and its header are written by PrepareRowType,
+// PrepareColumnNames, or the first row, each row is written as it arrives,
+// and [HTMLWriter.Flush] closes the table. Otherwise the whole table is
+// buffered and written by Flush. When only names are registered, header
+// types come from the first row unless the header was already written.
+func WithHTMLStreaming(streaming bool) HTMLOption {
+	return htmlOptionFunc(func(w *HTMLWriter) error {
+		w.streaming = streaming
+		return nil
+	})
+}
+
+// HTMLWriter writes rows as an HTML <table> fragment:
+//
+//	<table>
+//	<thead>
+//	<tr><th data-type="INT64">id</th></tr>
+//	</thead>
+//	<tbody>
+//	<tr><td>1</td></tr>
+//	<tr><td class="null">NULL</td></tr>
+//	</tbody>
+//	</table>
+//
+// Cell text and names are HTML-escaped and newlines become <br>. Each <th>
+// carries its column type in a data-type attribute when the type is known,
+// and top-level NULL cells get the class set by [WithHTMLNullClass]. Flush
+// ends the table, so rows written after Flush start a new table with the same
+// schema. A registered zero-column schema writes nothing. Cells are
+// formatted with [spanvalue.SpannerCLICompatibleFormatConfig] unless
+// [WithFormatter] is set.
+//
+// After the first output write failure, every later Write*/Flush call
+// returns that error; discard the writer (see package doc "Write errors").
+type HTMLWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for header cells.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	nullClass         string
+	streaming         bool
+
+	schema   columnSchema
+	rowTypes []*sppb.Type
+	// body holds buffered rows when not streaming.
+	body   strings.Builder
+	opened bool
+	out    io.Writer
+}
+
+// NewHTMLWriter returns an HTML table writer configured by options.
+func NewHTMLWriter(out io.Writer, options ...HTMLOption) (*HTMLWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &HTMLWriter{
+		formatter: spanvalue.SpannerCLICompatibleFormatConfig(),
+		nullClass: defaultHTMLNullClass,
+		out:       out,
+	}
+	if err := applyHTMLOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one table row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *HTMLWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema. When streaming, it also opens the table.
+func (w *HTMLWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return w.openIfStreaming()
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+// When streaming, it also opens the table.
+func (w *HTMLWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return w.openIfStreaming()
+}
+
+// WriteValues writes one table row; see [DelimitedWriter.WriteValues].
+func (w *HTMLWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one table row; see [DelimitedWriter.WriteStructValues].
+func (w *HTMLWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one table row; see [DelimitedWriter.WriteGCVs].
+func (w *HTMLWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	cells, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	if w.rowTypes == nil {
+		w.rowTypes = make([]*sppb.Type, len(values))
+		for i, v := range values {
+			w.rowTypes[i] = v.Type
+		}
+	}
+	var row strings.Builder
+	row.WriteString("<tr>")
+	for i, cell := range cells {
+		if w.nullClass != "" && spanvalue.IsNull(values[i]) {
+			row.WriteString(`<td class="` + html.EscapeString(w.nullClass) + `">`)
+		} else {
+			row.WriteString("<td>")
+		}
+		row.WriteString(htmlCellText(cell))
+		row.WriteString("</td>")
+	}
+	row.WriteString("</tr>\n")
+	if !w.streaming {
+		w.body.WriteString(row.String())
+		return nil
+	}
+	var b strings.Builder
+	if !w.opened {
+		if err := w.appendOpening(&b); err != nil {
+			return err
+		}
+	}
+	b.WriteString(row.String())
+	if err := w.write(b.String()); err != nil {
+		return err
+	}
+	w.opened = true
+	return nil
+}
+
+// Flush writes the table, or its closing tags when streaming, ending the
+// table. A registered schema with no rows yields a table with an empty
+// <tbody>; a registered zero-column schema writes nothing. With no
+// registered schema, Flush returns [ErrMissingColumnNames]. After a write
+// failure, Flush returns the latched error (see package doc "Write errors").
+func (w *HTMLWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	if !w.opened {
+		if err := w.appendOpening(&b); err != nil {
+			return err
+		}
+	}
+	b.WriteString(w.body.String())
+	b.WriteString("</tbody>\n</table>\n")
+	w.body.Reset()
+	w.opened = false
+	return w.write(b.String())
+}
+
+// FormatConfig returns the effective formatter used for table cells.
+// When no formatter is configured, this returns [spanvalue.SpannerCLICompatibleFormatConfig].
+// Configure it only via [NewHTMLWriter] or [WithFormatter].
+func (w *HTMLWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return w.formatter
+}
+
+// openIfStreaming writes the table opening once a non-empty schema is
+// registered in streaming mode.
+func (w *HTMLWriter) openIfStreaming() error {
+	if !w.streaming || w.opened || len(w.schema.names) == 0 {
+		return nil
+	}
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	var b strings.Builder
+	if err := w.appendOpening(&b); err != nil {
+		return err
+	}
+	if err := w.write(b.String()); err != nil {
+		return err
+	}
+	w.opened = true
+	return nil
+}
+
+// appendOpening appends <table>, the header, and <tbody>.
+func (w *HTMLWriter) appendOpening(b *strings.Builder) error {
+	names := w.schema.names
+	if w.unnamedFieldNamer != nil {
+		resolved, err := internal.ResolveColumnNames(names, w.unnamedFieldNamer)
+		if err != nil {
+			return err
+		}
+		names = resolved
+	}
+	types := w.schema.types
+	if len(types) == 0 {
+		types = w.rowTypes
+	}
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for i, name := range names {
+		if i < len(types) && types[i] != nil {
+			b.WriteString(`<th data-type="` + html.EscapeString(spantype.FormatTypeVerbose(types[i])) + `">`)
+		} else {
+			b.WriteString("<th>")
+		}
+		b.WriteString(htmlCellText(name))
+		b.WriteString("</th>")
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+	return nil
+}
+
+// htmlCellText escapes s for element content and turns line breaks into <br>.
+func htmlCellText(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func (w *HTMLWriter) write(s string) error {
+	_, err := io.WriteString(w.out, s)
+	return w.latchWriteErr(err)
+}
+
+func (w *HTMLWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.rowTypes = nil
+}
+
+func (w *HTMLWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.rowTypes = nil
+}
+
+func (w *HTMLWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
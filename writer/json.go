@@ -0,0 +1,382 @@
+package writer
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// JSONOption configures a JSONWriter created by [NewJSONWriter].
+type JSONOption interface {
+	applyJSONOption(*JSONWriter) error
+}
+
+type jsonOptionFunc func(*JSONWriter) error
+
+func (f jsonOptionFunc) applyJSONOption(w *JSONWriter) error {
+	return f(w)
+}
+
+func applyJSONOptions(w *JSONWriter, options ...JSONOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyJSONOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithJSONEnvelope makes [JSONWriter] write an envelope object instead of a
+// top-level array:
+//
+//	{"metadata":{"columns":[{"name":"id","type":"INT64"}]},
+//	"rows":[
+//	{"id":1}
+//	],
+//	"stats":{"rowCount":1}}
+//
+// Column types use [spantype.FormatTypeVerbose] and are omitted when unknown.
+// "stats" always holds "rowCount", the number of rows written to the
+// document. When the document is finished by [JSONWriter.FinishResult] (as
+// [WriteRowIterator] does), it also holds "rowCountExact" (the DML row
+// count, when non-zero), "queryPlan" (protojson), and "queryStats" from
+// [RowIteratorStats].
+func WithJSONEnvelope() JSONOption {
+	return jsonOptionFunc(func(w *JSONWriter) error {
+		w.envelope = true
+		return nil
+	})
+}
+
+// jsonColumn is one entry of the envelope's metadata.columns.
+type jsonColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// JSONWriter streams rows as one JSON document: by default a top-level
+// array of row objects, or the envelope selected by [WithJSONEnvelope]. Row
+// objects are the same as [JSONLWriter] lines. The document is opened by
+// the first row and each row is written as it arrives; [JSONWriter.Flush]
+// (or [JSONWriter.FinishResult]) closes it, writing "[]" or an envelope with
+// empty rows when no rows were written. Flush ends the document, so rows
+// written after Flush start a new document. Values are formatted with
+// [spanvalue.JSONFormatConfig] unless [WithFormatter] is set.
+//
+// After the first output write failure, every later Write*/Flush call
+// returns that error; discard the writer (see package doc "Write errors").
+type JSONWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for object keys.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	envelope          bool
+
+	schema        columnSchema
+	marshaledKeys [][]byte
+	opened        bool
+	rows          int64
+	out           io.Writer
+}
+
+// NewJSONWriter returns a JSON document writer configured by options.
+func NewJSONWriter(out io.Writer, options ...JSONOption) (*JSONWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &JSONWriter{
+		formatter: spanvalue.JSONFormatConfig(),
+		out:       out,
+	}
+	if err := applyJSONOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one row object. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *JSONWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *JSONWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *JSONWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one row object; see [DelimitedWriter.WriteValues].
+func (w *JSONWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one row object; see [DelimitedWriter.WriteStructValues].
+func (w *JSONWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one row object; see [DelimitedWriter.WriteGCVs]. A
+// registered zero-column schema accepts empty rows and writes them as {}.
+func (w *JSONWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 && len(values) != 0 {
+		return ErrMissingColumnNames
+	}
+	formattedValues, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	keys, err := w.keys()
+	if err != nil {
+		return err
+	}
+	obj, err := internal.AssembleJSONObjectWithMarshaledKeys(keys, formattedValues)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if !w.opened {
+		if err := w.appendOpening(&b, values); err != nil {
+			return err
+		}
+	}
+	if w.rows > 0 {
+		b.WriteByte(',')
+	}
+	b.WriteByte('\n')
+	b.WriteString(obj)
+	if err := w.write(b.String()); err != nil {
+		return err
+	}
+	w.opened = true
+	w.rows++
+	return nil
+}
+
+// Flush closes the document, as [JSONWriter.FinishResult] without run stats.
+func (w *JSONWriter) Flush() error {
+	return w.FinishResult(nil)
+}
+
+// FinishResult closes the document like [JSONWriter.Flush]; with
+// [WithJSONEnvelope], the stats of result (when non-nil) are added to the
+// envelope's "stats". [RowIteratorHooksFromWriter] calls it instead of Flush.
+// With no registered schema, it returns [ErrMissingColumnNames]. After a
+// write failure, it returns the latched error (see package doc "Write errors").
+func (w *JSONWriter) FinishResult(result *RowIteratorResult) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	var b strings.Builder
+	if !w.opened {
+		if err := w.appendOpening(&b, nil); err != nil {
+			return err
+		}
+	}
+	if w.rows > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteByte(']')
+	if w.envelope {
+		stats, err := w.marshalStats(result)
+		if err != nil {
+			return err
+		}
+		b.WriteString(`,"stats":`)
+		b.WriteString(stats)
+		b.WriteByte('}')
+	}
+	b.WriteByte('\n')
+	w.opened = false
+	w.rows = 0
+	return w.write(b.String())
+}
+
+// FormatConfig returns the effective formatter used for JSON value encoding.
+// When no formatter is configured, this returns [spanvalue.JSONFormatConfig].
+// Configure it only via [NewJSONWriter] or [WithFormatter].
+func (w *JSONWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.JSONFormatConfig()
+	}
+	return w.formatter
+}
+
+// appendOpening appends "[" or the envelope up to the rows array. Column
+// types come from the registered row type, else from values.
+func (w *JSONWriter) appendOpening(b *strings.Builder, values []spanner.GenericColumnValue) error {
+	if !w.envelope {
+		b.WriteByte('[')
+		return nil
+	}
+	names, err := w.resolvedNames()
+	if err != nil {
+		return err
+	}
+	types := w.schema.types
+	if len(types) == 0 {
+		types = make([]*sppb.Type, len(values))
+		for i, v := range values {
+			types[i] = v.Type
+		}
+	}
+	columns := make([]jsonColumn, len(names))
+	for i, name := range names {
+		columns[i].Name = name
+		if i < len(types) && types[i] != nil {
+			columns[i].Type = spantype.FormatTypeVerbose(types[i])
+		}
+	}
+	encoded, err := json.Marshal(columns)
+	if err != nil {
+		return err
+	}
+	b.WriteString(`{"metadata":{"columns":`)
+	b.Write(encoded)
+	b.WriteString(`},"rows":[`)
+	return nil
+}
+
+// marshalStats encodes the envelope's "stats" object.
+func (w *JSONWriter) marshalStats(result *RowIteratorResult) (string, error) {
+	var b strings.Builder
+	b.WriteString(`{"rowCount":`)
+	b.WriteString(strconv.FormatInt(w.rows, 10))
+	if result != nil {
+		stats := result.Stats
+		if stats.RowCount != 0 {
+			b.WriteString(`,"rowCountExact":`)
+			b.WriteString(strconv.FormatInt(stats.RowCount, 10))
+		}
+		if stats.QueryPlan != nil {
+			plan, err := protojson.Marshal(stats.QueryPlan)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(`,"queryPlan":`)
+			b.Write(plan)
+		}
+		if stats.QueryStats != nil {
+			queryStats, err := json.Marshal(stats.QueryStats)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(`,"queryStats":`)
+			b.Write(queryStats)
+		}
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+func (w *JSONWriter) resolvedNames() ([]string, error) {
+	if w.unnamedFieldNamer == nil {
+		return w.schema.names, nil
+	}
+	return internal.ResolveColumnNames(w.schema.names, w.unnamedFieldNamer)
+}
+
+func (w *JSONWriter) keys() ([][]byte, error) {
+	if w.marshaledKeys != nil {
+		return w.marshaledKeys, nil
+	}
+	names, err := w.resolvedNames()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := internal.MarshalJSONObjectKeys(names)
+	if err != nil {
+		return nil, err
+	}
+	w.marshaledKeys = keys
+	return keys, nil
+}
+
+func (w *JSONWriter) write(s string) error {
+	_, err := io.WriteString(w.out, s)
+	return w.latchWriteErr(err)
+}
+
+func (w *JSONWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.marshaledKeys = nil
+}
+
+func (w *JSONWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.marshaledKeys = nil
+}
+
+func (w *JSONWriter) initOrValidateColumnNames(columnNames []string) error {
+	initialized := len(w.schema.names) == 0
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	if initialized && len(w.schema.names) > 0 {
+		w.marshaledKeys = nil
+	}
+	return nil
+}
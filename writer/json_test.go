@@ -0,0 +1,126 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var (
+	_ RowIteratorWriter = (*JSONWriter)(nil)
+	_ ResultFinisher    = (*JSONWriter)(nil)
+)
+
+func TestJSONWriter_array(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewJSONWriter(t, &out)
+	md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+	names := []string{"id", "name"}
+	rows := RowSeq(
+		mustNewSpannerRow(t, names, []any{int64(1), "a"}),
+		mustNewSpannerRow(t, names, []any{int64(2), spanner.NullString{}}),
+	)
+	if _, err := WriteRowSeq(md, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n{\"id\":1,\"name\":\"a\"},\n{\"id\":2,\"name\":null}\n]\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if !json.Valid(out.Bytes()) {
+		t.Errorf("output is not valid JSON: %s", out.String())
+	}
+}
+
+func TestJSONWriter_empty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []JSONOption
+		want    string
+	}{
+		{name: "array", options: []JSONOption{WithRowType(tableTestRowType())}, want: "[]\n"},
+		{name: "array zero columns", options: []JSONOption{WithRowType(nil)}, want: "[]\n"},
+		{
+			name:    "envelope",
+			options: []JSONOption{WithJSONEnvelope(), WithRowType(tableTestRowType())},
+			want:    `{"metadata":{"columns":[{"name":"id","type":"INT64"},{"name":"name","type":"STRING"}]},"rows":[],"stats":{"rowCount":0}}` + "\n",
+		},
+		{
+			name:    "envelope zero columns",
+			options: []JSONOption{WithJSONEnvelope(), WithRowType(nil)},
+			want:    `{"metadata":{"columns":[]},"rows":[],"stats":{"rowCount":0}}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			if err := mustNewJSONWriter(t, &out, tt.options...).Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := mustNewJSONWriter(t, &out).Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+}
+
+func TestJSONWriter_envelopeStats(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewJSONWriter(t, &out, WithJSONEnvelope())
+	// Names only: the column type comes from the first row.
+	if err := w.WriteValues([]string{"n"}, []spanner.GenericColumnValue{gcvctor.Int64Value(7)}); err != nil {
+		t.Fatal(err)
+	}
+	result := &RowIteratorResult{Stats: RowIteratorStats{
+		RowCount:   3,
+		QueryStats: map[string]any{"elapsed_time": "1 msecs"},
+		QueryPlan:  &sppb.QueryPlan{PlanNodes: []*sppb.PlanNode{{DisplayName: "Scan"}}},
+	}}
+	if err := w.FinishResult(result); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"metadata":{"columns":[{"name":"n","type":"INT64"}]},"rows":[` + "\n" +
+		`{"n":7}` + "\n" +
+		`],"stats":{"rowCount":1,"rowCountExact":3,"queryPlan":{"planNodes":[{"displayName":"Scan"}]},"queryStats":{"elapsed_time":"1 msecs"}}}` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestJSONWriter_stickyError(t *testing.T) {
+	t.Parallel()
+
+	fw := &failNthWrite{n: 2}
+	w := mustNewJSONWriter(t, fw, WithColumnNames([]string{"n"}))
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1)}
+	if err := w.WriteGCVs(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+		t.Fatalf("second WriteGCVs error = %v, want errInjected", err)
+	}
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Errorf("Flush after failure error = %v, want errInjected", err)
+	}
+	if got, want := fw.buf.String(), "[\n{\"n\":1}"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
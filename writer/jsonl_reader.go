@@ -0,0 +1,400 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// JSONLMissingKeyPolicy selects what [JSONLReader] does when an object has no key
+// for a column.
+type JSONLMissingKeyPolicy int
+
+const (
+	// JSONLMissingKeyError rejects the record with [ErrMissingJSONLKey] (the default).
+	JSONLMissingKeyError JSONLMissingKeyPolicy = iota
+	// JSONLMissingKeyNull reads the column as NULL.
+	JSONLMissingKeyNull
+)
+
+// String returns the Go constant name for p, or "JSONLMissingKeyPolicy(n)" for unknown values.
+func (p JSONLMissingKeyPolicy) String() string {
+	switch p {
+	case JSONLMissingKeyError:
+		return "JSONLMissingKeyError"
+	case JSONLMissingKeyNull:
+		return "JSONLMissingKeyNull"
+	default:
+		return fmt.Sprintf("JSONLMissingKeyPolicy(%d)", int(p))
+	}
+}
+
+// JSONLExtraKeyPolicy selects what [JSONLReader] does with an object key that
+// matches no column.
+type JSONLExtraKeyPolicy int
+
+const (
+	// JSONLExtraKeyError rejects the record with [ErrUnknownJSONLKey] (the default).
+	JSONLExtraKeyError JSONLExtraKeyPolicy = iota
+	// JSONLExtraKeyIgnore skips the key and its value.
+	JSONLExtraKeyIgnore
+)
+
+// String returns the Go constant name for p, or "JSONLExtraKeyPolicy(n)" for unknown values.
+func (p JSONLExtraKeyPolicy) String() string {
+	switch p {
+	case JSONLExtraKeyError:
+		return "JSONLExtraKeyError"
+	case JSONLExtraKeyIgnore:
+		return "JSONLExtraKeyIgnore"
+	default:
+		return fmt.Sprintf("JSONLExtraKeyPolicy(%d)", int(p))
+	}
+}
+
+// JSONLReaderOption configures a JSONLReader created by [NewJSONLReader].
+type JSONLReaderOption interface {
+	applyJSONLReaderOption(*JSONLReader) error
+}
+
+type jsonlReaderOptionFunc func(*JSONLReader) error
+
+func (f jsonlReaderOptionFunc) applyJSONLReaderOption(r *JSONLReader) error {
+	return f(r)
+}
+
+func applyJSONLReaderOptions(r *JSONLReader, options ...JSONLReaderOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyJSONLReaderOption(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithJSONLMissingKeys sets the [JSONLReader] policy for columns without a key
+// (default [JSONLMissingKeyError]). Unknown policies return [ErrInvalidJSONLKeyPolicy].
+func WithJSONLMissingKeys(policy JSONLMissingKeyPolicy) JSONLReaderOption {
+	return jsonlReaderOptionFunc(func(r *JSONLReader) error {
+		if policy < JSONLMissingKeyError || policy > JSONLMissingKeyNull {
+			return fmt.Errorf("%w: %v", ErrInvalidJSONLKeyPolicy, policy)
+		}
+		r.missingKeys = policy
+		return nil
+	})
+}
+
+// WithJSONLExtraKeys sets the [JSONLReader] policy for keys that match no column
+// (default [JSONLExtraKeyError]). Unknown policies return [ErrInvalidJSONLKeyPolicy].
+func WithJSONLExtraKeys(policy JSONLExtraKeyPolicy) JSONLReaderOption {
+	return jsonlReaderOptionFunc(func(r *JSONLReader) error {
+		if policy < JSONLExtraKeyError || policy > JSONLExtraKeyIgnore {
+			return fmt.Errorf("%w: %v", ErrInvalidJSONLKeyPolicy, policy)
+		}
+		r.extraKeys = policy
+		return nil
+	})
+}
+
+// JSONLReadError reports a line that [JSONLReader] could not read, with its
+// position, while preserving the wrapped cause for [errors.Is] and [errors.As].
+type JSONLReadError struct {
+	// Line is the 1-based input line.
+	Line int
+	// Key is the object key of the failing value, or empty when the error
+	// concerns the whole line.
+	Key string
+	Err error
+}
+
+func (e *JSONLReadError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, key %q: %v", e.Line, e.Key, e.Err)
+}
+
+func (e *JSONLReadError) Unwrap() error {
+	return e.Err
+}
+
+// JSONLReader parses JSON objects, one per line, such as [JSONLWriter] output
+// with the default [spanvalue.JSONFormatConfig], back to Spanner values of a row
+// type registered with [WithReaderRowType]. Blank lines are skipped.
+//
+// Values use the [spanvalue.JSONFormatConfig] encoding: INT64 and ENUM as numbers
+// or quoted strings, floats as numbers or "NaN" and "Infinity" strings, JSON
+// columns as raw JSON, BYTES and PROTO as base64 strings, other scalars as
+// strings, ARRAY as arrays, and STRUCT as objects whose members are taken in
+// field order. A JSON value null, in a JSON column or an ARRAY<JSON> element, is
+// written as null like SQL NULL, so it reads back as SQL NULL.
+type JSONLReader struct {
+	in                *bufio.Reader
+	rowType           *sppb.StructType
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	missingKeys       JSONLMissingKeyPolicy
+	extraKeys         JSONLExtraKeyPolicy
+
+	names []string
+	keys  []string
+	line  int
+}
+
+// NewJSONLReader returns a reader of JSON Lines configured by options. Object keys
+// are matched against the column names of [WithReaderRowType], with unnamed
+// columns named by [WithUnnamedFieldNamer] (default
+// [spanvalue.IndexedUnnamedFieldNamer], as for [JSONLWriter]); repeated column
+// names match repeated keys in order. Without a row type it returns
+// [ErrMissingColumnNames].
+func NewJSONLReader(in io.Reader, options ...JSONLReaderOption) (*JSONLReader, error) {
+	if in == nil {
+		return nil, ErrNilInputReader
+	}
+	r := &JSONLReader{
+		in:                bufio.NewReader(in),
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+	}
+	if err := applyJSONLReaderOptions(r, options...); err != nil {
+		return nil, err
+	}
+	if r.rowType == nil {
+		return nil, ErrMissingColumnNames
+	}
+	r.names = columnNamesFromRowType(r.rowType)
+	keys, err := internal.ResolveColumnNames(r.names, r.unnamedFieldNamer)
+	if err != nil {
+		return nil, err
+	}
+	r.keys = keys
+	return r, nil
+}
+
+// RowType returns the column names and types of the records.
+func (r *JSONLReader) RowType() *sppb.StructType {
+	return r.rowType
+}
+
+// Metadata returns result set metadata holding [JSONLReader.RowType], for
+// [WriteRowSeq].
+func (r *JSONLReader) Metadata() *sppb.ResultSetMetadata {
+	return &sppb.ResultSetMetadata{RowType: r.rowType}
+}
+
+// ReadGCVs parses the next object into one value per column. It returns [io.EOF]
+// after the last line. Malformed lines, key policy violations, and values that do
+// not parse return a [*JSONLReadError]; the reader may continue with the next line
+// after any of them.
+func (r *JSONLReader) ReadGCVs() ([]spanner.GenericColumnValue, error) {
+	for {
+		line, err := r.in.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			r.line++
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		r.line++
+		return r.parseLine(line)
+	}
+}
+
+func (r *JSONLReader) parseLine(line []byte) ([]spanner.GenericColumnValue, error) {
+	members, err := decodeJSONObject(line)
+	if err != nil {
+		return nil, &JSONLReadError{Line: r.line, Err: fmt.Errorf("%w: %w", ErrInvalidJSONLRecord, err)}
+	}
+	fields := r.rowType.GetFields()
+	values := make([]spanner.GenericColumnValue, len(fields))
+	seen := make([]bool, len(fields))
+	for _, m := range members {
+		i := r.columnForKey(m.key, seen)
+		if i < 0 {
+			if r.extraKeys == JSONLExtraKeyIgnore {
+				continue
+			}
+			return nil, &JSONLReadError{Line: r.line, Key: m.key, Err: ErrUnknownJSONLKey}
+		}
+		seen[i] = true
+		typ := fields[i].GetType()
+		v, err := wireFromJSON(typ, m.value)
+		if err != nil {
+			return nil, &JSONLReadError{Line: r.line, Key: m.key, Err: err}
+		}
+		values[i] = spanner.GenericColumnValue{Type: typ, Value: v}
+	}
+	for i, ok := range seen {
+		if ok {
+			continue
+		}
+		if r.missingKeys == JSONLMissingKeyError {
+			return nil, &JSONLReadError{Line: r.line, Err: fmt.Errorf("%w: %q", ErrMissingJSONLKey, r.keys[i])}
+		}
+		values[i] = spanner.GenericColumnValue{Type: fields[i].GetType(), Value: structpb.NewNullValue()}
+	}
+	return values, nil
+}
+
+// columnForKey returns the first column named key that has no value yet, or -1.
+func (r *JSONLReader) columnForKey(key string, seen []bool) int {
+	for i, k := range r.keys {
+		if k == key && !seen[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// Rows returns the remaining lines as rows, for [WriteRowSeq] and [RunRowSeq].
+// Iteration stops after the first error.
+func (r *JSONLReader) Rows() iter.Seq2[*spanner.Row, error] {
+	return func(yield func(*spanner.Row, error) bool) {
+		for {
+			values, err := r.ReadGCVs()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			row, err := spanner.NewRow(r.names, gcvsAsAny(values))
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// decodeJSONObject splits one JSON object into its members in input order,
+// keeping repeated keys.
+func decodeJSONObject(data []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("want JSON object, got %s", bytes.TrimSpace(data))
+	}
+	var members []jsonMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after object")
+	}
+	return members, nil
+}
+
+// wireFromJSON converts one value in the [spanvalue.JSONFormatConfig] encoding to
+// the Spanner wire value of typ.
+func wireFromJSON(typ *sppb.Type, raw json.RawMessage) (*structpb.Value, error) {
+	if string(raw) == "null" {
+		return structpb.NewNullValue(), nil
+	}
+	invalid := func(err error) error {
+		return fmt.Errorf("%w: %v %s: %w", ErrInvalidCell, typ.GetCode(), raw, err)
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewBoolValue(b), nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM, sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		// Numbers, or strings for quoted INT64 and for NaN and the infinities.
+		s := string(raw)
+		if raw[0] == '"' {
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, invalid(err)
+			}
+		}
+		return scalarFromText(typ, s)
+	case sppb.TypeCode_JSON:
+		return structpb.NewStringValue(string(raw)), nil
+	case sppb.TypeCode_ARRAY:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, invalid(err)
+		}
+		values := make([]*structpb.Value, len(elems))
+		for i, elem := range elems {
+			v, err := wireFromJSON(typ.GetArrayElementType(), elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case sppb.TypeCode_STRUCT:
+		members, err := decodeJSONObject(raw)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		fields := typ.GetStructType().GetFields()
+		if len(members) != len(fields) {
+			return nil, invalid(fmt.Errorf("%d members, want %d", len(members), len(fields)))
+		}
+		values := make([]*structpb.Value, len(fields))
+		for i, f := range fields {
+			if f.GetName() != "" && members[i].key != f.GetName() {
+				return nil, invalid(fmt.Errorf("member %d is %q, want %q", i, members[i].key, f.GetName()))
+			}
+			v, err := wireFromJSON(f.GetType(), members[i].value)
+			if err != nil {
+				return nil, fmt.Errorf("field %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, invalid(err)
+	}
+	switch typ.GetCode() {
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, invalid(err)
+		}
+		return structpb.NewStringValue(s), nil
+	}
+	return scalarFromText(typ, s)
+}
@@ -0,0 +1,212 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+func readAllJSONLGCVs(t *testing.T, r *JSONLReader) [][]spanner.GenericColumnValue {
+	t.Helper()
+	var rows [][]spanner.GenericColumnValue
+	for {
+		values, err := r.ReadGCVs()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, values)
+	}
+}
+
+func TestJSONLReader_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	rowType, rows := delimitedReaderRoundTripRows()
+	rows[0][3] = gcvctor.Float64Value(math.NaN())
+	var out bytes.Buffer
+	w := mustNewJSONLWriter(t, &out, WithRowType(rowType))
+	for _, row := range rows {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewJSONLReader(&out, WithReaderRowType(rowType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rows, readAllJSONLGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONLReader_jsonNull(t *testing.T) {
+	t.Parallel()
+
+	// JSON null is indistinguishable from SQL NULL in JSONLWriter output.
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"j", "aj"},
+		[]*sppb.Type{typector.JSON(), typector.ElemTypeToArrayType(typector.JSON())},
+	).GetStructType()
+	var out bytes.Buffer
+	w := mustNewJSONLWriter(t, &out, WithRowType(rowType))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{
+		gcvctor.MustJSONStringValue("null"),
+		gcvctor.MustArrayValue(gcvctor.MustJSONStringValue("null"), gcvctor.MustJSONStringValue("1")),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewJSONLReader(&out, WithReaderRowType(rowType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]spanner.GenericColumnValue{{
+		gcvctor.NullFromCode(sppb.TypeCode_JSON),
+		gcvctor.MustArrayValue(gcvctor.NullFromCode(sppb.TypeCode_JSON), gcvctor.MustJSONStringValue("1")),
+	}}
+	if diff := cmp.Diff(want, readAllJSONLGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONLReader_transcode(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"id", "", ""},
+		[]*sppb.Type{typector.Int64(), typector.String(), typector.Bool()},
+	).GetStructType()
+	in := "{\"id\":1,\"_0\":\"a\",\"_1\":true}\n\n{\"_1\":false,\"id\":\"2\",\"_0\":null}\n"
+	r, err := NewJSONLReader(strings.NewReader(in), WithReaderRowType(rowType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := WriteRowSeq(r.Metadata(), r.Rows(), mustNewCSVWriter(t, &out)); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,_0,_1\n1,a,true\n2,<null>,false\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestJSONLReader_keyPolicies(t *testing.T) {
+	t.Parallel()
+
+	rowType := tableTestRowType()
+	in := "{\"id\":1,\"extra\":[1]}\n"
+	tests := []struct {
+		name    string
+		opts    []JSONLReaderOption
+		want    []spanner.GenericColumnValue
+		wantErr error
+	}{
+		{"strict", nil, nil, ErrUnknownJSONLKey},
+		{"extra only", []JSONLReaderOption{WithJSONLExtraKeys(JSONLExtraKeyIgnore)}, nil, ErrMissingJSONLKey},
+		{
+			"lenient",
+			[]JSONLReaderOption{WithJSONLExtraKeys(JSONLExtraKeyIgnore), WithJSONLMissingKeys(JSONLMissingKeyNull)},
+			[]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := NewJSONLReader(strings.NewReader(in), append([]JSONLReaderOption{WithReaderRowType(rowType)}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.ReadGCVs()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadGCVs() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("values mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJSONLReader_unnamedFieldNamer(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType([]string{""}, []*sppb.Type{typector.Int64()}).GetStructType()
+	namer := func(i int) string { return "col" + string(rune('A'+i)) }
+	var out bytes.Buffer
+	w := mustNewJSONLWriter(t, &out, WithRowType(rowType), WithUnnamedFieldNamer(namer))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(7)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "{\"colA\":7}\n" {
+		t.Fatalf("JSONL = %q", got)
+	}
+	r, err := NewJSONLReader(&out, WithReaderRowType(rowType), WithUnnamedFieldNamer(namer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]spanner.GenericColumnValue{{gcvctor.Int64Value(7)}}
+	if diff := cmp.Diff(want, readAllJSONLGCVs(t, r), protocmp.Transform()); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONLReader_errors(t *testing.T) {
+	t.Parallel()
+
+	rowType := tableTestRowType()
+	r, err := NewJSONLReader(strings.NewReader("{\"id\":1,\"name\":\"a\"}\n[1]\n{\"id\":true,\"name\":\"b\"}\n"), WithReaderRowType(rowType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadGCVs(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.ReadGCVs()
+	var readErr *JSONLReadError
+	if !errors.As(err, &readErr) || !errors.Is(err, ErrInvalidJSONLRecord) || readErr.Line != 2 {
+		t.Errorf("ReadGCVs() error = %v, want ErrInvalidJSONLRecord at line 2", err)
+	}
+	_, err = r.ReadGCVs()
+	if !errors.As(err, &readErr) || !errors.Is(err, ErrInvalidCell) || readErr.Line != 3 || readErr.Key != "id" {
+		t.Errorf("ReadGCVs() error = %v, want ErrInvalidCell at line 3, key id", err)
+	}
+	if _, err := r.ReadGCVs(); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadGCVs() error = %v, want io.EOF", err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		in   io.Reader
+		opts []JSONLReaderOption
+		want error
+	}{
+		{"nil input", nil, nil, ErrNilInputReader},
+		{"no row type", strings.NewReader(""), nil, ErrMissingColumnNames},
+		{"missing key policy", strings.NewReader(""), []JSONLReaderOption{WithReaderRowType(rowType), WithJSONLMissingKeys(9)}, ErrInvalidJSONLKeyPolicy},
+		{"extra key policy", strings.NewReader(""), []JSONLReaderOption{WithReaderRowType(rowType), WithJSONLExtraKeys(-1)}, ErrInvalidJSONLKeyPolicy},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewJSONLReader(tt.in, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewJSONLReader() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package writer
+
+import (
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// MarkdownOption configures a MarkdownWriter created by [NewMarkdownWriter].
+type MarkdownOption interface {
+	applyMarkdownOption(*MarkdownWriter) error
+}
+
+func applyMarkdownOptions(w *MarkdownWriter, options ...MarkdownOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyMarkdownOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownCellReplacer escapes cell text for a GFM table: backslashes and
+// pipes are backslash-escaped and line breaks become <br>, since a table row
+// must stay on one line.
+var markdownCellReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// MarkdownWriter writes rows as a GitHub Flavored Markdown table:
+//
+//	| id | name |
+//	| --- | --- |
+//	| 1 | Alice |
+//
+// The header and delimiter row are written with the first data row, or by
+// [MarkdownWriter.Flush] when no rows were written. Cells are formatted with
+// [spanvalue.SpannerCLICompatibleFormatConfig] unless [WithFormatter] is set.
+//
+// Each row is emitted with a single Write. After the first output write
+// failure, every later Write*/Flush call returns that error; discard the
+// writer (see package doc "Write errors").
+type MarkdownWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for the header.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+
+	schema      columnSchema
+	wroteHeader bool
+	out         io.Writer
+}
+
+// NewMarkdownWriter returns a Markdown table writer configured by options.
+func NewMarkdownWriter(out io.Writer, options ...MarkdownOption) (*MarkdownWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &MarkdownWriter{
+		formatter: spanvalue.SpannerCLICompatibleFormatConfig(),
+		out:       out,
+	}
+	if err := applyMarkdownOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one table row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *MarkdownWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *MarkdownWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *MarkdownWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one table row; see [DelimitedWriter.WriteValues].
+func (w *MarkdownWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one table row; see [DelimitedWriter.WriteStructValues].
+func (w *MarkdownWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one table row; see [DelimitedWriter.WriteGCVs].
+func (w *MarkdownWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	cells, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if !w.wroteHeader {
+		if err := w.appendHeader(&b); err != nil {
+			return err
+		}
+	}
+	appendMarkdownRow(&b, cells)
+	if err := w.write(b.String()); err != nil {
+		return err
+	}
+	w.wroteHeader = true
+	return nil
+}
+
+// Flush writes the header when no rows were written, so a registered schema
+// yields a header-only table; a registered zero-column schema writes nothing.
+// With no registered schema, Flush returns [ErrMissingColumnNames]. After a
+// write failure, Flush returns the latched error (see package doc "Write errors").
+func (w *MarkdownWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.wroteHeader {
+		return nil
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	if err := w.appendHeader(&b); err != nil {
+		return err
+	}
+	if err := w.write(b.String()); err != nil {
+		return err
+	}
+	w.wroteHeader = true
+	return nil
+}
+
+// FormatConfig returns the effective formatter used for table cells.
+// When no formatter is configured, this returns [spanvalue.SpannerCLICompatibleFormatConfig].
+// Configure it only via [NewMarkdownWriter] or [WithFormatter].
+func (w *MarkdownWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return w.formatter
+}
+
+func (w *MarkdownWriter) appendHeader(b *strings.Builder) error {
+	names := w.schema.names
+	if w.unnamedFieldNamer != nil {
+		resolved, err := internal.ResolveColumnNames(names, w.unnamedFieldNamer)
+		if err != nil {
+			return err
+		}
+		names = resolved
+	}
+	appendMarkdownRow(b, names)
+	b.WriteString("|")
+	for range names {
+		b.WriteString(" --- |")
+	}
+	b.WriteByte('\n')
+	return nil
+}
+
+func appendMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(markdownCellReplacer.Replace(cell))
+		b.WriteString(" |")
+	}
+	b.WriteByte('\n')
+}
+
+func (w *MarkdownWriter) write(s string) error {
+	_, err := io.WriteString(w.out, s)
+	return w.latchWriteErr(err)
+}
+
+func (w *MarkdownWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *MarkdownWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *MarkdownWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var (
+	_ RowIteratorWriter = (*MarkdownWriter)(nil)
+	_ RowIteratorWriter = (*HTMLWriter)(nil)
+)
+
+func TestMarkdownWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewMarkdownWriter(t, &out, WithRowType(tableTestRowType()))
+	writeRows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue(`a|b\c`)},
+		{gcvctor.Int64Value(2), gcvctor.StringValue("x\ny")},
+		{gcvctor.Int64Value(3), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+	}
+	for _, row := range writeRows {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "| id | name |\n" +
+		"| --- | --- |\n" +
+		`| 1 | a\|b\\c |` + "\n" +
+		"| 2 | x<br>y |\n" +
+		"| 3 | NULL |\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	w = mustNewMarkdownWriter(t, &out, WithColumnNames([]string{"a|b"}))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "| a\\|b |\n| --- |\n"; got != want {
+		t.Errorf("header-only output = %q, want %q", got, want)
+	}
+
+	if err := mustNewMarkdownWriter(t, &out).Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+}
+
+func TestMarkdownWriter_formatter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewMarkdownWriter(t, &out, WithFormatter(spanvalue.LiteralFormatConfig()))
+	if err := w.WriteValues([]string{"s"}, []spanner.GenericColumnValue{gcvctor.StringValue("v")}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "| s |\n| --- |\n| \"v\" |\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestHTMLWriter(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("<b>&\"x\"\ny")},
+		{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+	}
+	want := "<table>\n<thead>\n" +
+		`<tr><th data-type="INT64">id</th><th data-type="STRING">name</th></tr>` + "\n" +
+		"</thead>\n<tbody>\n" +
+		"<tr><td>1</td><td>&lt;b&gt;&amp;&#34;x&#34;<br>y</td></tr>\n" +
+		`<tr><td>2</td><td class="null">NULL</td></tr>` + "\n" +
+		"</tbody>\n</table>\n"
+
+	for _, streaming := range []bool{false, true} {
+		var out bytes.Buffer
+		w := mustNewHTMLWriter(t, &out, WithHTMLStreaming(streaming))
+		if err := w.PrepareRowType(tableTestRowType()); err != nil {
+			t.Fatal(err)
+		}
+		if streaming {
+			if got := out.String(); got != want[:bytes.Index([]byte(want), []byte("<tr><td>"))] {
+				t.Errorf("streaming output after PrepareRowType = %q", got)
+			}
+		} else if out.Len() != 0 {
+			t.Errorf("buffered output before Flush = %q, want empty", out.String())
+		}
+		for _, row := range rows {
+			if err := w.WriteGCVs(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != want {
+			t.Errorf("streaming=%v output mismatch\ngot:\n%s\nwant:\n%s", streaming, got, want)
+		}
+	}
+}
+
+func TestHTMLWriter_namesOnly(t *testing.T) {
+	t.Parallel()
+
+	// Buffered mode takes header types from the first row; a custom null
+	// class is escaped, and an empty one drops the attribute.
+	var out bytes.Buffer
+	w := mustNewHTMLWriter(t, &out, WithHTMLNullClass(`na"`))
+	if err := w.WriteValues([]string{"v"}, []spanner.GenericColumnValue{gcvctor.NullFromCode(sppb.TypeCode_BOOL)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "<table>\n<thead>\n<tr><th data-type=\"BOOL\">v</th></tr>\n</thead>\n<tbody>\n" +
+		"<tr><td class=\"na&#34;\">NULL</td></tr>\n</tbody>\n</table>\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	w = mustNewHTMLWriter(t, &out, WithHTMLNullClass(""), WithHTMLStreaming(true), WithColumnNames([]string{"v"}))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.NullFromCode(sppb.TypeCode_BOOL)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want = "<table>\n<thead>\n<tr><th data-type=\"BOOL\">v</th></tr>\n</thead>\n<tbody>\n" +
+		"<tr><td>NULL</td></tr>\n</tbody>\n</table>\n"
+	if got := out.String(); got != want {
+		t.Errorf("streaming names-only output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHTMLWriter_stickyError(t *testing.T) {
+	t.Parallel()
+
+	fw := &failNthWrite{n: 1}
+	w := mustNewHTMLWriter(t, fw, WithHTMLStreaming(true))
+	if err := w.PrepareRowType(tableTestRowType()); !errors.Is(err, errInjected) {
+		t.Fatalf("PrepareRowType error = %v, want errInjected", err)
+	}
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); !errors.Is(err, errInjected) {
+		t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+	}
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Errorf("Flush after failure error = %v, want errInjected", err)
+	}
+}
@@ -0,0 +1,420 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Spanner commit limits used as the default [MutationWriter] batch limits.
+const (
+	// DefaultMutationBatchCount is the Spanner limit on mutations per commit.
+	DefaultMutationBatchCount = 80000
+	// DefaultMutationBatchBytes is the Spanner limit on commit size (100 MiB).
+	DefaultMutationBatchBytes = 100 << 20
+)
+
+// MutationOption configures a MutationWriter created by [NewMutationWriter] or
+// [NewMutationJSONWriter].
+type MutationOption interface {
+	applyMutationOption(*MutationWriter) error
+}
+
+type mutationOptionFunc func(*MutationWriter) error
+
+func (f mutationOptionFunc) applyMutationOption(w *MutationWriter) error {
+	return f(w)
+}
+
+func applyMutationOptions(w *MutationWriter, options ...MutationOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyMutationOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MutationKind selects the mutation operation written by [MutationWriter].
+type MutationKind int
+
+const (
+	// MutationInsert inserts rows; the commit fails if a row already exists.
+	MutationInsert MutationKind = iota
+	// MutationInsertOrUpdate inserts rows or updates the given columns of existing rows.
+	MutationInsertOrUpdate
+	// MutationReplace inserts rows or replaces existing rows, resetting unlisted columns.
+	MutationReplace
+	// MutationUpdate updates the given columns of existing rows.
+	MutationUpdate
+	// MutationDelete deletes rows by the key columns ([WithMutationKeyColumns]).
+	MutationDelete
+)
+
+// String returns the Go constant name for k, or "MutationKind(n)" for unknown values.
+func (k MutationKind) String() string {
+	switch k {
+	case MutationInsert:
+		return "MutationInsert"
+	case MutationInsertOrUpdate:
+		return "MutationInsertOrUpdate"
+	case MutationReplace:
+		return "MutationReplace"
+	case MutationUpdate:
+		return "MutationUpdate"
+	case MutationDelete:
+		return "MutationDelete"
+	default:
+		return fmt.Sprintf("MutationKind(%d)", int(k))
+	}
+}
+
+// WithMutationKind selects the mutation operation (default [MutationInsert]).
+// Unknown kinds return [ErrInvalidMutationKind].
+func WithMutationKind(kind MutationKind) MutationOption {
+	return mutationOptionFunc(func(w *MutationWriter) error {
+		if kind < MutationInsert || kind > MutationDelete {
+			return fmt.Errorf("%w: %v", ErrInvalidMutationKind, kind)
+		}
+		w.kind = kind
+		return nil
+	})
+}
+
+// WithMutationKeyColumns sets the primary key columns, in key order, that
+// [MutationDelete] reads from each row. Without it, every column is a key part.
+// Names not in the registered schema return [ErrMissingKeyColumn] on the first write.
+func WithMutationKeyColumns(names ...string) MutationOption {
+	return mutationOptionFunc(func(w *MutationWriter) error {
+		w.keyColumns = slices.Clone(names)
+		return nil
+	})
+}
+
+// WithMutationBatchCount sets the most mutations per batch (default
+// [DefaultMutationBatchCount]). Like Spanner, the writer counts an insert, update, or
+// replace as one mutation per column and a delete as one per row; secondary indexes
+// also count toward the Spanner limit, so lower the value for indexed tables.
+// Values below 1 return [ErrInvalidMutationBatchLimit].
+func WithMutationBatchCount(n int) MutationOption {
+	return mutationOptionFunc(func(w *MutationWriter) error {
+		if n < 1 {
+			return fmt.Errorf("%w: count %d", ErrInvalidMutationBatchLimit, n)
+		}
+		w.maxCount = n
+		return nil
+	})
+}
+
+// WithMutationBatchBytes sets the most serialized mutation bytes per batch (default
+// [DefaultMutationBatchBytes]). Values below 1 return [ErrInvalidMutationBatchLimit].
+func WithMutationBatchBytes(n int) MutationOption {
+	return mutationOptionFunc(func(w *MutationWriter) error {
+		if n < 1 {
+			return fmt.Errorf("%w: bytes %d", ErrInvalidMutationBatchLimit, n)
+		}
+		w.maxBytes = n
+		return nil
+	})
+}
+
+// MutationWriter turns rows into Spanner mutations for a fixed table and hands them to
+// a sink in batches that stay within commit limits ([WithMutationBatchCount],
+// [WithMutationBatchBytes]), for replaying an export with Client.Apply, BatchWrite, or
+// the REST Commit API. Each row becomes one mutation built from the wire values, so
+// every Spanner type round-trips unchanged.
+//
+// A batch is sent when the next row would exceed a limit, and on Flush; a single row
+// over a limit returns [ErrMutationTooLarge]. Column names must be non-empty
+// ([ErrEmptyColumnName]). After the first sink or output failure, every later
+// Write*/Flush call returns that error; discard the writer (see package doc
+// "Write errors").
+type MutationWriter struct {
+	stickyWriteError
+	table      string
+	kind       MutationKind
+	keyColumns []string
+	maxCount   int
+	maxBytes   int
+	sink       func([]*sppb.Mutation) error
+
+	schema       columnSchema
+	keyIndexes   []int
+	pending      []*sppb.Mutation
+	pendingCount int
+	pendingBytes int
+}
+
+// NewMutationWriter returns a writer that passes each batch to sink as
+// [*spanner.Mutation] values, ready for Client.Apply or a BatchWrite mutation group.
+// table must be non-empty after strings.TrimSpace ([ErrEmptyTableName]); a nil sink
+// returns [ErrNilMutationSink].
+func NewMutationWriter(table string, sink func([]*spanner.Mutation) error, options ...MutationOption) (*MutationWriter, error) {
+	if sink == nil {
+		return nil, ErrNilMutationSink
+	}
+	return newMutationWriter(table, func(batch []*sppb.Mutation) error {
+		ms := make([]*spanner.Mutation, len(batch))
+		for i, pb := range batch {
+			m, err := spanner.WrapMutation(pb)
+			if err != nil {
+				return err
+			}
+			ms[i] = m
+		}
+		return sink(ms)
+	}, options...)
+}
+
+// NewMutationJSONWriter returns a writer that serializes each batch to out as one line of
+// JSON: a Commit request body {"mutations": [...]} for the Spanner REST API, with
+// [sppb.Mutation] values in protojson form. Callers add the transaction or
+// singleUseTransaction field before sending.
+func NewMutationJSONWriter(out io.Writer, table string, options ...MutationOption) (*MutationWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	return newMutationWriter(table, func(batch []*sppb.Mutation) error {
+		b, err := protojson.Marshal(&sppb.CommitRequest{Mutations: batch})
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(append(b, '\n'))
+		return err
+	}, options...)
+}
+
+func newMutationWriter(table string, sink func([]*sppb.Mutation) error, options ...MutationOption) (*MutationWriter, error) {
+	w := &MutationWriter{
+		table:    table,
+		maxCount: DefaultMutationBatchCount,
+		maxBytes: DefaultMutationBatchBytes,
+		sink:     sink,
+	}
+	if err := applyMutationOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(w.table) == "" {
+		return nil, ErrEmptyTableName
+	}
+	return w, nil
+}
+
+// TableName returns the table the mutations target.
+func (w *MutationWriter) TableName() string {
+	return w.table
+}
+
+// WriteRow adds one row's mutation. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *MutationWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *MutationWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *MutationWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues adds one row's mutation; see [DelimitedWriter.WriteValues].
+func (w *MutationWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues adds one row's mutation; see [DelimitedWriter.WriteStructValues].
+func (w *MutationWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs adds one row's mutation, first sending the pending batch to the sink when
+// the row would exceed a batch limit.
+func (w *MutationWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if len(w.schema.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	m, count, err := w.mutation(values)
+	if err != nil {
+		return err
+	}
+	size := proto.Size(m)
+	if count > w.maxCount || size > w.maxBytes {
+		return fmt.Errorf("%w: %d mutations, %d bytes", ErrMutationTooLarge, count, size)
+	}
+	if w.pendingCount+count > w.maxCount || w.pendingBytes+size > w.maxBytes {
+		if err := w.sendPending(); err != nil {
+			return err
+		}
+	}
+	w.pending = append(w.pending, m)
+	w.pendingCount += count
+	w.pendingBytes += size
+	return nil
+}
+
+// Flush sends the pending batch, if any, to the sink.
+func (w *MutationWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	return w.sendPending()
+}
+
+func (w *MutationWriter) sendPending() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	if err := w.latchWriteErr(w.sink(w.pending)); err != nil {
+		return err
+	}
+	w.pending = nil
+	w.pendingCount = 0
+	w.pendingBytes = 0
+	return nil
+}
+
+// mutation builds the row's mutation and its count toward the commit limit.
+func (w *MutationWriter) mutation(values []spanner.GenericColumnValue) (*sppb.Mutation, int, error) {
+	if slices.Contains(w.schema.names, "") {
+		return nil, 0, ErrEmptyColumnName
+	}
+	if w.kind == MutationDelete {
+		if err := w.resolveKeyIndexes(); err != nil {
+			return nil, 0, err
+		}
+		key := &structpb.ListValue{Values: make([]*structpb.Value, len(w.keyIndexes))}
+		for i, idx := range w.keyIndexes {
+			key.Values[i] = wireValueOrNull(values[idx].Value)
+		}
+		return &sppb.Mutation{Operation: &sppb.Mutation_Delete_{Delete: &sppb.Mutation_Delete{
+			Table:  w.table,
+			KeySet: &sppb.KeySet{Keys: []*structpb.ListValue{key}},
+		}}}, 1, nil
+	}
+	row := &structpb.ListValue{Values: make([]*structpb.Value, len(values))}
+	for i, v := range values {
+		row.Values[i] = wireValueOrNull(v.Value)
+	}
+	write := &sppb.Mutation_Write{
+		Table:   w.table,
+		Columns: slices.Clone(w.schema.names),
+		Values:  []*structpb.ListValue{row},
+	}
+	var m *sppb.Mutation
+	switch w.kind {
+	case MutationInsertOrUpdate:
+		m = &sppb.Mutation{Operation: &sppb.Mutation_InsertOrUpdate{InsertOrUpdate: write}}
+	case MutationReplace:
+		m = &sppb.Mutation{Operation: &sppb.Mutation_Replace{Replace: write}}
+	case MutationUpdate:
+		m = &sppb.Mutation{Operation: &sppb.Mutation_Update{Update: write}}
+	default:
+		m = &sppb.Mutation{Operation: &sppb.Mutation_Insert{Insert: write}}
+	}
+	return m, len(values), nil
+}
+
+func (w *MutationWriter) resolveKeyIndexes() error {
+	if w.keyIndexes != nil {
+		return nil
+	}
+	if len(w.keyColumns) == 0 {
+		w.keyIndexes = make([]int, len(w.schema.names))
+		for i := range w.keyIndexes {
+			w.keyIndexes[i] = i
+		}
+		return nil
+	}
+	indexes := make([]int, len(w.keyColumns))
+	for i, name := range w.keyColumns {
+		idx := slices.Index(w.schema.names, name)
+		if idx < 0 {
+			return fmt.Errorf("%w: %q", ErrMissingKeyColumn, name)
+		}
+		indexes[i] = idx
+	}
+	w.keyIndexes = indexes
+	return nil
+}
+
+func (w *MutationWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.keyIndexes = nil
+}
+
+func (w *MutationWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.keyIndexes = nil
+}
+
+func (w *MutationWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// wireValueOrNull replaces a nil wire value, which the writers read as NULL,
+// with an explicit protobuf NULL so the value can be marshaled.
+func wireValueOrNull(v *structpb.Value) *structpb.Value {
+	if v == nil {
+		return structpb.NewNullValue()
+	}
+	return v
+}
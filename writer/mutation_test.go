@@ -0,0 +1,295 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*MutationWriter)(nil)
+
+func readCommitRequests(t *testing.T, out *bytes.Buffer) []*sppb.CommitRequest {
+	t.Helper()
+	var reqs []*sppb.CommitRequest
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		req := &sppb.CommitRequest{}
+		if err := protojson.Unmarshal(sc.Bytes(), req); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+func mutationTestRows() [][]spanner.GenericColumnValue {
+	return [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("a")},
+		{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+		{gcvctor.Int64Value(3), gcvctor.StringValue("c")},
+	}
+}
+
+func mutationTestWrite(id string, name *structpb.Value) *sppb.Mutation_Write {
+	return &sppb.Mutation_Write{
+		Table:   "Singers",
+		Columns: []string{"id", "name"},
+		Values:  []*structpb.ListValue{{Values: []*structpb.Value{structpb.NewStringValue(id), name}}},
+	}
+}
+
+func TestMutationJSONWriter_batches(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w, err := NewMutationJSONWriter(&out, "Singers",
+		WithRowType(tableTestRowType()),
+		WithMutationKind(MutationInsertOrUpdate),
+		WithMutationBatchCount(4),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range mutationTestRows() {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(readCommitRequests(t, bytes.NewBuffer(out.Bytes()))); got != 1 {
+		t.Fatalf("batches before Flush = %d, want 1", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	insertOrUpdate := func(id string, name *structpb.Value) *sppb.Mutation {
+		return &sppb.Mutation{Operation: &sppb.Mutation_InsertOrUpdate{InsertOrUpdate: mutationTestWrite(id, name)}}
+	}
+	want := []*sppb.CommitRequest{
+		{Mutations: []*sppb.Mutation{
+			insertOrUpdate("1", structpb.NewStringValue("a")),
+			insertOrUpdate("2", structpb.NewNullValue()),
+		}},
+		{Mutations: []*sppb.Mutation{insertOrUpdate("3", structpb.NewStringValue("c"))}},
+	}
+	if diff := cmp.Diff(want, readCommitRequests(t, &out), protocmp.Transform()); diff != "" {
+		t.Errorf("commit requests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMutationJSONWriter_delete(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w, err := NewMutationJSONWriter(&out, "Singers",
+		WithMutationKind(MutationDelete),
+		WithMutationKeyColumns("id"),
+		WithMutationBatchCount(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := RowSeq(
+		mustNewSpannerRow(t, []string{"id", "name"}, []any{int64(1), "a"}),
+		mustNewSpannerRow(t, []string{"id", "name"}, []any{int64(2), "b"}),
+	)
+	if _, err := WriteRowSeq(&sppb.ResultSetMetadata{RowType: tableTestRowType()}, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	del := func(id string) *sppb.Mutation {
+		return &sppb.Mutation{Operation: &sppb.Mutation_Delete_{Delete: &sppb.Mutation_Delete{
+			Table:  "Singers",
+			KeySet: &sppb.KeySet{Keys: []*structpb.ListValue{{Values: []*structpb.Value{structpb.NewStringValue(id)}}}},
+		}}}
+	}
+	want := []*sppb.CommitRequest{
+		{Mutations: []*sppb.Mutation{del("1")}},
+		{Mutations: []*sppb.Mutation{del("2")}},
+	}
+	if diff := cmp.Diff(want, readCommitRequests(t, &out), protocmp.Transform()); diff != "" {
+		t.Errorf("commit requests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMutationJSONWriter_nilValue(t *testing.T) {
+	t.Parallel()
+
+	// A nil wire value is NULL, in written rows and in delete keys.
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1), {Type: &sppb.Type{Code: sppb.TypeCode_STRING}}}
+	tests := []struct {
+		name string
+		opts []MutationOption
+		want *sppb.Mutation
+	}{
+		{
+			name: "insert",
+			want: &sppb.Mutation{Operation: &sppb.Mutation_Insert{Insert: mutationTestWrite("1", structpb.NewNullValue())}},
+		},
+		{
+			name: "delete",
+			opts: []MutationOption{WithMutationKind(MutationDelete), WithMutationKeyColumns("name")},
+			want: &sppb.Mutation{Operation: &sppb.Mutation_Delete_{Delete: &sppb.Mutation_Delete{
+				Table:  "Singers",
+				KeySet: &sppb.KeySet{Keys: []*structpb.ListValue{{Values: []*structpb.Value{structpb.NewNullValue()}}}},
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w, err := NewMutationJSONWriter(&out, "Singers", append([]MutationOption{WithRowType(tableTestRowType())}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteGCVs(row); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			want := []*sppb.CommitRequest{{Mutations: []*sppb.Mutation{tt.want}}}
+			if diff := cmp.Diff(want, readCommitRequests(t, &out), protocmp.Transform()); diff != "" {
+				t.Errorf("commit requests mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMutationWriter_sink(t *testing.T) {
+	t.Parallel()
+
+	var batches [][]*spanner.Mutation
+	w, err := NewMutationWriter("Singers", func(ms []*spanner.Mutation) error {
+		batches = append(batches, ms)
+		return nil
+	}, WithColumnNames([]string{"id", "name"}), WithMutationKind(MutationReplace), WithMutationBatchCount(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range mutationTestRows() {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(batches))
+	}
+	for i, batch := range batches {
+		if len(batch) != 1 || batch[0] == nil {
+			t.Errorf("batch %d = %v, want one mutation", i, batch)
+		}
+	}
+	if got := w.TableName(); got != "Singers" {
+		t.Errorf("TableName() = %q", got)
+	}
+}
+
+func TestMutationWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	discard := func([]*spanner.Mutation) error { return nil }
+	for _, tt := range []struct {
+		name  string
+		table string
+		opts  []MutationOption
+		want  error
+	}{
+		{"empty table", " ", nil, ErrEmptyTableName},
+		{"kind", "T", []MutationOption{WithMutationKind(MutationKind(5))}, ErrInvalidMutationKind},
+		{"count", "T", []MutationOption{WithMutationBatchCount(0)}, ErrInvalidMutationBatchLimit},
+		{"bytes", "T", []MutationOption{WithMutationBatchBytes(-1)}, ErrInvalidMutationBatchLimit},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewMutationWriter(tt.table, discard, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewMutationWriter error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewMutationWriter("T", nil); !errors.Is(err, ErrNilMutationSink) {
+		t.Errorf("NewMutationWriter(nil sink) error = %v, want ErrNilMutationSink", err)
+	}
+	if _, err := NewMutationJSONWriter(nil, "T"); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewMutationJSONWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}
+	for _, tt := range []struct {
+		name string
+		opts []MutationOption
+		want error
+	}{
+		{"no schema", nil, ErrMissingColumnNames},
+		{"too many mutations", []MutationOption{WithRowType(tableTestRowType()), WithMutationBatchCount(1)}, ErrMutationTooLarge},
+		{"too many bytes", []MutationOption{WithRowType(tableTestRowType()), WithMutationBatchBytes(10)}, ErrMutationTooLarge},
+		{"key column", []MutationOption{WithRowType(tableTestRowType()), WithMutationKind(MutationDelete), WithMutationKeyColumns("pk")}, ErrMissingKeyColumn},
+		{"unnamed column", []MutationOption{WithColumnNames([]string{"id", ""})}, ErrEmptyColumnName},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w, err := NewMutationWriter("T", discard, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteGCVs(row); !errors.Is(err, tt.want) {
+				t.Errorf("WriteGCVs error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		w, err := NewMutationWriter("T", func([]*spanner.Mutation) error {
+			calls++
+			return errInjected
+		}, WithRowType(tableTestRowType()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) || calls != 1 {
+			t.Errorf("Flush after failure error = %v with %d sink calls, want errInjected and 1 call", err, calls)
+		}
+	})
+}
+
+func TestMutationKind_String(t *testing.T) {
+	t.Parallel()
+
+	for k, want := range map[MutationKind]string{
+		MutationInsert:         "MutationInsert",
+		MutationInsertOrUpdate: "MutationInsertOrUpdate",
+		MutationReplace:        "MutationReplace",
+		MutationUpdate:         "MutationUpdate",
+		MutationDelete:         "MutationDelete",
+		7:                      "MutationKind(7)",
+	} {
+		if got := k.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(k), got, want)
+		}
+	}
+}
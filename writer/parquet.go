@@ -0,0 +1,704 @@
+package writer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/encoding"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// ParquetOption configures a ParquetWriter created by [NewParquetWriter].
+type ParquetOption interface {
+	applyParquetOption(*ParquetWriter) error
+}
+
+type parquetOptionFunc func(*ParquetWriter) error
+
+func (f parquetOptionFunc) applyParquetOption(w *ParquetWriter) error {
+	return f(w)
+}
+
+func applyParquetOptions(w *ParquetWriter, options ...ParquetOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyParquetOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParquetCompression selects the page compression codec of [ParquetWriter].
+type ParquetCompression int
+
+const (
+	// ParquetSnappy compresses pages with Snappy (the default).
+	ParquetSnappy ParquetCompression = iota
+	// ParquetUncompressed writes uncompressed pages.
+	ParquetUncompressed
+	// ParquetGzip compresses pages with gzip.
+	ParquetGzip
+	// ParquetZstd compresses pages with Zstandard.
+	ParquetZstd
+	// ParquetLZ4Raw compresses pages with LZ4_RAW.
+	ParquetLZ4Raw
+	// ParquetBrotli compresses pages with Brotli.
+	ParquetBrotli
+)
+
+// String returns the Go constant name for c, or "ParquetCompression(n)" for unknown values.
+func (c ParquetCompression) String() string {
+	switch c {
+	case ParquetSnappy:
+		return "ParquetSnappy"
+	case ParquetUncompressed:
+		return "ParquetUncompressed"
+	case ParquetGzip:
+		return "ParquetGzip"
+	case ParquetZstd:
+		return "ParquetZstd"
+	case ParquetLZ4Raw:
+		return "ParquetLZ4Raw"
+	case ParquetBrotli:
+		return "ParquetBrotli"
+	default:
+		return fmt.Sprintf("ParquetCompression(%d)", int(c))
+	}
+}
+
+func (c ParquetCompression) codec() compress.Codec {
+	switch c {
+	case ParquetUncompressed:
+		return &parquet.Uncompressed
+	case ParquetGzip:
+		return &parquet.Gzip
+	case ParquetZstd:
+		return &parquet.Zstd
+	case ParquetLZ4Raw:
+		return &parquet.Lz4Raw
+	case ParquetBrotli:
+		return &parquet.Brotli
+	default:
+		return &parquet.Snappy
+	}
+}
+
+// WithParquetCompression selects the page compression codec (default
+// [ParquetSnappy]). Unknown codecs return [ErrInvalidParquetCompression].
+func WithParquetCompression(c ParquetCompression) ParquetOption {
+	return parquetOptionFunc(func(w *ParquetWriter) error {
+		if c < ParquetSnappy || c > ParquetBrotli {
+			return fmt.Errorf("%w: %v", ErrInvalidParquetCompression, c)
+		}
+		w.compression = c
+		return nil
+	})
+}
+
+// WithParquetRowGroupRows caps the number of rows per row group; a full row
+// group is written to the output before the next row is accepted. n == 0
+// (the default) leaves sizing to the Parquet library; negative n returns
+// [ErrInvalidRowGroupRows].
+func WithParquetRowGroupRows(n int64) ParquetOption {
+	return parquetOptionFunc(func(w *ParquetWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidRowGroupRows, n)
+		}
+		w.rowGroupRows = n
+		return nil
+	})
+}
+
+// WithParquetTimestampNanos sets whether TIMESTAMP columns use nanosecond
+// precision (default false: microseconds, which more readers support, with
+// sub-microsecond digits truncated). Nanosecond timestamps are int64, so
+// values outside years 1678 to 2261 return an error.
+func WithParquetTimestampNanos(nanos bool) ParquetOption {
+	return parquetOptionFunc(func(w *ParquetWriter) error {
+		w.timestampNanos = nanos
+		return nil
+	})
+}
+
+// ParquetWriter writes rows as an Apache Parquet file. The Parquet schema is
+// derived from the row type, with every column and nested field optional:
+//
+//   - BOOL: BOOLEAN; INT64 and ENUM: INT64; FLOAT32: FLOAT; FLOAT64: DOUBLE
+//   - STRING and INTERVAL: STRING; BYTES and PROTO: BYTE_ARRAY; JSON: JSON
+//   - NUMERIC: DECIMAL(38,9); PostgreSQL NUMERIC: STRING, since its range
+//     exceeds DECIMAL(38,9)
+//   - TIMESTAMP: TIMESTAMP(MICROS, UTC), or NANOS with [WithParquetTimestampNanos]
+//   - DATE: DATE; UUID: UUID
+//   - ARRAY: LIST of the element type; STRUCT: group
+//
+// Columns keep the row type order. Unnamed columns and STRUCT fields are named
+// with [spanvalue.IndexedUnnamedFieldNamer] unless [WithUnnamedFieldNamer] is
+// set; names left empty return [ErrEmptyColumnName] and duplicate names
+// within one group return [ErrDuplicateColumnName]. Field types come from
+// the registered row type, or from the first row when only names are
+// registered. Values are written typed, so [WithFormatter] has no effect.
+//
+// Parquet stores its schema in a footer, so [ParquetWriter.Flush] finishes
+// the file and later Write* calls return [ErrWriterClosed]. A registered
+// schema with no rows yields a valid file without row groups; a registered
+// zero-column schema writes nothing.
+//
+// After the first output failure, every later Write*/Flush call returns that
+// error; discard the writer (see package doc "Write errors").
+type ParquetWriter struct {
+	stickyWriteError
+	// unnamedFieldNamer resolves empty column and STRUCT field names.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	compression       ParquetCompression
+	rowGroupRows      int64
+	timestampNanos    bool
+
+	schema  columnSchema
+	columns []*parquetColumn
+	leaves  int
+	pw      *parquet.Writer
+	closed  bool
+	out     io.Writer
+}
+
+// NewParquetWriter returns a Parquet writer configured by options.
+func NewParquetWriter(out io.Writer, options ...ParquetOption) (*ParquetWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &ParquetWriter{
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		out:               out,
+	}
+	if err := applyParquetOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *ParquetWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *ParquetWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *ParquetWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one row; see [DelimitedWriter.WriteValues].
+func (w *ParquetWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one row; see [DelimitedWriter.WriteStructValues].
+func (w *ParquetWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one row; see [DelimitedWriter.WriteGCVs]. Values must
+// match the column types of the file.
+func (w *ParquetWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	if w.pw == nil {
+		types := w.schema.types
+		if len(types) == 0 {
+			types = make([]*sppb.Type, len(values))
+			for i, v := range values {
+				types[i] = v.Type
+			}
+		}
+		if err := w.open(types); err != nil {
+			return err
+		}
+	}
+	leaves := make([][]parquet.Value, w.leaves)
+	for i, col := range w.columns {
+		if !internal.TypesEquivalent(col.typ, values[i].Type) {
+			return fmt.Errorf("%w: column %q has type %s, want %s", ErrParquetTypeMismatch, col.name, values[i].Type, col.typ)
+		}
+		if err := col.shred(leaves, values[i].Value, 0, 0, 0, w.timestampNanos); err != nil {
+			return err
+		}
+	}
+	var row parquet.Row
+	for _, column := range leaves {
+		row = append(row, column...)
+	}
+	if _, err := w.pw.WriteRows([]parquet.Row{row}); err != nil {
+		return w.latchWriteErr(err)
+	}
+	return nil
+}
+
+// Flush writes buffered rows and the file footer, finishing the file. Flush
+// after the file is finished returns nil. With no registered schema, Flush
+// returns [ErrMissingColumnNames]; with registered names but no field types
+// and no rows, it returns [ErrMissingFieldTypes]. After a write failure, Flush
+// returns the latched error (see package doc "Write errors").
+func (w *ParquetWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return nil
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		w.closed = true
+		return nil
+	}
+	if w.pw == nil {
+		if len(w.schema.types) == 0 {
+			return ErrMissingFieldTypes
+		}
+		if err := w.open(w.schema.types); err != nil {
+			return err
+		}
+	}
+	if err := w.pw.Close(); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.closed = true
+	return nil
+}
+
+// open builds the Parquet schema from types and starts the file.
+func (w *ParquetWriter) open(types []*sppb.Type) error {
+	fields := make([]*sppb.StructType_Field, len(w.schema.names))
+	for i, name := range w.schema.names {
+		fields[i] = &sppb.StructType_Field{Name: name, Type: types[i]}
+	}
+	columns, root, err := w.parquetGroup(fields)
+	if err != nil {
+		return err
+	}
+	leaves := 0
+	for _, col := range columns {
+		leaves = col.assignLeaves(leaves)
+	}
+	options := []parquet.WriterOption{
+		parquet.NewSchema("spanner", root),
+		parquet.Compression(w.compression.codec()),
+	}
+	if w.rowGroupRows > 0 {
+		options = append(options, parquet.MaxRowsPerRowGroup(w.rowGroupRows))
+	}
+	w.columns = columns
+	w.leaves = leaves
+	w.pw = parquet.NewWriter(w.out, options...)
+	return nil
+}
+
+// parquetGroup converts fields to columns and an order-preserving group node.
+func (w *ParquetWriter) parquetGroup(fields []*sppb.StructType_Field) ([]*parquetColumn, parquet.Node, error) {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.GetName()
+	}
+	names, err := internal.ResolveColumnNames(names, w.unnamedFieldNamer)
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[string]bool, len(names))
+	columns := make([]*parquetColumn, len(fields))
+	group := make(parquetGroupNode, len(fields))
+	for i, f := range fields {
+		name := names[i]
+		if name == "" {
+			return nil, nil, ErrEmptyColumnName
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("%w: %q", ErrDuplicateColumnName, name)
+		}
+		seen[name] = true
+		col, node, err := w.parquetColumnOf(name, f.GetType())
+		if err != nil {
+			return nil, nil, err
+		}
+		columns[i] = col
+		group[i] = parquetField{Node: parquet.Optional(node), name: name}
+	}
+	return columns, group, nil
+}
+
+// parquetColumnOf maps typ to a column and its (required) Parquet node.
+func (w *ParquetWriter) parquetColumnOf(name string, typ *sppb.Type) (*parquetColumn, parquet.Node, error) {
+	col := &parquetColumn{name: name, typ: typ}
+	var node parquet.Node
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		node = parquet.Leaf(parquet.BooleanType)
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		node = parquet.Leaf(parquet.Int64Type)
+	case sppb.TypeCode_FLOAT32:
+		node = parquet.Leaf(parquet.FloatType)
+	case sppb.TypeCode_FLOAT64:
+		node = parquet.Leaf(parquet.DoubleType)
+	case sppb.TypeCode_STRING, sppb.TypeCode_INTERVAL:
+		node = parquet.String()
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		node = parquet.Leaf(parquet.ByteArrayType)
+	case sppb.TypeCode_JSON:
+		node = parquet.JSON()
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			node = parquet.String()
+		} else {
+			node = parquet.Decimal(parquetNumericScale, parquetNumericPrecision, parquet.FixedLenByteArrayType(parquetDecimalBytes))
+		}
+	case sppb.TypeCode_TIMESTAMP:
+		unit := parquet.Microsecond
+		if w.timestampNanos {
+			unit = parquet.Nanosecond
+		}
+		node = parquet.TimestampAdjusted(unit, true)
+	case sppb.TypeCode_DATE:
+		node = parquet.Date()
+	case sppb.TypeCode_UUID:
+		node = parquet.UUID()
+	case sppb.TypeCode_ARRAY:
+		elem, elemNode, err := w.parquetColumnOf("element", typ.GetArrayElementType())
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %q: %w", name, err)
+		}
+		col.elem = elem
+		node = parquet.List(parquet.Optional(elemNode))
+	case sppb.TypeCode_STRUCT:
+		if len(typ.GetStructType().GetFields()) == 0 {
+			return nil, nil, fmt.Errorf("%w: column %q is a STRUCT without fields", ErrUnsupportedParquetType, name)
+		}
+		fields, group, err := w.parquetGroup(typ.GetStructType().GetFields())
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %q: %w", name, err)
+		}
+		col.fields = fields
+		node = group
+	default:
+		return nil, nil, fmt.Errorf("%w: column %q has type %s", ErrUnsupportedParquetType, name, typ.GetCode())
+	}
+	return col, node, nil
+}
+
+func (w *ParquetWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *ParquetWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *ParquetWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+const (
+	parquetNumericPrecision = 38
+	parquetNumericScale     = 9
+	// parquetDecimalBytes is the FIXED_LEN_BYTE_ARRAY width of DECIMAL(38,9).
+	parquetDecimalBytes = 16
+)
+
+// parquetColumn mirrors one optional Parquet node and shreds values into its
+// leaf columns. Leaves are numbered in row type order.
+type parquetColumn struct {
+	name   string
+	typ    *sppb.Type
+	elem   *parquetColumn   // ARRAY
+	fields []*parquetColumn // STRUCT
+	// leaf is the leaf column index of a scalar column, and firstLeaf and
+	// numLeaves span the leaves of a nested one.
+	leaf      int
+	firstLeaf int
+	numLeaves int
+}
+
+// assignLeaves numbers the leaves of c from next and returns the next free index.
+func (c *parquetColumn) assignLeaves(next int) int {
+	c.firstLeaf = next
+	switch {
+	case c.elem != nil:
+		next = c.elem.assignLeaves(next)
+	case c.fields != nil:
+		for _, f := range c.fields {
+			next = f.assignLeaves(next)
+		}
+	default:
+		c.leaf = next
+		next++
+	}
+	c.numLeaves = next - c.firstLeaf
+	return next
+}
+
+// shred appends v to the leaf columns with Dremel repetition and definition
+// levels. def is the definition level of the enclosing node; c itself is
+// optional, so a non-NULL v is defined at def+1. repDepth is the number of
+// enclosing repeated nodes.
+func (c *parquetColumn) shred(leaves [][]parquet.Value, v *structpb.Value, rep, def, repDepth int, nanos bool) error {
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); v == nil || isNull {
+		c.appendNulls(leaves, rep, def)
+		return nil
+	}
+	def++
+	switch {
+	case c.elem != nil:
+		values := v.GetListValue().GetValues()
+		if len(values) == 0 {
+			// An empty list: defined, without a repeated entry.
+			c.elem.appendNulls(leaves, rep, def)
+			return nil
+		}
+		for i, elem := range values {
+			r := rep
+			if i > 0 {
+				r = repDepth + 1
+			}
+			if err := c.elem.shred(leaves, elem, r, def+1, repDepth+1, nanos); err != nil {
+				return fmt.Errorf("column %q[%d]: %w", c.name, i, err)
+			}
+		}
+	case c.typ.GetCode() == sppb.TypeCode_STRUCT:
+		values := v.GetListValue().GetValues()
+		if len(values) != len(c.fields) {
+			return fmt.Errorf("%w: column %q has %d struct values, want %d", ErrMismatchedStructValueCount, c.name, len(values), len(c.fields))
+		}
+		for i, f := range c.fields {
+			if err := f.shred(leaves, values[i], rep, def, repDepth, nanos); err != nil {
+				return fmt.Errorf("column %q: %w", c.name, err)
+			}
+		}
+	default:
+		pv, err := parquetScalar(c.typ, v, nanos)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", c.name, err)
+		}
+		leaves[c.leaf] = append(leaves[c.leaf], pv.Level(rep, def, c.leaf))
+	}
+	return nil
+}
+
+func (c *parquetColumn) appendNulls(leaves [][]parquet.Value, rep, def int) {
+	for leaf := c.firstLeaf; leaf < c.firstLeaf+c.numLeaves; leaf++ {
+		leaves[leaf] = append(leaves[leaf], parquet.NullValue().Level(rep, def, leaf))
+	}
+}
+
+// parquetScalar converts a non-NULL scalar wire value to its Parquet value.
+func parquetScalar(typ *sppb.Type, v *structpb.Value, nanos bool) (parquet.Value, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("BOOL wire kind %T", v.GetKind())
+		}
+		return parquet.BooleanValue(b.BoolValue), nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM:
+		n, err := strconv.ParseInt(v.GetStringValue(), 10, 64)
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.Int64Value(n), nil
+	case sppb.TypeCode_FLOAT64:
+		f, err := internal.FloatFromWire(v)
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.DoubleValue(f), nil
+	case sppb.TypeCode_FLOAT32:
+		f, err := internal.FloatFromWire(v)
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.FloatValue(float32(f)), nil
+	case sppb.TypeCode_STRING, sppb.TypeCode_JSON, sppb.TypeCode_INTERVAL:
+		return parquet.ByteArrayValue([]byte(v.GetStringValue())), nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		b, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.ByteArrayValue(b), nil
+	case sppb.TypeCode_NUMERIC:
+		if typ.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			return parquet.ByteArrayValue([]byte(v.GetStringValue())), nil
+		}
+		b, err := parquetDecimal(v.GetStringValue())
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.FixedLenByteArrayValue(b), nil
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		if nanos {
+			ns := t.UnixNano()
+			if !time.Unix(0, ns).Equal(t) {
+				return parquet.Value{}, fmt.Errorf("TIMESTAMP %q out of nanosecond range", v.GetStringValue())
+			}
+			return parquet.Int64Value(ns), nil
+		}
+		return parquet.Int64Value(t.UnixMicro()), nil
+	case sppb.TypeCode_DATE:
+		d, err := civil.ParseDate(v.GetStringValue())
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.Int32Value(int32(d.DaysSince(civil.Date{Year: 1970, Month: time.January, Day: 1}))), nil
+	case sppb.TypeCode_UUID:
+		u, err := uuid.Parse(v.GetStringValue())
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.FixedLenByteArrayValue(u[:]), nil
+	default:
+		return parquet.Value{}, fmt.Errorf("%w: %s", ErrUnsupportedParquetType, typ.GetCode())
+	}
+}
+
+// parquetDecimal encodes a NUMERIC wire string as the big-endian two's
+// complement unscaled value of DECIMAL(38,9).
+func parquetDecimal(s string) ([]byte, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid NUMERIC %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(parquetNumericScale), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("NUMERIC %q has more than %d fractional digits", s, parquetNumericScale)
+	}
+	n := r.Num()
+	limit := new(big.Int).Lsh(big.NewInt(1), parquetDecimalBytes*8-1)
+	if n.CmpAbs(limit) >= 0 {
+		return nil, fmt.Errorf("NUMERIC %q out of DECIMAL(%d,%d) range", s, parquetNumericPrecision, parquetNumericScale)
+	}
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), parquetDecimalBytes*8))
+	}
+	return n.FillBytes(make([]byte, parquetDecimalBytes)), nil
+}
+
+// parquetGroupNode is a Parquet group that keeps its fields in the given
+// order; parquet.Group sorts fields by name.
+type parquetGroupNode []parquet.Field
+
+func (g parquetGroupNode) ID() int                     { return 0 }
+func (g parquetGroupNode) Type() parquet.Type          { return parquet.Group{}.Type() }
+func (g parquetGroupNode) Optional() bool              { return false }
+func (g parquetGroupNode) Repeated() bool              { return false }
+func (g parquetGroupNode) Required() bool              { return true }
+func (g parquetGroupNode) Leaf() bool                  { return false }
+func (g parquetGroupNode) Fields() []parquet.Field     { return g }
+func (g parquetGroupNode) Encoding() encoding.Encoding { return nil }
+func (g parquetGroupNode) Compression() compress.Codec { return nil }
+func (g parquetGroupNode) GoType() reflect.Type        { return reflect.TypeFor[map[string]any]() }
+
+func (g parquetGroupNode) String() string {
+	var b strings.Builder
+	b.WriteString("group {")
+	for i, f := range g {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(f.Name())
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+func (f parquetField) Name() string { return f.name }
+
+// Value is unused: rows are shredded by [parquetColumn], not by reflection.
+func (f parquetField) Value(reflect.Value) reflect.Value { return reflect.Value{} }
+
+var _ parquet.Node = parquetGroupNode(nil)
@@ -0,0 +1,335 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+	"github.com/google/go-cmp/cmp"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*ParquetWriter)(nil)
+
+func openParquet(t *testing.T, b []byte) *parquet.File {
+	t.Helper()
+	f, err := parquet.OpenFile(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// parquetLeafRows reads every row of f as leaf values formatted as
+// "value@rep,def", so tests can check Dremel levels.
+func parquetLeafRows(t *testing.T, f *parquet.File) [][]string {
+	t.Helper()
+	r := parquet.NewReader(f)
+	defer r.Close()
+	rows := make([]parquet.Row, f.NumRows())
+	n, err := r.ReadRows(rows)
+	if err != nil && n != len(rows) {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, row := range rows[:n] {
+		var leaves []string
+		for _, v := range row {
+			s := "null"
+			if !v.IsNull() {
+				switch v.Kind() {
+				case parquet.ByteArray, parquet.FixedLenByteArray:
+					s = fmt.Sprintf("%q", v.ByteArray())
+				default:
+					s = v.String()
+				}
+			}
+			leaves = append(leaves, fmt.Sprintf("%s@%d,%d", s, v.RepetitionLevel(), v.DefinitionLevel()))
+		}
+		got = append(got, leaves)
+	}
+	return got
+}
+
+func TestParquetWriter_schema(t *testing.T) {
+	t.Parallel()
+
+	rowType := typector.MustNameTypeSlicesToStructType(
+		[]string{"z", "b", "f32", "f64", "n", "pgn", "ts", "d", "j", "by", "p", "e", "u", "arr", "s", ""},
+		[]*sppb.Type{
+			typector.Int64(), typector.Bool(), typector.Float32(), typector.Float64(),
+			typector.Numeric(), typector.PGNumeric(), typector.Timestamp(), typector.Date(),
+			typector.JSON(), typector.Bytes(), typector.FQNToProtoType("pkg.Msg"), typector.FQNToEnumType("pkg.Enum"),
+			typector.UUID(), typector.ElemCodeToArrayType(sppb.TypeCode_STRING),
+			typector.NameCodeToStructType("a", sppb.TypeCode_INT64), typector.String(),
+		},
+	).GetStructType()
+
+	var out bytes.Buffer
+	w := mustNewParquetWriter(t, &out, WithRowType(rowType))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	f := openParquet(t, out.Bytes())
+	want := `message spanner {
+	optional int64 z (INT(64,true));
+	optional boolean b;
+	optional float f32;
+	optional double f64;
+	optional fixed_len_byte_array(16) n (DECIMAL(38,9));
+	optional binary pgn (STRING);
+	optional int64 ts (TIMESTAMP(isAdjustedToUTC=true,unit=MICROS));
+	optional int32 d (DATE);
+	optional binary j (JSON);
+	optional binary by;
+	optional binary p;
+	optional int64 e (INT(64,true));
+	optional fixed_len_byte_array(16) u (UUID);
+	optional group arr (LIST) {
+		repeated group list {
+			optional binary element (STRING);
+		}
+	}
+	optional group s {
+		optional int64 a (INT(64,true));
+	}
+	optional binary _0 (STRING);
+}`
+	if diff := cmp.Diff(want, f.Schema().String()); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if got := f.NumRows(); got != 0 {
+		t.Errorf("NumRows = %d, want 0", got)
+	}
+}
+
+func TestParquetWriter_values(t *testing.T) {
+	t.Parallel()
+
+	st := gcvctor.MustStructValueOf([]string{"x", "y"}, []spanner.GenericColumnValue{gcvctor.Int64Value(7), gcvctor.StringValue("s")})
+	names := []string{"id", "num", "ts", "d", "by", "arr", "st"}
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(1),
+			gcvctor.NumericValue(big.NewRat(-12345, 100)),
+			gcvctor.TimestampValue(time.Unix(1, 2500)),
+			gcvctor.MustDateStringValue("1970-01-03"),
+			gcvctor.BytesValue([]byte("hi")),
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.NullFromCode(sppb.TypeCode_INT64), gcvctor.Int64Value(3)),
+			st,
+		},
+		{
+			gcvctor.NullFromCode(sppb.TypeCode_INT64),
+			gcvctor.NumericValue(big.NewRat(1, 1)),
+			gcvctor.NullFromCode(sppb.TypeCode_TIMESTAMP),
+			gcvctor.MustDateStringValue("1969-12-31"),
+			gcvctor.NullFromCode(sppb.TypeCode_BYTES),
+			gcvctor.EmptyArrayFromCode(sppb.TypeCode_INT64),
+			gcvctor.NullOf(st.Type),
+		},
+		{
+			gcvctor.Int64Value(3),
+			gcvctor.NullFromCode(sppb.TypeCode_NUMERIC),
+			gcvctor.TimestampValue(time.Unix(0, 0)),
+			gcvctor.NullFromCode(sppb.TypeCode_DATE),
+			gcvctor.BytesValue(nil),
+			gcvctor.NullArrayFromCode(sppb.TypeCode_INT64),
+			gcvctor.MustStructValueOf([]string{"x", "y"}, []spanner.GenericColumnValue{gcvctor.NullFromCode(sppb.TypeCode_INT64), gcvctor.StringValue("t")}),
+		},
+	}
+
+	var out bytes.Buffer
+	w := mustNewParquetWriter(t, &out)
+	for _, row := range rows {
+		if err := w.WriteValues(names, row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// -123.45 * 10^9 as a 16-byte big-endian two's complement.
+	negNumeric := string(new(big.Int).Add(big.NewInt(-123450000000), new(big.Int).Lsh(big.NewInt(1), 128)).FillBytes(make([]byte, 16)))
+	oneNumeric := string(big.NewInt(1000000000).FillBytes(make([]byte, 16)))
+	want := [][]string{
+		{
+			"1@0,1", fmt.Sprintf("%q@0,1", negNumeric), "1000002@0,1", "2@0,1", `"hi"@0,1`,
+			"1@0,3", "null@1,2", "3@1,3",
+			"7@0,2", `"s"@0,2`,
+		},
+		{
+			"null@0,0", fmt.Sprintf("%q@0,1", oneNumeric), "null@0,0", "-1@0,1", "null@0,0",
+			"null@0,1",
+			"null@0,0", "null@0,0",
+		},
+		{
+			"3@0,1", "null@0,0", "0@0,1", "null@0,0", `""@0,1`,
+			"null@0,0",
+			"null@0,1", `"t"@0,2`,
+		},
+	}
+	if diff := cmp.Diff(want, parquetLeafRows(t, openParquet(t, out.Bytes()))); diff != "" {
+		t.Errorf("leaf values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParquetWriter_rowIterator(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewParquetWriter(t, &out, WithParquetTimestampNanos(true))
+	names := []string{"id", "name"}
+	md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+	rows := RowSeq(
+		mustNewSpannerRow(t, names, []any{int64(1), "a"}),
+		mustNewSpannerRow(t, names, []any{int64(2), spanner.NullString{}}),
+	)
+	if _, err := WriteRowSeq(md, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1@0,1", `"a"@0,1`}, {"2@0,1", "null@0,0"}}
+	if diff := cmp.Diff(want, parquetLeafRows(t, openParquet(t, out.Bytes()))); diff != "" {
+		t.Errorf("leaf values mismatch (-want +got):\n%s", diff)
+	}
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(3), gcvctor.StringValue("c")}); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WriteGCVs after Flush error = %v, want ErrWriterClosed", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("second Flush error = %v, want nil", err)
+	}
+}
+
+func TestParquetWriter_rowGroupsAndCompression(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewParquetWriter(t, &out,
+		WithRowType(tableTestRowType()),
+		WithParquetRowGroupRows(2),
+		WithParquetCompression(ParquetZstd),
+		WithParquetTimestampNanos(true),
+	)
+	for i := range 5 {
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(int64(i)), gcvctor.StringValue(strings.Repeat("x", i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	f := openParquet(t, out.Bytes())
+	if got := len(f.RowGroups()); got != 3 {
+		t.Errorf("row groups = %d, want 3", got)
+	}
+	if got := f.NumRows(); got != 5 {
+		t.Errorf("NumRows = %d, want 5", got)
+	}
+	if got := f.Metadata().RowGroups[0].Columns[0].MetaData.Codec; got != format.Zstd {
+		t.Errorf("codec = %v, want ZSTD", got)
+	}
+}
+
+func TestParquetWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewParquetWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewParquetWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewParquetWriter(&bytes.Buffer{}, WithParquetCompression(ParquetCompression(99))); !errors.Is(err, ErrInvalidParquetCompression) {
+		t.Errorf("invalid compression error = %v, want ErrInvalidParquetCompression", err)
+	}
+	if _, err := NewParquetWriter(&bytes.Buffer{}, WithParquetRowGroupRows(-1)); !errors.Is(err, ErrInvalidRowGroupRows) {
+		t.Errorf("negative row group rows error = %v, want ErrInvalidRowGroupRows", err)
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name    string
+			rowType *sppb.StructType
+			want    error
+		}{
+			{
+				name:    "duplicate",
+				rowType: typector.MustNameCodeSlicesToStructType([]string{"a", "a"}, []sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_INT64}).GetStructType(),
+				want:    ErrDuplicateColumnName,
+			},
+			{
+				name:    "unsupported",
+				rowType: typector.MustNameCodeSlicesToStructType([]string{"a"}, []sppb.TypeCode{sppb.TypeCode_TYPE_CODE_UNSPECIFIED}).GetStructType(),
+				want:    ErrUnsupportedParquetType,
+			},
+		}
+		for _, tt := range tests {
+			w := mustNewParquetWriter(t, &bytes.Buffer{}, WithRowType(tt.rowType))
+			if err := w.Flush(); !errors.Is(err, tt.want) {
+				t.Errorf("%s: Flush error = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+		w := mustNewParquetWriter(t, &bytes.Buffer{}, WithRowType(typector.MustNameCodeSlicesToStructType([]string{""}, []sppb.TypeCode{sppb.TypeCode_INT64}).GetStructType()), WithUnnamedFieldNamer(nil))
+		if err := w.Flush(); !errors.Is(err, ErrEmptyColumnName) {
+			t.Errorf("empty name: Flush error = %v, want ErrEmptyColumnName", err)
+		}
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewParquetWriter(t, &bytes.Buffer{})
+		if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+			t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+		}
+		w = mustNewParquetWriter(t, &bytes.Buffer{}, WithColumnNames([]string{"id"}))
+		if err := w.Flush(); !errors.Is(err, ErrMissingFieldTypes) {
+			t.Errorf("Flush names-only error = %v, want ErrMissingFieldTypes", err)
+		}
+		w = mustNewParquetWriter(t, &bytes.Buffer{}, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.StringValue("1"), gcvctor.StringValue("a")}); !errors.Is(err, ErrParquetTypeMismatch) {
+			t.Errorf("mismatched value error = %v, want ErrParquetTypeMismatch", err)
+		}
+		tsType := typector.MustNameCodeSlicesToStructType([]string{"ts"}, []sppb.TypeCode{sppb.TypeCode_TIMESTAMP}).GetStructType()
+		w = mustNewParquetWriter(t, &bytes.Buffer{}, WithRowType(tsType), WithParquetTimestampNanos(true))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.TimestampValue(time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC))}); err == nil {
+			t.Error("out-of-range nanosecond TIMESTAMP error = nil, want error")
+		}
+		var out bytes.Buffer
+		w = mustNewParquetWriter(t, &out, WithRowType(nil))
+		if err := w.WriteGCVs(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("zero-column output = %q, want empty", out.String())
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewParquetWriter(t, fw, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Errorf("second Flush error = %v, want errInjected", err)
+		}
+	})
+}
@@ -0,0 +1,551 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// PGCopyOption configures a PGCopyWriter created by [NewPGCopyWriter].
+type PGCopyOption interface {
+	applyPGCopyOption(*PGCopyWriter) error
+}
+
+type pgCopyOptionFunc func(*PGCopyWriter) error
+
+func (f pgCopyOptionFunc) applyPGCopyOption(w *PGCopyWriter) error {
+	return f(w)
+}
+
+func applyPGCopyOptions(w *PGCopyWriter, options ...PGCopyOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyPGCopyOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PGCopyFormat selects the COPY format written by [PGCopyWriter].
+type PGCopyFormat int
+
+const (
+	// PGCopyText writes the COPY text format (the default): tab-separated, \N
+	// for NULL, and backslash escapes for backslashes, tabs, and newlines.
+	PGCopyText PGCopyFormat = iota
+	// PGCopyCSV writes the COPY CSV format: comma-separated, an unquoted empty
+	// field for NULL, and double quotes around values that need them.
+	PGCopyCSV
+)
+
+// String returns the Go constant name for f, or "PGCopyFormat(n)" for unknown values.
+func (f PGCopyFormat) String() string {
+	switch f {
+	case PGCopyText:
+		return "PGCopyText"
+	case PGCopyCSV:
+		return "PGCopyCSV"
+	default:
+		return fmt.Sprintf("PGCopyFormat(%d)", int(f))
+	}
+}
+
+// WithPGCopyFormat selects the COPY format (default [PGCopyText]). Unknown
+// formats return [ErrInvalidPGCopyFormat].
+func WithPGCopyFormat(format PGCopyFormat) PGCopyOption {
+	return pgCopyOptionFunc(func(w *PGCopyWriter) error {
+		if format < PGCopyText || format > PGCopyCSV {
+			return fmt.Errorf("%w: %v", ErrInvalidPGCopyFormat, format)
+		}
+		w.format = format
+		return nil
+	})
+}
+
+// WithPGCopyDelimiter sets the field delimiter (default tab for [PGCopyText] and
+// comma for [PGCopyCSV]). PostgreSQL requires a single-byte character; NUL,
+// newlines, backslash, and double quote return [ErrInvalidDelimiter].
+func WithPGCopyDelimiter(delimiter rune) PGCopyOption {
+	return pgCopyOptionFunc(func(w *PGCopyWriter) error {
+		if delimiter <= 0 || delimiter >= utf8.RuneSelf ||
+			strings.ContainsRune("\r\n\\\"", delimiter) {
+			return fmt.Errorf("%w: %q", ErrInvalidDelimiter, delimiter)
+		}
+		w.delimiter = delimiter
+		return nil
+	})
+}
+
+// WithPGCopyNullString sets the text written for NULL, matching the NULL option
+// of COPY (default \N for [PGCopyText] and an empty string for [PGCopyCSV]).
+func WithPGCopyNullString(null string) PGCopyOption {
+	return pgCopyOptionFunc(func(w *PGCopyWriter) error {
+		w.null = &null
+		return nil
+	})
+}
+
+// WithPGCopyForceQuote sets whether [PGCopyCSV] quotes every non-NULL value, as
+// FORCE_QUOTE * does for COPY TO (default false: only values that need quotes).
+// The text format ignores it.
+func WithPGCopyForceQuote(forceQuote bool) PGCopyOption {
+	return pgCopyOptionFunc(func(w *PGCopyWriter) error {
+		w.forceQuote = forceQuote
+		return nil
+	})
+}
+
+// WithPGCopyHeader sets whether a line of column names precedes the rows,
+// matching the HEADER option of COPY (default false). The text format accepts
+// HEADER from PostgreSQL 15 on.
+func WithPGCopyHeader(header bool) PGCopyOption {
+	return pgCopyOptionFunc(func(w *PGCopyWriter) error {
+		w.header = header
+		return nil
+	})
+}
+
+// PGCopyWriter writes rows as input for PostgreSQL COPY ... FROM STDIN, for
+// moving PostgreSQL-dialect data to Cloud SQL, AlloyDB, or other PostgreSQL
+// servers. [PGCopyWriter.CopyStatement] returns the matching COPY command.
+// Values use PostgreSQL input syntax:
+//
+//   - BOOL: t or f
+//   - FLOAT32/FLOAT64: shortest round-trip digits, NaN, Infinity, or -Infinity
+//   - BYTES and PROTO: bytea hex, such as \x0102
+//   - TIMESTAMP: timestamptz text in UTC, such as 2024-01-02 03:04:05.123456+00;
+//     digits below microseconds, which PostgreSQL does not store, are truncated
+//   - ARRAY: array literals such as {1,2,"a b",NULL}
+//   - other scalars: the Spanner wire string (INT64, NUMERIC, DATE, STRING,
+//     JSON, UUID, and INTERVAL in ISO 8601 form)
+//
+// STRUCT values return [ErrUnsupportedPGCopyType]. The text format escapes
+// backslashes, the delimiter, and control characters with backslashes; the CSV
+// format quotes values containing the delimiter, quotes, or newlines, and values
+// equal to the NULL string, so they stay distinct from NULL.
+//
+// Output is buffered; call Flush after the final write. After the first output
+// write failure, every later Write*/Flush call returns that error; discard the
+// writer (see package doc "Write errors").
+type PGCopyWriter struct {
+	stickyWriteError
+	// unnamedFieldNamer resolves empty column names for the header and
+	// [PGCopyWriter.CopyStatement]. See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	format            PGCopyFormat
+	delimiter         rune
+	null              *string
+	forceQuote        bool
+	header            bool
+
+	schema      columnSchema
+	out         *bufio.Writer
+	wroteHeader bool
+	line        strings.Builder
+}
+
+// NewPGCopyWriter returns a PostgreSQL COPY writer configured by options.
+func NewPGCopyWriter(out io.Writer, options ...PGCopyOption) (*PGCopyWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &PGCopyWriter{
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		out:               bufio.NewWriter(out),
+	}
+	if err := applyPGCopyOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if w.delimiter == 0 {
+		w.delimiter = '\t'
+		if w.format == PGCopyCSV {
+			w.delimiter = ','
+		}
+	}
+	if w.null == nil {
+		null := `\N`
+		if w.format == PGCopyCSV {
+			null = ""
+		}
+		w.null = &null
+	}
+	return w, nil
+}
+
+// WriteRow writes one COPY line. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *PGCopyWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *PGCopyWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *PGCopyWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one COPY line; see [DelimitedWriter.WriteValues].
+func (w *PGCopyWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one COPY line; see [DelimitedWriter.WriteStructValues].
+func (w *PGCopyWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one COPY line; see [DelimitedWriter.WriteGCVs].
+func (w *PGCopyWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	w.line.Reset()
+	for i, v := range values {
+		if i > 0 {
+			w.line.WriteRune(w.delimiter)
+		}
+		if internal.IsNullGenericColumnValue(v) {
+			w.line.WriteString(*w.null)
+			continue
+		}
+		s, err := pgCopyValue(v)
+		if err != nil {
+			return fmt.Errorf("column %d (%q): %w", i, w.schema.names[i], err)
+		}
+		w.writeField(s)
+	}
+	w.line.WriteByte('\n')
+	if w.header && !w.wroteHeader {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	_, err := w.out.WriteString(w.line.String())
+	return w.latchWriteErr(err)
+}
+
+// Flush writes a pending header and flushes buffered lines to the underlying
+// writer; see [DelimitedWriter.Flush]. Flush does not close the underlying
+// writer, and writing may continue afterwards.
+func (w *PGCopyWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.header && !w.wroteHeader {
+		if !w.schema.registered {
+			return ErrMissingColumnNames
+		}
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return w.latchWriteErr(w.out.Flush())
+}
+
+// CopyStatement returns the COPY table (columns) FROM STDIN command whose
+// options match the writer's format, delimiter, NULL string, and header, with
+// identifiers quoted for PostgreSQL. Table may be schema-qualified with dots. It
+// requires a registered schema with at least one column.
+func (w *PGCopyWriter) CopyStatement(table string) (string, error) {
+	if table == "" {
+		return "", ErrEmptyTableName
+	}
+	if len(w.schema.names) == 0 {
+		return "", ErrMissingColumnNames
+	}
+	quotedTable, err := quoteQualifiedIdentifier(table, databasepb.DatabaseDialect_POSTGRESQL)
+	if err != nil {
+		return "", err
+	}
+	names, err := w.resolvedNames()
+	if err != nil {
+		return "", err
+	}
+	quotedColumns, err := quoteIdentifiers(names, databasepb.DatabaseDialect_POSTGRESQL)
+	if err != nil {
+		return "", err
+	}
+	opts := []string{"FORMAT text"}
+	defaultDelimiter, defaultNull := '\t', `\N`
+	if w.format == PGCopyCSV {
+		opts[0] = "FORMAT csv"
+		defaultDelimiter, defaultNull = ',', ""
+	}
+	if w.delimiter != defaultDelimiter {
+		opts = append(opts, "DELIMITER "+pgStringLiteral(string(w.delimiter)))
+	}
+	if *w.null != defaultNull {
+		opts = append(opts, "NULL "+pgStringLiteral(*w.null))
+	}
+	if w.header {
+		opts = append(opts, "HEADER true")
+	}
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (%s)",
+		quotedTable, strings.Join(quotedColumns, ", "), strings.Join(opts, ", ")), nil
+}
+
+func (w *PGCopyWriter) writeHeader() error {
+	names, err := w.resolvedNames()
+	if err != nil {
+		return err
+	}
+	line := w.line.String()
+	w.line.Reset()
+	for i, name := range names {
+		if i > 0 {
+			w.line.WriteRune(w.delimiter)
+		}
+		w.writeField(name)
+	}
+	w.line.WriteByte('\n')
+	header := w.line.String()
+	w.line.Reset()
+	w.line.WriteString(line)
+	if _, err := w.out.WriteString(header); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.wroteHeader = true
+	return nil
+}
+
+// writeField appends a non-NULL value to the current line, escaped for the text
+// format or quoted as needed for CSV.
+func (w *PGCopyWriter) writeField(s string) {
+	if w.format == PGCopyText {
+		for _, r := range s {
+			switch r {
+			case '\\':
+				w.line.WriteString(`\\`)
+			case '\n':
+				w.line.WriteString(`\n`)
+			case '\r':
+				w.line.WriteString(`\r`)
+			case '\t':
+				w.line.WriteString(`\t`)
+			case '\b':
+				w.line.WriteString(`\b`)
+			case '\f':
+				w.line.WriteString(`\f`)
+			case '\v':
+				w.line.WriteString(`\v`)
+			default:
+				if r == w.delimiter {
+					w.line.WriteByte('\\')
+				}
+				w.line.WriteRune(r)
+			}
+		}
+		return
+	}
+	if !w.forceQuote && s != *w.null && s != `\.` &&
+		!strings.ContainsAny(s, "\"\r\n") && !strings.ContainsRune(s, w.delimiter) {
+		w.line.WriteString(s)
+		return
+	}
+	w.line.WriteByte('"')
+	w.line.WriteString(strings.ReplaceAll(s, `"`, `""`))
+	w.line.WriteByte('"')
+}
+
+func (w *PGCopyWriter) resolvedNames() ([]string, error) {
+	return internal.ResolveColumnNames(w.schema.names, w.unnamedFieldNamer)
+}
+
+func (w *PGCopyWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *PGCopyWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *PGCopyWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// pgCopyValue renders a non-NULL value in PostgreSQL input syntax, before COPY
+// escaping or quoting.
+func pgCopyValue(v spanner.GenericColumnValue) (string, error) {
+	if v.Type.GetCode() != sppb.TypeCode_ARRAY {
+		return pgScalarText(v.Type, v.Value)
+	}
+	elemType := v.Type.GetArrayElementType()
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, elem := range v.Value.GetListValue().GetValues() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if _, ok := elem.GetKind().(*structpb.Value_NullValue); ok {
+			b.WriteString("NULL")
+			continue
+		}
+		s, err := pgScalarText(elemType, elem)
+		if err != nil {
+			return "", fmt.Errorf("element %d: %w", i, err)
+		}
+		b.WriteString(pgArrayElement(s))
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// pgArrayElement quotes an array element when PostgreSQL array input would
+// otherwise misread it.
+func pgArrayElement(s string) string {
+	if s != "" && !strings.EqualFold(s, "NULL") && !strings.ContainsAny(s, "{}\",\\ \t\n\r\v\f") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func pgScalarText(typ *sppb.Type, v *structpb.Value) (string, error) {
+	switch typ.GetCode() {
+	case sppb.TypeCode_BOOL:
+		if v.GetBoolValue() {
+			return "t", nil
+		}
+		return "f", nil
+	case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		f, err := internal.FloatFromWire(v)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case math.IsNaN(f):
+			return "NaN", nil
+		case math.IsInf(f, 1):
+			return "Infinity", nil
+		case math.IsInf(f, -1):
+			return "-Infinity", nil
+		}
+		bits := 64
+		if typ.GetCode() == sppb.TypeCode_FLOAT32 {
+			bits = 32
+		}
+		return strconv.FormatFloat(f, 'g', -1, bits), nil
+	case sppb.TypeCode_BYTES, sppb.TypeCode_PROTO:
+		b, err := internal.DecodeBase64Wire(v.GetStringValue())
+		if err != nil {
+			return "", err
+		}
+		return `\x` + hex.EncodeToString(b), nil
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		if err != nil {
+			return "", err
+		}
+		return t.UTC().Format("2006-01-02 15:04:05.999999") + "+00", nil
+	case sppb.TypeCode_INT64, sppb.TypeCode_ENUM, sppb.TypeCode_NUMERIC, sppb.TypeCode_STRING,
+		sppb.TypeCode_DATE, sppb.TypeCode_JSON, sppb.TypeCode_UUID, sppb.TypeCode_INTERVAL:
+		return v.GetStringValue(), nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedPGCopyType, typ.GetCode())
+	}
+}
+
+// pgStringLiteral quotes s as a PostgreSQL string constant, using the E'...' form
+// when s holds control characters.
+func pgStringLiteral(s string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { return r < 0x20 }) {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	var b strings.Builder
+	b.WriteString("E'")
+	for _, r := range s {
+		switch {
+		case r == '\'' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
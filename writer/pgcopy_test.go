@@ -0,0 +1,259 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*PGCopyWriter)(nil)
+
+func pgCopyTestRowType() *sppb.StructType {
+	return typector.MustNameTypeSlicesToStructType(
+		[]string{"id", "name", "ok", "f", "by", "ts", "arr", "n"},
+		[]*sppb.Type{
+			typector.Int64(), typector.String(), typector.Bool(), typector.Float64(),
+			typector.Bytes(), typector.Timestamp(), typector.ElemCodeToArrayType(sppb.TypeCode_STRING),
+			typector.Numeric(),
+		},
+	).GetStructType()
+}
+
+func pgCopyTestRows(rowType *sppb.StructType) [][]spanner.GenericColumnValue {
+	nulls := make([]spanner.GenericColumnValue, len(rowType.GetFields()))
+	for i, f := range rowType.GetFields() {
+		nulls[i] = gcvctor.NullOf(f.GetType())
+	}
+	return [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(1), gcvctor.StringValue("a\tb\\c\nd"), gcvctor.BoolValue(true),
+			gcvctor.Float64Value(math.Inf(-1)), gcvctor.BytesValue([]byte{0x01, 0xab}),
+			gcvctor.TimestampValue(time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)),
+			gcvctor.MustArrayValue(
+				gcvctor.StringValue("x"), gcvctor.StringValue("a b"), gcvctor.NullFromCode(sppb.TypeCode_STRING),
+				gcvctor.StringValue(""), gcvctor.StringValue("null"), gcvctor.StringValue(`q"\`),
+			),
+			gcvctor.NumericValue(big.NewRat(3, 2)),
+		},
+		nulls,
+	}
+}
+
+func TestPGCopyWriter_text(t *testing.T) {
+	t.Parallel()
+
+	rowType := pgCopyTestRowType()
+	var out bytes.Buffer
+	w := mustNewPGCopyWriter(t, &out, WithRowType(rowType), WithPGCopyHeader(true))
+	for _, row := range pgCopyTestRows(rowType) {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{"id", "name", "ok", "f", "by", "ts", "arr", "n"}, "\t") + "\n" +
+		strings.Join([]string{
+			"1", `a\tb\\c\nd`, "t", "-Infinity", `\\x01ab`, "2024-01-02 03:04:05.123456+00",
+			`{x,"a b",NULL,"","null","q\\"\\\\"}`, "1.500000000",
+		}, "\t") + "\n" +
+		strings.Repeat(`\N`+"\t", 7) + `\N` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("output =\n%q\nwant\n%q", got, want)
+	}
+
+	stmt, err := w.CopyStatement("public.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStmt := `COPY "public"."items" ("id", "name", "ok", "f", "by", "ts", "arr", "n") FROM STDIN WITH (FORMAT text, HEADER true)`
+	if stmt != wantStmt {
+		t.Errorf("CopyStatement = %q, want %q", stmt, wantStmt)
+	}
+}
+
+func TestPGCopyWriter_csv(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("a,b")},
+		{gcvctor.Int64Value(2), gcvctor.StringValue("")},
+		{gcvctor.Int64Value(3), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+		{gcvctor.Int64Value(4), gcvctor.StringValue(`\.`)},
+		{gcvctor.Int64Value(5), gcvctor.StringValue("say \"hi\"\n")},
+	}
+	tests := []struct {
+		name     string
+		opts     []PGCopyOption
+		want     string
+		wantStmt string
+	}{
+		{
+			"default",
+			nil,
+			"1,\"a,b\"\n2,\"\"\n3,\n4,\"\\.\"\n5,\"say \"\"hi\"\"\n\"\n",
+			`COPY "t" ("id", "name") FROM STDIN WITH (FORMAT csv)`,
+		},
+		{
+			"force quote and NULL string",
+			[]PGCopyOption{WithPGCopyForceQuote(true), WithPGCopyNullString("NULL"), WithPGCopyHeader(true)},
+			"\"id\",\"name\"\n\"1\",\"a,b\"\n\"2\",\"\"\n\"3\",NULL\n\"4\",\"\\.\"\n\"5\",\"say \"\"hi\"\"\n\"\n",
+			`COPY "t" ("id", "name") FROM STDIN WITH (FORMAT csv, NULL 'NULL', HEADER true)`,
+		},
+		{
+			"tab delimiter",
+			[]PGCopyOption{WithPGCopyDelimiter('\t')},
+			"1\ta,b\n2\t\"\"\n3\t\n4\t\"\\.\"\n5\t\"say \"\"hi\"\"\n\"\n",
+			`COPY "t" ("id", "name") FROM STDIN WITH (FORMAT csv, DELIMITER E'\t')`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			opts := append([]PGCopyOption{WithRowType(tableTestRowType()), WithPGCopyFormat(PGCopyCSV)}, tt.opts...)
+			w := mustNewPGCopyWriter(t, &out, opts...)
+			for _, row := range rows {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			stmt, err := w.CopyStatement("t")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stmt != tt.wantStmt {
+				t.Errorf("CopyStatement = %q, want %q", stmt, tt.wantStmt)
+			}
+		})
+	}
+}
+
+func TestPGCopyWriter_rowIterator(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	md := &sppb.ResultSetMetadata{RowType: typector.MustNameCodeSlicesToStructType(
+		[]string{"id", ""},
+		[]sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_STRING},
+	).GetStructType()}
+	rows := RowSeq(
+		mustNewSpannerRow(t, []string{"id", ""}, []any{int64(1), "x"}),
+		mustNewSpannerRow(t, []string{"id", ""}, []any{int64(2), "y z"}),
+	)
+	if _, err := WriteRowSeq(md, rows, mustNewPGCopyWriter(t, &out, WithPGCopyHeader(true))); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "id\t_0\n1\tx\n2\ty z\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPGCopyWriter_headerOnFlush(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewPGCopyWriter(t, &out, WithPGCopyHeader(true))
+	if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Fatalf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+	if err := w.PrepareColumnNames([]string{"a", "b\tc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "a\tb\\tc\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPGCopyWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+		point := gcvctor.MustStructValueOf([]string{"x"}, []spanner.GenericColumnValue{gcvctor.Int64Value(1)})
+		w := mustNewPGCopyWriter(t, &bytes.Buffer{}, WithColumnNames([]string{"p"}))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{point}); !errors.Is(err, ErrUnsupportedPGCopyType) {
+			t.Errorf("WriteGCVs(STRUCT) error = %v, want ErrUnsupportedPGCopyType", err)
+		}
+		arr := gcvctor.MustArrayValue(point)
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{arr}); !errors.Is(err, ErrUnsupportedPGCopyType) {
+			t.Errorf("WriteGCVs(ARRAY<STRUCT>) error = %v, want ErrUnsupportedPGCopyType", err)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		for _, tt := range []struct {
+			name string
+			opt  PGCopyOption
+			want error
+		}{
+			{"format", WithPGCopyFormat(PGCopyFormat(2)), ErrInvalidPGCopyFormat},
+			{"backslash delimiter", WithPGCopyDelimiter('\\'), ErrInvalidDelimiter},
+			{"multibyte delimiter", WithPGCopyDelimiter('│'), ErrInvalidDelimiter},
+		} {
+			if _, err := NewPGCopyWriter(&bytes.Buffer{}, tt.opt); !errors.Is(err, tt.want) {
+				t.Errorf("%s: NewPGCopyWriter error = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+		if _, err := NewPGCopyWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+			t.Errorf("NewPGCopyWriter(nil) error = %v, want ErrNilOutputWriter", err)
+		}
+	})
+
+	t.Run("copy statement", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewPGCopyWriter(t, &bytes.Buffer{})
+		if _, err := w.CopyStatement("t"); !errors.Is(err, ErrMissingColumnNames) {
+			t.Errorf("CopyStatement without schema error = %v, want ErrMissingColumnNames", err)
+		}
+		if _, err := w.CopyStatement(""); !errors.Is(err, ErrEmptyTableName) {
+			t.Errorf("CopyStatement(\"\") error = %v, want ErrEmptyTableName", err)
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewPGCopyWriter(t, fw, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+	})
+}
+
+func TestPGCopyFormat_String(t *testing.T) {
+	t.Parallel()
+
+	for f, want := range map[PGCopyFormat]string{PGCopyText: "PGCopyText", PGCopyCSV: "PGCopyCSV", 7: "PGCopyFormat(7)"} {
+		if got := f.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(f), got, want)
+		}
+	}
+}
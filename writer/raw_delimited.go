@@ -0,0 +1,410 @@
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// RawDelimitedOption configures a RawDelimitedWriter created by [NewRawDelimitedWriter]
+// or [NewRawTSVWriter].
+type RawDelimitedOption interface {
+	applyRawDelimitedOption(*RawDelimitedWriter) error
+}
+
+type rawDelimitedOptionFunc func(*RawDelimitedWriter) error
+
+func (f rawDelimitedOptionFunc) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	return f(w)
+}
+
+func applyRawDelimitedOptions(w *RawDelimitedWriter, options ...RawDelimitedOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyRawDelimitedOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RawEscapePolicy selects how [RawDelimitedWriter] handles special characters in a
+// field: the delimiter, CR, LF, and any character of the line terminator.
+type RawEscapePolicy int
+
+const (
+	// RawEscapeBackslash prefixes special characters with a backslash, as MySQL
+	// LOAD DATA and Hive ESCAPED BY '\\' expect (the default). LF, CR, and tab
+	// become \n, \r, and \t, and backslashes are doubled.
+	RawEscapeBackslash RawEscapePolicy = iota
+	// RawEscapeReplace replaces each special character with the
+	// [WithRawReplacement] string. Backslashes are kept.
+	RawEscapeReplace
+	// RawEscapeReject fails the row with [ErrRawDelimitedSpecialChar].
+	RawEscapeReject
+	// RawEscapeNone writes fields unchanged; output is only parseable when values
+	// are known not to contain special characters.
+	RawEscapeNone
+)
+
+// String returns the Go constant name for p, or "RawEscapePolicy(n)" for unknown values.
+func (p RawEscapePolicy) String() string {
+	switch p {
+	case RawEscapeBackslash:
+		return "RawEscapeBackslash"
+	case RawEscapeReplace:
+		return "RawEscapeReplace"
+	case RawEscapeReject:
+		return "RawEscapeReject"
+	case RawEscapeNone:
+		return "RawEscapeNone"
+	default:
+		return fmt.Sprintf("RawEscapePolicy(%d)", int(p))
+	}
+}
+
+// WithRawEscapePolicy selects how special characters in fields are handled (default
+// [RawEscapeBackslash]). Unknown policies return [ErrInvalidRawEscapePolicy].
+func WithRawEscapePolicy(policy RawEscapePolicy) RawDelimitedOption {
+	return rawDelimitedOptionFunc(func(w *RawDelimitedWriter) error {
+		if policy < RawEscapeBackslash || policy > RawEscapeNone {
+			return fmt.Errorf("%w: %v", ErrInvalidRawEscapePolicy, policy)
+		}
+		w.escape = policy
+		return nil
+	})
+}
+
+// WithRawReplacement sets the string [RawEscapeReplace] writes for each special
+// character (default a single space). [NewRawDelimitedWriter] rejects a replacement
+// that contains a special character with [ErrInvalidRawReplacement].
+func WithRawReplacement(replacement string) RawDelimitedOption {
+	return rawDelimitedOptionFunc(func(w *RawDelimitedWriter) error {
+		w.replacement = replacement
+		return nil
+	})
+}
+
+// WithRawLineTerminator sets the string written after each line (default "\n"),
+// for example "\r\n". It must be non-empty valid UTF-8 without the delimiter;
+// otherwise [NewRawDelimitedWriter] returns [ErrInvalidLineTerminator].
+func WithRawLineTerminator(terminator string) RawDelimitedOption {
+	return rawDelimitedOptionFunc(func(w *RawDelimitedWriter) error {
+		w.terminator = terminator
+		return nil
+	})
+}
+
+// WithRawNullString sets the token written verbatim for NULL values, such as \N for
+// MySQL LOAD DATA and Hive, or an empty string. Without it, NULL cells use the
+// formatter's NULL text and are escaped like other values.
+func WithRawNullString(null string) RawDelimitedOption {
+	return rawDelimitedOptionFunc(func(w *RawDelimitedWriter) error {
+		w.null = &null
+		return nil
+	})
+}
+
+// WithRawHeader sets whether [RawDelimitedWriter] writes a header line of column names
+// (default true). The header is written before the first data row, or on
+// [RawDelimitedWriter.Flush] if only names were registered.
+func WithRawHeader(header bool) RawDelimitedOption {
+	return rawDelimitedOptionFunc(func(w *RawDelimitedWriter) error {
+		w.header = header
+		return nil
+	})
+}
+
+// RawDelimitedWriter writes rows as unquoted delimited text: formatted fields joined by
+// the delimiter, for loaders that do not understand CSV quoting (BigQuery with quoting
+// disabled, Hive, MySQL LOAD DATA, cut and awk pipelines). Special characters in fields
+// and header names are handled by the [RawEscapePolicy] from [WithRawEscapePolicy].
+//
+// Output is buffered; call Flush after the final write. Configuration is
+// constructor-only. After the first output write failure, every later Write*/Flush
+// call returns that error; discard the writer (see package doc "Write errors").
+type RawDelimitedWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for the header.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	delimiter         rune
+	escape            RawEscapePolicy
+	replacement       string
+	terminator        string
+	null              *string
+	header            bool
+
+	schema      columnSchema
+	out         *bufio.Writer
+	wroteHeader bool
+	line        strings.Builder
+}
+
+// NewRawTSVWriter returns a tab-delimited raw writer configured by options.
+// It is a thin helper for NewRawDelimitedWriter(out, '\t', opts...).
+func NewRawTSVWriter(out io.Writer, opts ...RawDelimitedOption) (*RawDelimitedWriter, error) {
+	return NewRawDelimitedWriter(out, '\t', opts...)
+}
+
+// NewRawDelimitedWriter returns a raw delimited writer using delimiter as the field
+// delimiter and configured by options. Delimiter must be a valid non-zero rune other
+// than CR and LF, and not a backslash under [RawEscapeBackslash]; otherwise it returns
+// [ErrInvalidDelimiter].
+func NewRawDelimitedWriter(out io.Writer, delimiter rune, options ...RawDelimitedOption) (*RawDelimitedWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &RawDelimitedWriter{
+		formatter:         spanvalue.SimpleFormatConfig(),
+		unnamedFieldNamer: spanvalue.IndexedUnnamedFieldNamer,
+		delimiter:         delimiter,
+		replacement:       " ",
+		terminator:        "\n",
+		header:            true,
+		out:               bufio.NewWriter(out),
+	}
+	if err := applyRawDelimitedOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if delimiter == 0 || delimiter == '\r' || delimiter == '\n' ||
+		!utf8.ValidRune(delimiter) || delimiter == utf8.RuneError ||
+		(delimiter == '\\' && w.escape == RawEscapeBackslash) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDelimiter, delimiter)
+	}
+	if w.terminator == "" || !utf8.ValidString(w.terminator) || strings.ContainsRune(w.terminator, delimiter) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLineTerminator, w.terminator)
+	}
+	if w.escape == RawEscapeReplace && strings.ContainsFunc(w.replacement, w.special) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRawReplacement, w.replacement)
+	}
+	return w, nil
+}
+
+// WriteRow writes one line. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *RawDelimitedWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *RawDelimitedWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *RawDelimitedWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one line; see [DelimitedWriter.WriteValues].
+func (w *RawDelimitedWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one line; see [DelimitedWriter.WriteStructValues].
+func (w *RawDelimitedWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one line; see [DelimitedWriter.WriteGCVs]. Under [RawEscapeReject],
+// a field with a special character returns [ErrRawDelimitedSpecialChar] and nothing
+// is written for the row.
+func (w *RawDelimitedWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	formattedValues, err := spanvalue.FormatRowColumns(w.formatter, w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	w.line.Reset()
+	for i, s := range formattedValues {
+		if i > 0 {
+			w.line.WriteRune(w.delimiter)
+		}
+		if w.null != nil && internal.IsNullGenericColumnValue(values[i]) {
+			w.line.WriteString(*w.null)
+			continue
+		}
+		if err := w.writeField(s); err != nil {
+			return fmt.Errorf("column %d (%q): %w", i, w.schema.names[i], err)
+		}
+	}
+	w.line.WriteString(w.terminator)
+	if w.header && !w.wroteHeader {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	_, err = w.out.WriteString(w.line.String())
+	return w.latchWriteErr(err)
+}
+
+// Flush writes a pending header and flushes buffered lines to the underlying writer;
+// see [DelimitedWriter.Flush]. Flush does not close the underlying writer, and
+// writing may continue afterwards.
+func (w *RawDelimitedWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.header && !w.wroteHeader {
+		if !w.schema.registered {
+			return ErrMissingColumnNames
+		}
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return w.latchWriteErr(w.out.Flush())
+}
+
+// FormatConfig returns the effective formatter used for fields.
+// When no formatter is configured, this returns [spanvalue.SimpleFormatConfig].
+func (w *RawDelimitedWriter) FormatConfig() *spanvalue.FormatConfig {
+	return w.formatter
+}
+
+// writeHeader writes the header line ahead of the pending data line, if any.
+func (w *RawDelimitedWriter) writeHeader() error {
+	if len(w.schema.names) == 0 {
+		w.wroteHeader = true
+		return nil
+	}
+	names, err := internal.ResolveColumnNames(w.schema.names, w.unnamedFieldNamer)
+	if err != nil {
+		return err
+	}
+	line := w.line.String()
+	w.line.Reset()
+	for i, name := range names {
+		if i > 0 {
+			w.line.WriteRune(w.delimiter)
+		}
+		if err := w.writeField(name); err != nil {
+			return fmt.Errorf("header column %d (%q): %w", i, name, err)
+		}
+	}
+	w.line.WriteString(w.terminator)
+	header := w.line.String()
+	w.line.Reset()
+	w.line.WriteString(line)
+	if _, err := w.out.WriteString(header); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.wroteHeader = true
+	return nil
+}
+
+// writeField appends s to the current line under the escape policy.
+func (w *RawDelimitedWriter) writeField(s string) error {
+	if w.escape == RawEscapeNone || (!strings.ContainsFunc(s, w.special) &&
+		(w.escape != RawEscapeBackslash || !strings.Contains(s, `\`))) {
+		w.line.WriteString(s)
+		return nil
+	}
+	if w.escape == RawEscapeReject {
+		i := strings.IndexFunc(s, w.special)
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		return fmt.Errorf("%w: %q", ErrRawDelimitedSpecialChar, r)
+	}
+	for _, r := range s {
+		switch {
+		case w.escape == RawEscapeReplace:
+			if w.special(r) {
+				w.line.WriteString(w.replacement)
+			} else {
+				w.line.WriteRune(r)
+			}
+		case r == '\n':
+			w.line.WriteString(`\n`)
+		case r == '\r':
+			w.line.WriteString(`\r`)
+		case r == '\t' && w.special(r):
+			w.line.WriteString(`\t`)
+		case r == '\\' || w.special(r):
+			w.line.WriteByte('\\')
+			w.line.WriteRune(r)
+		default:
+			w.line.WriteRune(r)
+		}
+	}
+	return nil
+}
+
+// special reports whether r would split a field or a line.
+func (w *RawDelimitedWriter) special(r rune) bool {
+	return r == w.delimiter || r == '\n' || r == '\r' || strings.ContainsRune(w.terminator, r)
+}
+
+func (w *RawDelimitedWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *RawDelimitedWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *RawDelimitedWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*RawDelimitedWriter)(nil)
+
+func TestRawDelimitedWriter_escapePolicies(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("a\tb\\c\nd")},
+		{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+	}
+	tests := []struct {
+		name string
+		opts []RawDelimitedOption
+		want string
+	}{
+		{"backslash", nil, "id\tname\n1\ta\\tb\\\\c\\nd\n2\t<null>\n"},
+		{"backslash with NULL token", []RawDelimitedOption{WithRawNullString(`\N`)}, "id\tname\n1\ta\\tb\\\\c\\nd\n2\t\\N\n"},
+		{"replace", []RawDelimitedOption{WithRawEscapePolicy(RawEscapeReplace)}, "id\tname\n1\ta b\\c d\n2\t<null>\n"},
+		{"custom replacement", []RawDelimitedOption{WithRawEscapePolicy(RawEscapeReplace), WithRawReplacement("")}, "id\tname\n1\tab\\cd\n2\t<null>\n"},
+		{"none", []RawDelimitedOption{WithRawEscapePolicy(RawEscapeNone), WithRawHeader(false), WithRawNullString("")}, "1\ta\tb\\c\nd\n2\t\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewRawDelimitedWriter(t, &out, '\t', append([]RawDelimitedOption{WithRowType(tableTestRowType())}, tt.opts...)...)
+			for _, row := range rows {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawDelimitedWriter_reject(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewRawDelimitedWriter(t, &out, ',', WithRowType(tableTestRowType()), WithRawEscapePolicy(RawEscapeReject))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue(`a\b`)}); err != nil {
+		t.Fatal(err)
+	}
+	err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("a,b")})
+	if !errors.Is(err, ErrRawDelimitedSpecialChar) {
+		t.Fatalf("WriteGCVs error = %v, want ErrRawDelimitedSpecialChar", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "id,name\n1,a\\b\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRawDelimitedWriter_lineTerminator(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewRawDelimitedWriter(t, &out, ',',
+		WithColumnNames([]string{"a,b", ""}),
+		WithRawLineTerminator("\r\n"),
+		WithFormatter(spanvalue.SpannerCLICompatibleFormatConfig()),
+	)
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.StringValue("x,y\r"), gcvctor.BytesValue([]byte("hi"))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "a\\,b,_0\r\nx\\,y\\r,aGk=\r\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRawDelimitedWriter_rowIterator(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w, err := NewRawTSVWriter(&out, WithRawNullString(`\N`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"id", "name"}
+	md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+	rows := RowSeq(
+		mustNewSpannerRow(t, names, []any{int64(1), "a"}),
+		mustNewSpannerRow(t, names, []any{int64(2), spanner.NullString{}}),
+	)
+	if _, err := WriteRowSeq(md, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "id\tname\n1\ta\n2\t\\N\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRawDelimitedWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		delimiter rune
+		opts      []RawDelimitedOption
+		want      error
+	}{
+		{"zero delimiter", 0, nil, ErrInvalidDelimiter},
+		{"newline delimiter", '\n', nil, ErrInvalidDelimiter},
+		{"backslash delimiter", '\\', nil, ErrInvalidDelimiter},
+		{"escape policy", '\t', []RawDelimitedOption{WithRawEscapePolicy(RawEscapePolicy(4))}, ErrInvalidRawEscapePolicy},
+		{"empty terminator", '\t', []RawDelimitedOption{WithRawLineTerminator("")}, ErrInvalidLineTerminator},
+		{"terminator with delimiter", ';', []RawDelimitedOption{WithRawLineTerminator(";\n")}, ErrInvalidLineTerminator},
+		{"replacement", '\t', []RawDelimitedOption{WithRawEscapePolicy(RawEscapeReplace), WithRawReplacement("\t")}, ErrInvalidRawReplacement},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewRawDelimitedWriter(&bytes.Buffer{}, tt.delimiter, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewRawDelimitedWriter error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewRawDelimitedWriter(&bytes.Buffer{}, '\\', WithRawEscapePolicy(RawEscapeReplace)); err != nil {
+		t.Errorf("backslash delimiter without backslash escaping: %v", err)
+	}
+	if _, err := NewRawTSVWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewRawTSVWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	w := mustNewRawDelimitedWriter(t, &bytes.Buffer{}, '\t')
+	if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewRawDelimitedWriter(t, fw, '\t', WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+	})
+}
+
+func TestRawEscapePolicy_String(t *testing.T) {
+	t.Parallel()
+
+	for p, want := range map[RawEscapePolicy]string{
+		RawEscapeBackslash: "RawEscapeBackslash",
+		RawEscapeReplace:   "RawEscapeReplace",
+		RawEscapeReject:    "RawEscapeReject",
+		RawEscapeNone:      "RawEscapeNone",
+		9:                  "RawEscapePolicy(9)",
+	} {
+		if got := p.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(p), got, want)
+		}
+	}
+}
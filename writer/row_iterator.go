@@ -132,16 +132,23 @@ func (h RowIteratorHooks) OnRunStart(fn func()) RowIteratorHooks {
 }
 
 // RowIteratorWriter streams rows from a [cloud.google.com/go/spanner.RowIterator]
-// through [WriteRowIterator]. [DelimitedWriter], [JSONLWriter], and
-// [SQLInsertWriter] implement it.
+// through [WriteRowIterator]. Every writer in this package implements it.
 type RowIteratorWriter interface {
 	FlushWriter
 	PrepareRowType(*sppb.StructType) error
 }
 
+// ResultFinisher is implemented by writers whose output records run results,
+// such as [JSONWriter] with [WithJSONEnvelope]. FinishResult finalizes output
+// like [Flusher.Flush] and receives the [RowIteratorResult] of the run.
+type ResultFinisher interface {
+	FinishResult(*RowIteratorResult) error
+}
+
 // RowIteratorHooksFromWriter returns hooks that register metadata via
 // [RowIteratorWriter.PrepareRowType], write each row, and call [Flusher.Flush]
-// in Finish. Flush is not called when PrepareRowType or WriteRow returns an error.
+// in Finish, or [ResultFinisher.FinishResult] when w implements it. Neither
+// is called when PrepareRowType or WriteRow returns an error.
 // A nil writer returns empty hooks.
 func RowIteratorHooksFromWriter(w RowIteratorWriter) RowIteratorHooks {
 	if w == nil {
@@ -152,7 +159,10 @@ func RowIteratorHooksFromWriter(w RowIteratorWriter) RowIteratorHooks {
 			return w.PrepareRowType(rowTypeFromMetadata(md))
 		}).
 		WithWriteRow(w.WriteRow).
-		WithFinish(func(*RowIteratorResult) error {
+		WithFinish(func(result *RowIteratorResult) error {
+			if f, ok := w.(ResultFinisher); ok {
+				return f.FinishResult(result)
+			}
 			return w.Flush()
 		})
 }
@@ -0,0 +1,145 @@
+package writer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+type sqlColumnOverrideKind int
+
+const (
+	sqlColumnLiteral sqlColumnOverrideKind = iota
+	sqlColumnOmit
+	sqlColumnExpression
+	sqlColumnTransform
+	sqlColumnCommitTimestamp
+)
+
+// SQLColumnOverride changes how [SQLInsertWriter] writes one column; register it with
+// [WithSQLColumnOverride]. The zero value writes the formatted literal unchanged.
+type SQLColumnOverride struct {
+	kind      sqlColumnOverrideKind
+	expr      string
+	transform func(literal string, value spanner.GenericColumnValue) (string, error)
+}
+
+// SQLOmitColumn leaves the column out of the column list and VALUES, for generated
+// columns and others the database fills in.
+func SQLOmitColumn() SQLColumnOverride {
+	return SQLColumnOverride{kind: sqlColumnOmit}
+}
+
+// SQLColumnExpression writes expr verbatim in place of every value of the column.
+func SQLColumnExpression(expr string) SQLColumnOverride {
+	return SQLColumnOverride{kind: sqlColumnExpression, expr: expr}
+}
+
+// SQLDefaultColumn writes DEFAULT, so the column takes its DEFAULT expression.
+func SQLDefaultColumn() SQLColumnOverride {
+	return SQLColumnExpression("DEFAULT")
+}
+
+// SQLPendingCommitTimestamp writes PENDING_COMMIT_TIMESTAMP() for GoogleSQL or
+// SPANNER.PENDING_COMMIT_TIMESTAMP() for PostgreSQL ([WithSQLDialect]), for columns
+// with allow_commit_timestamp.
+func SQLPendingCommitTimestamp() SQLColumnOverride {
+	return SQLColumnOverride{kind: sqlColumnCommitTimestamp}
+}
+
+// SQLColumnTransform rewrites each formatted literal with fn, which also receives the
+// original value; for example, wrapping the literal in a function call. An error from
+// fn is returned by the Write* call.
+func SQLColumnTransform(fn func(literal string, value spanner.GenericColumnValue) (string, error)) SQLColumnOverride {
+	return SQLColumnOverride{kind: sqlColumnTransform, transform: fn}
+}
+
+// WithSQLColumnOverride registers override for the named column of a [SQLInsertWriter];
+// repeat it for several columns, and a later override for the same column replaces an
+// earlier one. An empty name returns [ErrEmptyColumnName], and a [SQLColumnTransform] with a
+// nil function returns [ErrInvalidColumnOverride]. Names missing from the registered schema
+// return [ErrInvalidColumnOverride] at construction, Prepare*, or the first write, as does
+// omitting every column or a [WithSQLConflictTarget] column.
+func WithSQLColumnOverride(column string, override SQLColumnOverride) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		if column == "" {
+			return ErrEmptyColumnName
+		}
+		if override.kind == sqlColumnTransform && override.transform == nil {
+			return fmt.Errorf("%w: nil transform for %q", ErrInvalidColumnOverride, column)
+		}
+		if w.columnOverrides == nil {
+			w.columnOverrides = make(map[string]SQLColumnOverride)
+		}
+		w.columnOverrides[column] = override
+		return nil
+	})
+}
+
+// omitsColumn reports whether name is left out of INSERT output.
+func (w *SQLInsertWriter) omitsColumn(name string) bool {
+	return w.columnOverrides[name].kind == sqlColumnOmit
+}
+
+// insertColumnList validates the column overrides against names and returns the
+// quoted, comma-separated list of the columns written.
+func (w *SQLInsertWriter) insertColumnList(names []string) (string, error) {
+	for column := range w.columnOverrides {
+		if !slices.Contains(names, column) {
+			return "", fmt.Errorf("%w: column %q not in schema", ErrInvalidColumnOverride, column)
+		}
+	}
+	for _, column := range w.conflictTarget {
+		if w.omitsColumn(column) {
+			return "", fmt.Errorf("%w: conflict target %q omitted", ErrInvalidColumnOverride, column)
+		}
+	}
+	written := make([]string, 0, len(names))
+	for _, name := range names {
+		if !w.omitsColumn(name) {
+			written = append(written, name)
+		}
+	}
+	if len(written) == 0 && len(names) > 0 {
+		return "", fmt.Errorf("%w: every column omitted", ErrInvalidColumnOverride)
+	}
+	quotedColumns, err := quoteIdentifiers(written, w.sqlDialect)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(quotedColumns, ", "), nil
+}
+
+// applyColumnOverrides returns the value expressions written for one row, dropping
+// omitted columns.
+func (w *SQLInsertWriter) applyColumnOverrides(formattedValues []string, values []spanner.GenericColumnValue) ([]string, error) {
+	if len(w.columnOverrides) == 0 {
+		return formattedValues, nil
+	}
+	written := make([]string, 0, len(formattedValues))
+	for i, literal := range formattedValues {
+		override := w.columnOverrides[w.schema.names[i]]
+		switch override.kind {
+		case sqlColumnOmit:
+			continue
+		case sqlColumnExpression:
+			literal = override.expr
+		case sqlColumnCommitTimestamp:
+			literal = "PENDING_COMMIT_TIMESTAMP()"
+			if w.sqlDialect == databasepb.DatabaseDialect_POSTGRESQL {
+				literal = "SPANNER.PENDING_COMMIT_TIMESTAMP()"
+			}
+		case sqlColumnTransform:
+			var err error
+			literal, err = override.transform(literal, values[i])
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", w.schema.names[i], err)
+			}
+		}
+		written = append(written, literal)
+	}
+	return written, nil
+}
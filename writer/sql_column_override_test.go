@@ -0,0 +1,123 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+func TestSQLInsertWriterColumnOverrides(t *testing.T) {
+	t.Parallel()
+
+	columnNames := []string{"id", "name", "updated", "search", "status"}
+	values := []spanner.GenericColumnValue{
+		gcvctor.Int64Value(1),
+		gcvctor.StringValue("a"),
+		gcvctor.StringValue("2024-01-02T03:04:05Z"),
+		gcvctor.StringValue("tokens"),
+		gcvctor.StringValue("active"),
+	}
+	upper := SQLColumnTransform(func(literal string, _ spanner.GenericColumnValue) (string, error) {
+		return "UPPER(" + literal + ")", nil
+	})
+	overrides := []SQLInsertOption{
+		WithSQLColumnOverride("name", upper),
+		WithSQLColumnOverride("updated", SQLPendingCommitTimestamp()),
+		WithSQLColumnOverride("search", SQLOmitColumn()),
+		WithSQLColumnOverride("status", SQLDefaultColumn()),
+	}
+
+	tests := []struct {
+		name    string
+		options []SQLInsertOption
+		want    string
+	}{
+		{
+			name:    "GoogleSQL",
+			options: overrides,
+			want:    "INSERT INTO `users` (`id`, `name`, `updated`, `status`) VALUES (1, UPPER(\"a\"), PENDING_COMMIT_TIMESTAMP(), DEFAULT);\n",
+		},
+		{
+			name: "PostgreSQL upsert",
+			options: append([]SQLInsertOption{
+				WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL),
+				WithSQLInsertKind(SQLInsertOrUpdate),
+				WithSQLConflictTarget("id"),
+			}, overrides...),
+			want: `INSERT INTO "users" ("id", "name", "updated", "status") VALUES (1, UPPER("a"), SPANNER.PENDING_COMMIT_TIMESTAMP(), DEFAULT)` +
+				` ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "updated" = EXCLUDED."updated", "status" = EXCLUDED."status";` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w := mustNewSQLInsertWriter(t, &out, "users", tt.options...)
+			if err := w.WriteValues(columnNames, values); err != nil {
+				t.Fatalf("WriteValues() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Fatalf("SQL output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLInsertWriterColumnOverrideErrors(t *testing.T) {
+	t.Parallel()
+
+	schema := WithColumnNames([]string{"id", "name"})
+	for _, tt := range []struct {
+		name    string
+		options []SQLInsertOption
+		want    error
+	}{
+		{"empty name", []SQLInsertOption{WithSQLColumnOverride("", SQLOmitColumn())}, ErrEmptyColumnName},
+		{"nil transform", []SQLInsertOption{WithSQLColumnOverride("id", SQLColumnTransform(nil))}, ErrInvalidColumnOverride},
+		{"unknown column", []SQLInsertOption{schema, WithSQLColumnOverride("pk", SQLDefaultColumn())}, ErrInvalidColumnOverride},
+		{"all omitted", []SQLInsertOption{
+			schema, WithSQLColumnOverride("id", SQLOmitColumn()), WithSQLColumnOverride("name", SQLOmitColumn()),
+		}, ErrInvalidColumnOverride},
+		{"conflict target omitted", []SQLInsertOption{
+			schema, WithSQLInsertKind(SQLInsertOrIgnore), WithSQLConflictTarget("id"), WithSQLColumnOverride("id", SQLOmitColumn()),
+		}, ErrInvalidColumnOverride},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewSQLInsertWriter(&bytes.Buffer{}, "users", tt.options...); !errors.Is(err, tt.want) {
+				t.Fatalf("NewSQLInsertWriter() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown column on first write", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewSQLInsertWriter(t, &bytes.Buffer{}, "users", WithSQLColumnOverride("pk", SQLOmitColumn()))
+		err := w.WriteValues([]string{"id"}, []spanner.GenericColumnValue{gcvctor.Int64Value(1)})
+		if !errors.Is(err, ErrInvalidColumnOverride) {
+			t.Fatalf("WriteValues() error = %v, want ErrInvalidColumnOverride", err)
+		}
+	})
+
+	t.Run("transform error", func(t *testing.T) {
+		t.Parallel()
+		var out bytes.Buffer
+		w := mustNewSQLInsertWriter(t, &out, "users", schema, WithSQLColumnOverride("name",
+			SQLColumnTransform(func(string, spanner.GenericColumnValue) (string, error) { return "", errInjected })))
+		err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")})
+		if !errors.Is(err, errInjected) {
+			t.Fatalf("WriteGCVs() error = %v, want errInjected", err)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("WriteGCVs() wrote %q, want no output", out.String())
+		}
+	})
+}
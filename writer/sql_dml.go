@@ -0,0 +1,601 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// SQLUpdateOption configures a SQLUpdateWriter created by [NewSQLUpdateWriter].
+type SQLUpdateOption interface {
+	applySQLUpdateOption(*SQLUpdateWriter) error
+}
+
+// SQLDeleteOption configures a SQLDeleteWriter created by [NewSQLDeleteWriter].
+type SQLDeleteOption interface {
+	applySQLDeleteOption(*SQLDeleteWriter) error
+}
+
+func applySQLUpdateOptions(w *SQLUpdateWriter, options ...SQLUpdateOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applySQLUpdateOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applySQLDeleteOptions(w *SQLDeleteWriter, options ...SQLDeleteOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applySQLDeleteOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SQLUpdateWriter streams UPDATE statements that set every non-key column of a row
+// and match the row by its primary key columns:
+//
+//	UPDATE t SET c1 = v1, c2 = v2 WHERE pk1 = k1 AND pk2 = k2;
+//
+// A NULL key value matches with IS NULL. Identifiers are quoted for [WithSQLDialect]
+// and values use [WithFormatter] literals, as in [SQLInsertWriter]. Each statement is
+// emitted with a single Write; after any write error every later Write*/Flush call
+// returns that first error; discard the writer (see package doc "Write errors").
+type SQLUpdateWriter struct {
+	stickyWriteError
+	table      string
+	formatter  *spanvalue.FormatConfig
+	sqlDialect databasepb.DatabaseDialect
+	keyColumns []string
+
+	schema        columnSchema
+	keyIndexes    []int
+	quotedTable   string
+	quotedColumns []string
+	out           io.Writer
+}
+
+// NewSQLUpdateWriter returns a SQL UPDATE writer for table keyed by keyColumns.
+// table must be non-empty after strings.TrimSpace ([ErrEmptyTableName]), and keyColumns
+// must be non-empty ([ErrNoKeyColumns]). Once a schema is registered, key columns
+// missing from it return [ErrMissingKeyColumn], and a schema with only key columns
+// returns [ErrNoUpdateColumns].
+func NewSQLUpdateWriter(out io.Writer, table string, keyColumns []string, options ...SQLUpdateOption) (*SQLUpdateWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &SQLUpdateWriter{
+		table:      table,
+		formatter:  spanvalue.LiteralFormatConfig(),
+		sqlDialect: databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL,
+		keyColumns: slices.Clone(keyColumns),
+		out:        out,
+	}
+	if err := applySQLUpdateOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if err := validateSQLKeyedTarget(w.table, w.keyColumns); err != nil {
+		return nil, err
+	}
+	if len(w.schema.names) > 0 {
+		if err := w.prepareStatement(); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// TableName returns the qualified table name used in UPDATE statements.
+func (w *SQLUpdateWriter) TableName() string {
+	return w.table
+}
+
+// FormatConfig returns the effective formatter used for value literals.
+// When no formatter is configured, this returns [spanvalue.LiteralFormatConfig].
+func (w *SQLUpdateWriter) FormatConfig() *spanvalue.FormatConfig {
+	return w.formatter
+}
+
+// WriteRow writes one UPDATE statement. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *SQLUpdateWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types and validates the key columns
+// against them; see [DelimitedWriter.PrepareRowType].
+func (w *SQLUpdateWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	if len(columnNames) == 0 {
+		return nil
+	}
+	return w.prepareStatement()
+}
+
+// PrepareColumnNames registers column names and validates the key columns against
+// them; see [DelimitedWriter.PrepareColumnNames].
+func (w *SQLUpdateWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return w.prepareStatement()
+}
+
+// WriteValues writes one UPDATE statement; see [DelimitedWriter.WriteValues].
+func (w *SQLUpdateWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one UPDATE statement; see [DelimitedWriter.WriteStructValues].
+func (w *SQLUpdateWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one UPDATE statement; see [DelimitedWriter.WriteGCVs].
+func (w *SQLUpdateWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if len(w.schema.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.prepareStatement(); err != nil {
+		return err
+	}
+	formattedValues, err := spanvalue.FormatRowColumns(w.formatter, w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("UPDATE ")
+	b.WriteString(w.quotedTable)
+	b.WriteString(" SET ")
+	first := true
+	for i, val := range formattedValues {
+		if slices.Contains(w.keyIndexes, i) {
+			continue
+		}
+		if !first {
+			b.WriteString(", ")
+		}
+		first = false
+		b.WriteString(w.quotedColumns[i])
+		b.WriteString(" = ")
+		b.WriteString(val)
+	}
+	b.WriteString(" WHERE ")
+	appendSQLKeyPredicate(&b, w.quotedColumns, w.keyIndexes, values, formattedValues)
+	b.WriteString(";\n")
+	_, err = io.WriteString(w.out, b.String())
+	return w.latchWriteErr(err)
+}
+
+// Flush is a no-op apart from returning a latched write error: each UPDATE is
+// written as soon as its row arrives.
+func (w *SQLUpdateWriter) Flush() error {
+	return w.writeErr
+}
+
+// prepareStatement resolves the key columns and quotes identifiers for the
+// registered schema, once per schema.
+func (w *SQLUpdateWriter) prepareStatement() error {
+	if w.keyIndexes != nil {
+		return nil
+	}
+	keyIndexes, quotedTable, quotedColumns, err := prepareSQLKeyedStatement(w.table, w.sqlDialect, w.schema.names, w.keyColumns)
+	if err != nil {
+		return err
+	}
+	if len(keyIndexes) == len(w.schema.names) {
+		return ErrNoUpdateColumns
+	}
+	w.keyIndexes, w.quotedTable, w.quotedColumns = keyIndexes, quotedTable, quotedColumns
+	return nil
+}
+
+func (w *SQLUpdateWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.keyIndexes = nil
+}
+
+func (w *SQLUpdateWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.keyIndexes = nil
+}
+
+func (w *SQLUpdateWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// SQLDeleteWriter streams DELETE statements that match rows by their primary key
+// columns; other columns of the row are ignored:
+//
+//	DELETE FROM t WHERE pk1 = k1 AND pk2 = k2;
+//
+// With [WithSQLBatchSize] n > 1, up to n rows share one statement. A single key column
+// becomes pk IN UNNEST([k1, k2]) for GoogleSQL and pk IN (k1, k2) for PostgreSQL
+// ([WithSQLDialect]); composite keys become (pk1 = a AND pk2 = b) OR (...). A NULL key
+// value matches with IS NULL. Identifiers, literals, batching, and write errors behave
+// as in [SQLInsertWriter]; call Flush after the final row to close a partial batch.
+type SQLDeleteWriter struct {
+	stickyWriteError
+	table      string
+	formatter  *spanvalue.FormatConfig
+	sqlDialect databasepb.DatabaseDialect
+	keyColumns []string
+	batchSize  int
+
+	schema        columnSchema
+	keyIndexes    []int
+	quotedTable   string
+	quotedColumns []string
+	pending       []sqlKeyRow
+	out           io.Writer
+}
+
+// sqlKeyRow holds the key literals of one pending DELETE row, in key order; a nil
+// entry is a NULL key value.
+type sqlKeyRow []*string
+
+// NewSQLDeleteWriter returns a SQL DELETE writer for table keyed by keyColumns.
+// table must be non-empty after strings.TrimSpace ([ErrEmptyTableName]), and keyColumns
+// must be non-empty ([ErrNoKeyColumns]). Once a schema is registered, key columns
+// missing from it return [ErrMissingKeyColumn].
+func NewSQLDeleteWriter(out io.Writer, table string, keyColumns []string, options ...SQLDeleteOption) (*SQLDeleteWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &SQLDeleteWriter{
+		table:      table,
+		formatter:  spanvalue.LiteralFormatConfig(),
+		sqlDialect: databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL,
+		keyColumns: slices.Clone(keyColumns),
+		out:        out,
+	}
+	if err := applySQLDeleteOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if err := validateSQLKeyedTarget(w.table, w.keyColumns); err != nil {
+		return nil, err
+	}
+	if len(w.schema.names) > 0 {
+		if err := w.prepareStatement(); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// TableName returns the qualified table name used in DELETE statements.
+func (w *SQLDeleteWriter) TableName() string {
+	return w.table
+}
+
+// FormatConfig returns the effective formatter used for key literals.
+// When no formatter is configured, this returns [spanvalue.LiteralFormatConfig].
+func (w *SQLDeleteWriter) FormatConfig() *spanvalue.FormatConfig {
+	return w.formatter
+}
+
+// WriteRow deletes one row by key. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *SQLDeleteWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types and validates the key columns
+// against them; see [DelimitedWriter.PrepareRowType].
+func (w *SQLDeleteWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	if len(columnNames) == 0 {
+		return nil
+	}
+	return w.prepareStatement()
+}
+
+// PrepareColumnNames registers column names and validates the key columns against
+// them; see [DelimitedWriter.PrepareColumnNames].
+func (w *SQLDeleteWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return w.prepareStatement()
+}
+
+// WriteValues deletes one row by key; see [DelimitedWriter.WriteValues].
+func (w *SQLDeleteWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues deletes one row by key; see [DelimitedWriter.WriteStructValues].
+func (w *SQLDeleteWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs deletes one row by key; see [DelimitedWriter.WriteGCVs].
+func (w *SQLDeleteWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if len(w.schema.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.prepareStatement(); err != nil {
+		return err
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	key := make(sqlKeyRow, len(w.keyIndexes))
+	for i, idx := range w.keyIndexes {
+		if internal.IsNullGenericColumnValue(values[idx]) {
+			continue
+		}
+		lit, err := w.formatter.FormatColumn(values[idx], true)
+		if err != nil {
+			return err
+		}
+		key[i] = &lit
+	}
+	w.pending = append(w.pending, key)
+	if len(w.pending) >= max(w.batchSize, 1) {
+		return w.closePendingBatch()
+	}
+	return nil
+}
+
+// Flush writes a partial batch started by [WithSQLBatchSize]; otherwise it is a
+// no-op. After a write failure it returns the latched error without writing.
+func (w *SQLDeleteWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	return w.closePendingBatch()
+}
+
+// closePendingBatch writes one DELETE for the pending rows with a single Write.
+func (w *SQLDeleteWriter) closePendingBatch() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("DELETE FROM ")
+	b.WriteString(w.quotedTable)
+	b.WriteString(" WHERE ")
+	switch {
+	case len(w.pending) == 1:
+		w.appendKeyRow(&b, w.pending[0])
+	case len(w.keyIndexes) == 1:
+		w.appendKeyList(&b)
+	default:
+		for i, key := range w.pending {
+			if i > 0 {
+				b.WriteString(" OR ")
+			}
+			b.WriteByte('(')
+			w.appendKeyRow(&b, key)
+			b.WriteByte(')')
+		}
+	}
+	b.WriteString(";\n")
+	if _, err := io.WriteString(w.out, b.String()); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
+
+func (w *SQLDeleteWriter) appendKeyRow(b *strings.Builder, key sqlKeyRow) {
+	for i, lit := range key {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteString(w.quotedColumns[w.keyIndexes[i]])
+		if lit == nil {
+			b.WriteString(" IS NULL")
+			continue
+		}
+		b.WriteString(" = ")
+		b.WriteString(*lit)
+	}
+}
+
+// appendKeyList writes the IN predicate for a single-column key, plus IS NULL when
+// a pending key is NULL, since IN never matches NULL.
+func (w *SQLDeleteWriter) appendKeyList(b *strings.Builder) {
+	column := w.quotedColumns[w.keyIndexes[0]]
+	var lits []string
+	hasNull := false
+	for _, key := range w.pending {
+		if key[0] == nil {
+			hasNull = true
+			continue
+		}
+		lits = append(lits, *key[0])
+	}
+	if len(lits) > 0 {
+		b.WriteString(column)
+		if w.sqlDialect == databasepb.DatabaseDialect_POSTGRESQL {
+			b.WriteString(" IN (")
+			b.WriteString(strings.Join(lits, ", "))
+			b.WriteString(")")
+		} else {
+			b.WriteString(" IN UNNEST([")
+			b.WriteString(strings.Join(lits, ", "))
+			b.WriteString("])")
+		}
+		if hasNull {
+			b.WriteString(" OR ")
+		}
+	}
+	if hasNull {
+		b.WriteString(column)
+		b.WriteString(" IS NULL")
+	}
+}
+
+// prepareStatement resolves the key columns and quotes identifiers for the
+// registered schema, once per schema.
+func (w *SQLDeleteWriter) prepareStatement() error {
+	if w.keyIndexes != nil {
+		return nil
+	}
+	keyIndexes, quotedTable, quotedColumns, err := prepareSQLKeyedStatement(w.table, w.sqlDialect, w.schema.names, w.keyColumns)
+	if err != nil {
+		return err
+	}
+	w.keyIndexes, w.quotedTable, w.quotedColumns = keyIndexes, quotedTable, quotedColumns
+	return nil
+}
+
+func (w *SQLDeleteWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.keyIndexes = nil
+}
+
+func (w *SQLDeleteWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.keyIndexes = nil
+}
+
+func (w *SQLDeleteWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+// validateSQLKeyedTarget checks the constructor arguments shared by the UPDATE and
+// DELETE writers.
+func validateSQLKeyedTarget(table string, keyColumns []string) error {
+	if strings.TrimSpace(table) == "" {
+		return ErrEmptyTableName
+	}
+	if len(keyColumns) == 0 {
+		return ErrNoKeyColumns
+	}
+	if slices.Contains(keyColumns, "") {
+		return ErrEmptyColumnName
+	}
+	return nil
+}
+
+// prepareSQLKeyedStatement returns the schema positions of keyColumns and the quoted
+// table and column identifiers.
+func prepareSQLKeyedStatement(table string, dialect databasepb.DatabaseDialect, names, keyColumns []string) ([]int, string, []string, error) {
+	keyIndexes := make([]int, len(keyColumns))
+	for i, key := range keyColumns {
+		idx := slices.Index(names, key)
+		if idx < 0 {
+			return nil, "", nil, fmt.Errorf("%w: %q", ErrMissingKeyColumn, key)
+		}
+		keyIndexes[i] = idx
+	}
+	quotedTable, err := quoteQualifiedIdentifier(table, dialect)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	quotedColumns, err := quoteIdentifiers(names, dialect)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return keyIndexes, quotedTable, quotedColumns, nil
+}
+
+// appendSQLKeyPredicate appends pk1 = k1 AND pk2 IS NULL ... for the key columns.
+func appendSQLKeyPredicate(b *strings.Builder, quotedColumns []string, keyIndexes []int, values []spanner.GenericColumnValue, formattedValues []string) {
+	for i, idx := range keyIndexes {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteString(quotedColumns[idx])
+		if internal.IsNullGenericColumnValue(values[idx]) {
+			b.WriteString(" IS NULL")
+			continue
+		}
+		b.WriteString(" = ")
+		b.WriteString(formattedValues[idx])
+	}
+}
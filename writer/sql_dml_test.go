@@ -0,0 +1,227 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var (
+	_ RowIteratorWriter = (*SQLUpdateWriter)(nil)
+	_ RowIteratorWriter = (*SQLDeleteWriter)(nil)
+)
+
+func sqlDMLTestRows() [][]spanner.GenericColumnValue {
+	return [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("a")},
+		{gcvctor.Int64Value(2), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+		{gcvctor.NullFromCode(sppb.TypeCode_INT64), gcvctor.StringValue("c")},
+	}
+}
+
+func TestSQLUpdateWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		keys []string
+		opts []SQLUpdateOption
+		want string
+	}{
+		{
+			name: "GoogleSQL",
+			keys: []string{"id"},
+			want: "UPDATE `Singers` SET `name` = \"a\" WHERE `id` = 1;\n" +
+				"UPDATE `Singers` SET `name` = NULL WHERE `id` = 2;\n" +
+				"UPDATE `Singers` SET `name` = \"c\" WHERE `id` IS NULL;\n",
+		},
+		{
+			name: "PostgreSQL",
+			keys: []string{"name"},
+			opts: []SQLUpdateOption{
+				WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL),
+				WithFormatter(spanvalue.LiteralFormatConfigWithSingleQuotedLiterals()),
+			},
+			want: `UPDATE "Singers" SET "id" = 1 WHERE "name" = 'a';` + "\n" +
+				`UPDATE "Singers" SET "id" = 2 WHERE "name" IS NULL;` + "\n" +
+				`UPDATE "Singers" SET "id" = NULL WHERE "name" = 'c';` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewSQLUpdateWriter(t, &out, "Singers", tt.keys, append([]SQLUpdateOption{WithRowType(tableTestRowType())}, tt.opts...)...)
+			for _, row := range sqlDMLTestRows() {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLUpdateWriter_compositeKey(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewSQLUpdateWriter(t, &out, "db.Albums", []string{"singer", "album"})
+	names := []string{"album", "title", "singer"}
+	md := &sppb.ResultSetMetadata{RowType: typector.MustNameCodeSlicesToStructType(
+		names,
+		[]sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_STRING, sppb.TypeCode_INT64},
+	).GetStructType()}
+	rows := RowSeq(mustNewSpannerRow(t, names, []any{int64(2), "t", int64(1)}))
+	if _, err := WriteRowSeq(md, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "UPDATE `db`.`Albums` SET `title` = \"t\" WHERE `singer` = 1 AND `album` = 2;\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestSQLDeleteWriter(t *testing.T) {
+	t.Parallel()
+
+	pg := WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL)
+	singleQuoted := WithFormatter(spanvalue.LiteralFormatConfigWithSingleQuotedLiterals())
+	tests := []struct {
+		name string
+		keys []string
+		opts []SQLDeleteOption
+		want string
+	}{
+		{
+			name: "unbatched",
+			keys: []string{"id"},
+			want: "DELETE FROM `Singers` WHERE `id` = 1;\n" +
+				"DELETE FROM `Singers` WHERE `id` = 2;\n" +
+				"DELETE FROM `Singers` WHERE `id` IS NULL;\n",
+		},
+		{
+			name: "GoogleSQL batch",
+			keys: []string{"id"},
+			opts: []SQLDeleteOption{WithSQLBatchSize(2)},
+			want: "DELETE FROM `Singers` WHERE `id` IN UNNEST([1, 2]);\n" +
+				"DELETE FROM `Singers` WHERE `id` IS NULL;\n",
+		},
+		{
+			name: "PostgreSQL batch with NULL",
+			keys: []string{"name"},
+			opts: []SQLDeleteOption{pg, singleQuoted, WithSQLBatchSize(3)},
+			want: `DELETE FROM "Singers" WHERE "name" IN ('a', 'c') OR "name" IS NULL;` + "\n",
+		},
+		{
+			name: "composite batch",
+			keys: []string{"id", "name"},
+			opts: []SQLDeleteOption{pg, singleQuoted, WithSQLBatchSize(2)},
+			want: `DELETE FROM "Singers" WHERE ("id" = 1 AND "name" = 'a') OR ("id" = 2 AND "name" IS NULL);` + "\n" +
+				`DELETE FROM "Singers" WHERE "id" IS NULL AND "name" = 'c';` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewSQLDeleteWriter(t, &out, "Singers", tt.keys, append([]SQLDeleteOption{WithRowType(tableTestRowType())}, tt.opts...)...)
+			for _, row := range sqlDMLTestRows() {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLDMLWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name  string
+		table string
+		keys  []string
+		opts  []SQLUpdateOption
+		want  error
+	}{
+		{"empty table", " ", []string{"id"}, nil, ErrEmptyTableName},
+		{"no keys", "T", nil, nil, ErrNoKeyColumns},
+		{"empty key", "T", []string{""}, nil, ErrEmptyColumnName},
+		{"missing key", "T", []string{"pk"}, []SQLUpdateOption{WithRowType(tableTestRowType())}, ErrMissingKeyColumn},
+		{"only keys", "T", []string{"id", "name"}, []SQLUpdateOption{WithRowType(tableTestRowType())}, ErrNoUpdateColumns},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewSQLUpdateWriter(&bytes.Buffer{}, tt.table, tt.keys, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewSQLUpdateWriter error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewSQLUpdateWriter(nil, "T", []string{"id"}); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewSQLUpdateWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewSQLDeleteWriter(nil, "T", []string{"id"}); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewSQLDeleteWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	if _, err := NewSQLDeleteWriter(&bytes.Buffer{}, "T", nil); !errors.Is(err, ErrNoKeyColumns) {
+		t.Errorf("NewSQLDeleteWriter(no keys) error = %v, want ErrNoKeyColumns", err)
+	}
+
+	row := sqlDMLTestRows()[0]
+	t.Run("prepare", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewSQLDeleteWriter(t, &bytes.Buffer{}, "T", []string{"pk"})
+		if err := w.WriteGCVs(row); !errors.Is(err, ErrMissingColumnNames) {
+			t.Errorf("WriteGCVs without schema error = %v, want ErrMissingColumnNames", err)
+		}
+		if err := w.PrepareRowType(tableTestRowType()); !errors.Is(err, ErrMissingKeyColumn) {
+			t.Errorf("PrepareRowType error = %v, want ErrMissingKeyColumn", err)
+		}
+		u := mustNewSQLUpdateWriter(t, &bytes.Buffer{}, "T", []string{"id"})
+		if err := u.PrepareColumnNames([]string{"id"}); !errors.Is(err, ErrNoUpdateColumns) {
+			t.Errorf("PrepareColumnNames error = %v, want ErrNoUpdateColumns", err)
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewSQLDeleteWriter(t, fw, "T", []string{"id"}, WithRowType(tableTestRowType()), WithSQLBatchSize(2))
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		fu := &failNthWrite{n: 1}
+		u := mustNewSQLUpdateWriter(t, fu, "T", []string{"id"}, WithRowType(tableTestRowType()))
+		if err := u.WriteGCVs(row); !errors.Is(err, errInjected) {
+			t.Fatalf("WriteGCVs error = %v, want errInjected", err)
+		}
+		if err := u.Flush(); !errors.Is(err, errInjected) {
+			t.Errorf("Flush after failure error = %v, want errInjected", err)
+		}
+	})
+}
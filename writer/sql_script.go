@@ -0,0 +1,203 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type sqlInsertOptionFunc func(*SQLInsertWriter) error
+
+func (f sqlInsertOptionFunc) applySQLInsertOption(w *SQLInsertWriter) error {
+	return f(w)
+}
+
+// WithSQLBatchBytes caps the size in bytes of each INSERT statement, excluding the
+// statement separator. A multi-row batch ([WithSQLBatchSize]) closes early when the next
+// row would exceed n, and a single row that exceeds n on its own returns
+// [ErrSQLStatementTooLarge]. 0 (the default) means no limit; negative values return
+// [ErrInvalidSQLBatchLimit].
+func WithSQLBatchBytes(n int) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: bytes %d", ErrInvalidSQLBatchLimit, n)
+		}
+		w.batchBytes = n
+		return nil
+	})
+}
+
+// WithSQLMaxMutations caps the estimated mutations per transaction: each statement
+// outside [WithSQLTransactionSize] framing, or each BEGIN/COMMIT block within it.
+// Like Spanner, a row counts one mutation per column plus the columns of every secondary
+// index declared with [WithSQLIndexColumnCounts]. Batches close, and transactions commit,
+// early to stay within n; a single row above n returns [ErrSQLStatementTooLarge].
+// Spanner allows [DefaultMutationBatchCount] mutations per commit. 0 (the default) means
+// no limit; negative values return [ErrInvalidSQLBatchLimit].
+func WithSQLMaxMutations(n int) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: mutations %d", ErrInvalidSQLBatchLimit, n)
+		}
+		w.maxMutations = n
+		return nil
+	})
+}
+
+// WithSQLIndexColumnCounts declares the table's secondary indexes for
+// [WithSQLMaxMutations], one count per index: its key columns plus STORING columns.
+// Negative counts return [ErrInvalidSQLBatchLimit].
+func WithSQLIndexColumnCounts(counts ...int) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		total := 0
+		for _, n := range counts {
+			if n < 0 {
+				return fmt.Errorf("%w: index columns %d", ErrInvalidSQLBatchLimit, n)
+			}
+			total += n
+		}
+		w.indexColumns = total
+		return nil
+	})
+}
+
+// WithSQLStatementSeparator sets the text written after each statement, including
+// BEGIN and COMMIT (default ";\n"); for example ";\n\n" leaves a blank line between
+// statements. An empty separator returns [ErrInvalidStatementSeparator].
+func WithSQLStatementSeparator(sep string) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		if sep == "" {
+			return ErrInvalidStatementSeparator
+		}
+		w.separator = sep
+		return nil
+	})
+}
+
+// WithSQLTransactionSize wraps every n statements in BEGIN and COMMIT so a script
+// applies them in transactions of bounded size; [WithSQLMaxMutations] may commit
+// earlier. [*SQLInsertWriter.Flush] commits an open transaction. 0 (the default)
+// writes no framing; negative values return [ErrInvalidSQLBatchLimit].
+func WithSQLTransactionSize(n int) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: transaction size %d", ErrInvalidSQLBatchLimit, n)
+		}
+		w.txSize = n
+		return nil
+	})
+}
+
+// WithSQLHeaderComment writes lines as "-- " comments before the first statement, or
+// at [*SQLInsertWriter.Flush] when there are no rows, for example to record the source
+// query and export time:
+//
+//	writer.WithSQLHeaderComment("query: "+sql, "exported: "+time.Now().Format(time.RFC3339))
+//
+// Lines containing newlines become several comment lines.
+func WithSQLHeaderComment(lines ...string) SQLInsertOption {
+	return sqlInsertOptionFunc(func(w *SQLInsertWriter) error {
+		w.header = nil
+		for _, line := range lines {
+			w.header = append(w.header, strings.Split(strings.ReplaceAll(line, "\r\n", "\n"), "\n")...)
+		}
+		return nil
+	})
+}
+
+// rowMutations estimates the Spanner mutations one inserted row costs.
+func (w *SQLInsertWriter) rowMutations() int {
+	n := w.indexColumns
+	for _, name := range w.schema.names {
+		if !w.omitsColumn(name) {
+			n++
+		}
+	}
+	return n
+}
+
+// checkStatementLimits rejects a single-row statement that exceeds the byte or
+// mutation limit on its own.
+func (w *SQLInsertWriter) checkStatementLimits(size, mutations int) error {
+	if w.batchBytes > 0 && size > w.batchBytes {
+		return fmt.Errorf("%w: %d bytes exceeds %d", ErrSQLStatementTooLarge, size, w.batchBytes)
+	}
+	if w.maxMutations > 0 && mutations > w.maxMutations {
+		return fmt.Errorf("%w: %d mutations exceeds %d", ErrSQLStatementTooLarge, mutations, w.maxMutations)
+	}
+	return nil
+}
+
+// txMutationBase returns the mutations already committed to the transaction a new
+// statement of the given cost would join.
+func (w *SQLInsertWriter) txMutationBase(mutations int) int {
+	if w.txSize == 0 || !w.txOpen || (w.maxMutations > 0 && w.txMutations+mutations > w.maxMutations) {
+		return 0
+	}
+	return w.txMutations
+}
+
+// emitStatement writes stmt and its separator with a single Write, preceded by the
+// header and BEGIN, and followed by COMMIT, as the script framing requires.
+func (w *SQLInsertWriter) emitStatement(stmt string, mutations int) error {
+	var b strings.Builder
+	w.appendHeader(&b)
+	if w.txSize > 0 {
+		if w.txOpen && w.maxMutations > 0 && w.txMutations+mutations > w.maxMutations {
+			w.appendCommit(&b)
+		}
+		if !w.txOpen {
+			b.WriteString("BEGIN")
+			b.WriteString(w.separator)
+			w.txOpen = true
+		}
+	}
+	b.WriteString(stmt)
+	b.WriteString(w.separator)
+	if w.txSize > 0 {
+		w.txStatements++
+		w.txMutations += mutations
+		if w.txStatements >= w.txSize {
+			w.appendCommit(&b)
+		}
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return w.latchWriteErr(err)
+}
+
+// finishScript commits an open transaction and writes a header not yet written.
+func (w *SQLInsertWriter) finishScript() error {
+	var b strings.Builder
+	w.appendHeader(&b)
+	if w.txOpen {
+		w.appendCommit(&b)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return w.latchWriteErr(err)
+}
+
+func (w *SQLInsertWriter) appendHeader(b *strings.Builder) {
+	if w.headerWritten {
+		return
+	}
+	w.headerWritten = true
+	for _, line := range w.header {
+		b.WriteString("--")
+		if line != "" {
+			b.WriteString(" ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func (w *SQLInsertWriter) appendCommit(b *strings.Builder) {
+	b.WriteString("COMMIT")
+	b.WriteString(w.separator)
+	w.txOpen = false
+	w.txStatements = 0
+	w.txMutations = 0
+}
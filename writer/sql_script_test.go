@@ -0,0 +1,148 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+func writeSQLScriptRows(t *testing.T, w *SQLInsertWriter, n int) {
+	t.Helper()
+	for i := range n {
+		values := []spanner.GenericColumnValue{gcvctor.Int64Value(int64(i + 1)), gcvctor.StringValue("a")}
+		if err := w.WriteValues([]string{"id", "name"}, values); err != nil {
+			t.Fatalf("WriteValues() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+}
+
+func TestSQLInsertWriterScript(t *testing.T) {
+	t.Parallel()
+
+	const prefix = "INSERT INTO `users` (`id`, `name`) VALUES"
+	tests := []struct {
+		name    string
+		options []SQLInsertOption
+		rows    int
+		want    string
+	}{
+		{
+			name:    "batch bytes",
+			options: []SQLInsertOption{WithSQLBatchSize(10), WithSQLBatchBytes(70)},
+			rows:    3,
+			want: prefix + "\n  (1, \"a\"),\n  (2, \"a\");\n" +
+				prefix + "\n  (3, \"a\");\n",
+		},
+		{
+			name:    "batch mutations with index",
+			options: []SQLInsertOption{WithSQLBatchSize(10), WithSQLMaxMutations(7), WithSQLIndexColumnCounts(1)},
+			rows:    3,
+			want: prefix + "\n  (1, \"a\"),\n  (2, \"a\");\n" +
+				prefix + "\n  (3, \"a\");\n",
+		},
+		{
+			name:    "transaction size",
+			options: []SQLInsertOption{WithSQLTransactionSize(2)},
+			rows:    3,
+			want: "BEGIN;\n" +
+				prefix + " (1, \"a\");\n" +
+				prefix + " (2, \"a\");\n" +
+				"COMMIT;\nBEGIN;\n" +
+				prefix + " (3, \"a\");\n" +
+				"COMMIT;\n",
+		},
+		{
+			name:    "transaction mutations",
+			options: []SQLInsertOption{WithSQLTransactionSize(3), WithSQLMaxMutations(5)},
+			rows:    3,
+			want: "BEGIN;\n" +
+				prefix + " (1, \"a\");\n" +
+				prefix + " (2, \"a\");\n" +
+				"COMMIT;\nBEGIN;\n" +
+				prefix + " (3, \"a\");\n" +
+				"COMMIT;\n",
+		},
+		{
+			name: "header and separator",
+			options: []SQLInsertOption{
+				WithSQLHeaderComment("query: SELECT *\nFROM users", "exported: 2024-01-02T03:04:05Z"),
+				WithSQLStatementSeparator(";\n\n"),
+			},
+			rows: 2,
+			want: "-- query: SELECT *\n-- FROM users\n-- exported: 2024-01-02T03:04:05Z\n" +
+				prefix + " (1, \"a\");\n\n" +
+				prefix + " (2, \"a\");\n\n",
+		},
+		{
+			name:    "header without rows",
+			options: []SQLInsertOption{WithSQLHeaderComment("empty"), WithSQLTransactionSize(2)},
+			want:    "-- empty\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w := mustNewSQLInsertWriter(t, &out, "users", tt.options...)
+			writeSQLScriptRows(t, w, tt.rows)
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Fatalf("SQL output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLInsertWriterScriptErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		options []SQLInsertOption
+		want    error
+	}{
+		{"negative bytes", []SQLInsertOption{WithSQLBatchBytes(-1)}, ErrInvalidSQLBatchLimit},
+		{"negative mutations", []SQLInsertOption{WithSQLMaxMutations(-1)}, ErrInvalidSQLBatchLimit},
+		{"negative index", []SQLInsertOption{WithSQLIndexColumnCounts(2, -1)}, ErrInvalidSQLBatchLimit},
+		{"negative transaction", []SQLInsertOption{WithSQLTransactionSize(-1)}, ErrInvalidSQLBatchLimit},
+		{"empty separator", []SQLInsertOption{WithSQLStatementSeparator("")}, ErrInvalidStatementSeparator},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewSQLInsertWriter(&bytes.Buffer{}, "users", tt.options...); !errors.Is(err, tt.want) {
+				t.Fatalf("NewSQLInsertWriter() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	values := []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}
+	for _, tt := range []struct {
+		name    string
+		options []SQLInsertOption
+	}{
+		{"bytes", []SQLInsertOption{WithSQLBatchBytes(20)}},
+		{"bytes batched", []SQLInsertOption{WithSQLBatchBytes(20), WithSQLBatchSize(5)}},
+		{"mutations", []SQLInsertOption{WithSQLMaxMutations(3), WithSQLIndexColumnCounts(1, 1)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewSQLInsertWriter(t, &out, "users", tt.options...)
+			if err := w.WriteValues([]string{"id", "name"}, values); !errors.Is(err, ErrSQLStatementTooLarge) {
+				t.Fatalf("WriteValues() error = %v, want ErrSQLStatementTooLarge", err)
+			}
+			if out.Len() != 0 {
+				t.Fatalf("WriteValues() wrote %q, want no output", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package writer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MaxSQLStatementParameters is the Spanner limit on query parameters per statement.
+const MaxSQLStatementParameters = 950
+
+// SQLStatementOption configures a SQLStatementWriter created by [NewSQLStatementWriter].
+type SQLStatementOption interface {
+	applySQLStatementOption(*SQLStatementWriter) error
+}
+
+type sqlStatementOptionFunc func(*SQLStatementWriter) error
+
+func (f sqlStatementOptionFunc) applySQLStatementOption(w *SQLStatementWriter) error {
+	return f(w)
+}
+
+func applySQLStatementOptions(w *SQLStatementWriter, options ...SQLStatementOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applySQLStatementOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SQLStatementBatchForm selects how [SQLStatementWriter] binds the rows of a batched
+// statement ([WithSQLBatchSize]).
+type SQLStatementBatchForm int
+
+const (
+	// SQLBatchValues writes multi-row VALUES with one parameter per cell:
+	// INSERT INTO t (a, b) VALUES (@p1, @p2), (@p3, @p4).
+	SQLBatchValues SQLStatementBatchForm = iota
+	// SQLBatchUnnest binds the rows as one ARRAY<STRUCT> parameter:
+	// INSERT INTO t (a, b) SELECT * FROM UNNEST(@rows). GoogleSQL only.
+	SQLBatchUnnest
+)
+
+// String returns the Go constant name for f, or "SQLStatementBatchForm(n)" for unknown values.
+func (f SQLStatementBatchForm) String() string {
+	switch f {
+	case SQLBatchValues:
+		return "SQLBatchValues"
+	case SQLBatchUnnest:
+		return "SQLBatchUnnest"
+	default:
+		return fmt.Sprintf("SQLStatementBatchForm(%d)", int(f))
+	}
+}
+
+// WithSQLStatementBatchForm selects how batched rows are bound (default
+// [SQLBatchValues]). Unknown forms, and [SQLBatchUnnest] with the PostgreSQL dialect,
+// which has no STRUCT parameters, return [ErrInvalidSQLStatementBatchForm].
+func WithSQLStatementBatchForm(form SQLStatementBatchForm) SQLStatementOption {
+	return sqlStatementOptionFunc(func(w *SQLStatementWriter) error {
+		if form < SQLBatchValues || form > SQLBatchUnnest {
+			return fmt.Errorf("%w: %v", ErrInvalidSQLStatementBatchForm, form)
+		}
+		w.batchForm = form
+		return nil
+	})
+}
+
+// WithSQLStatementsPerCall sets how many statements [SQLStatementWriter] passes to its
+// sink at once (default 1), for example to group them into one BatchUpdate call.
+// Values below 1 return [ErrInvalidSQLBatchLimit].
+func WithSQLStatementsPerCall(n int) SQLStatementOption {
+	return sqlStatementOptionFunc(func(w *SQLStatementWriter) error {
+		if n < 1 {
+			return fmt.Errorf("%w: statements per call %d", ErrInvalidSQLBatchLimit, n)
+		}
+		w.perCall = n
+		return nil
+	})
+}
+
+// SQLStatementWriter turns rows into parameterized INSERT [spanner.Statement] values for
+// a fixed table and hands them to a sink, for example Client.ReadWriteTransaction with
+// BatchUpdate. Values are bound as parameters (@p1, @p2, ... for GoogleSQL; $1, $2, ...
+// for PostgreSQL via [WithSQLDialect]) holding the original wire values, so nothing is
+// inlined as a literal and every Spanner type round-trips unchanged.
+//
+// [WithSQLBatchSize] puts up to n rows in one statement, bound as [WithSQLStatementBatchForm]
+// selects; [WithSQLStatementsPerCall] groups statements per sink call. [SQLBatchValues]
+// binds batch size × column count parameters, and a product above
+// [MaxSQLStatementParameters] returns [ErrInvalidSQLBatchLimit] once the columns are
+// known; [SQLBatchUnnest] binds one parameter per statement. Call Flush after
+// the final row. After the first sink failure, every later Write*/Flush call returns that
+// error; discard the writer (see package doc "Write errors").
+type SQLStatementWriter struct {
+	stickyWriteError
+	table      string
+	sqlDialect databasepb.DatabaseDialect
+	batchSize  int
+	batchForm  SQLStatementBatchForm
+	perCall    int
+	sink       func([]spanner.Statement) error
+
+	schema        columnSchema
+	quotedTable   string
+	quotedColumns string
+	rows          [][]spanner.GenericColumnValue
+	pending       []spanner.Statement
+}
+
+// NewSQLStatementWriter returns a writer that passes parameterized INSERT statements for
+// table to sink. table must be non-empty after strings.TrimSpace ([ErrEmptyTableName]);
+// a nil sink returns [ErrNilStatementSink].
+func NewSQLStatementWriter(table string, sink func([]spanner.Statement) error, options ...SQLStatementOption) (*SQLStatementWriter, error) {
+	if sink == nil {
+		return nil, ErrNilStatementSink
+	}
+	w := &SQLStatementWriter{
+		table:      table,
+		sqlDialect: databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL,
+		perCall:    1,
+		sink:       sink,
+	}
+	if err := applySQLStatementOptions(w, options...); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(w.table) == "" {
+		return nil, ErrEmptyTableName
+	}
+	if w.batchForm == SQLBatchUnnest && w.sqlDialect == databasepb.DatabaseDialect_POSTGRESQL {
+		return nil, fmt.Errorf("%w: %v requires GoogleSQL", ErrInvalidSQLStatementBatchForm, w.batchForm)
+	}
+	if len(w.schema.names) > 0 {
+		if err := w.prepareStatement(); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// TableName returns the table the statements insert into.
+func (w *SQLStatementWriter) TableName() string {
+	return w.table
+}
+
+// WriteRow adds one row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *SQLStatementWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *SQLStatementWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	if len(columnNames) == 0 {
+		return nil
+	}
+	return w.prepareStatement()
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *SQLStatementWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return w.prepareStatement()
+}
+
+// WriteValues adds one row; see [DelimitedWriter.WriteValues].
+func (w *SQLStatementWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues adds one row; see [DelimitedWriter.WriteStructValues].
+func (w *SQLStatementWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs adds one row, completing a statement when the batch is full and passing
+// completed statements to the sink per [WithSQLStatementsPerCall].
+func (w *SQLStatementWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if len(w.schema.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	if err := w.prepareStatement(); err != nil {
+		return err
+	}
+	// Nil wire values are NULL; bind them as explicit NULLs, both as
+	// parameters and inside the UNNEST array.
+	row := make([]spanner.GenericColumnValue, len(values))
+	for i, v := range values {
+		row[i] = spanner.GenericColumnValue{Type: v.Type, Value: wireValueOrNull(v.Value)}
+	}
+	w.rows = append(w.rows, row)
+	if len(w.rows) < max(w.batchSize, 1) {
+		return nil
+	}
+	w.closeStatement()
+	if len(w.pending) < w.perCall {
+		return nil
+	}
+	return w.sendPending()
+}
+
+// Flush completes a partial batch and passes every pending statement to the sink.
+func (w *SQLStatementWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	w.closeStatement()
+	return w.sendPending()
+}
+
+// closeStatement turns the buffered rows into one pending statement.
+func (w *SQLStatementWriter) closeStatement() {
+	if len(w.rows) == 0 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(w.quotedTable)
+	b.WriteString(" (")
+	b.WriteString(w.quotedColumns)
+	b.WriteString(")")
+	params := make(map[string]any)
+	if w.batchForm == SQLBatchUnnest && len(w.rows) > 1 {
+		b.WriteString(" SELECT * FROM UNNEST(@rows)")
+		params["rows"] = w.rowsParam()
+	} else {
+		b.WriteString(" VALUES ")
+		n := 0
+		for i, row := range w.rows {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('(')
+			for j, value := range row {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				n++
+				name := "p" + strconv.Itoa(n)
+				if w.sqlDialect == databasepb.DatabaseDialect_POSTGRESQL {
+					b.WriteString("$" + strconv.Itoa(n))
+				} else {
+					b.WriteString("@" + name)
+				}
+				params[name] = value
+			}
+			b.WriteByte(')')
+		}
+	}
+	w.pending = append(w.pending, spanner.Statement{SQL: b.String(), Params: params})
+	w.rows = nil
+}
+
+// rowsParam binds the buffered rows as one ARRAY<STRUCT> value whose field names and
+// types come from the registered schema, or from the first row's values.
+func (w *SQLStatementWriter) rowsParam() spanner.GenericColumnValue {
+	fields := make([]*sppb.StructType_Field, len(w.schema.names))
+	for i, name := range w.schema.names {
+		typ := w.rows[0][i].Type
+		if len(w.schema.types) == len(fields) {
+			typ = w.schema.types[i]
+		}
+		fields[i] = &sppb.StructType_Field{Name: name, Type: typ}
+	}
+	list := make([]*structpb.Value, len(w.rows))
+	for i, row := range w.rows {
+		cells := make([]*structpb.Value, len(row))
+		for j, value := range row {
+			cells[j] = value.Value
+		}
+		list[i] = structpb.NewListValue(&structpb.ListValue{Values: cells})
+	}
+	return spanner.GenericColumnValue{
+		Type: &sppb.Type{
+			Code:             sppb.TypeCode_ARRAY,
+			ArrayElementType: &sppb.Type{Code: sppb.TypeCode_STRUCT, StructType: &sppb.StructType{Fields: fields}},
+		},
+		Value: structpb.NewListValue(&structpb.ListValue{Values: list}),
+	}
+}
+
+func (w *SQLStatementWriter) sendPending() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	if err := w.latchWriteErr(w.sink(w.pending)); err != nil {
+		return err
+	}
+	w.pending = nil
+	return nil
+}
+
+// prepareStatement quotes the table and column identifiers for the registered schema.
+func (w *SQLStatementWriter) prepareStatement() error {
+	if w.quotedColumns != "" {
+		return nil
+	}
+	if params := max(w.batchSize, 1) * len(w.schema.names); w.batchForm == SQLBatchValues && params > MaxSQLStatementParameters {
+		return fmt.Errorf("%w: %d rows of %d columns bind %d parameters, above %d; use SQLBatchUnnest or a smaller batch",
+			ErrInvalidSQLBatchLimit, max(w.batchSize, 1), len(w.schema.names), params, MaxSQLStatementParameters)
+	}
+	quotedTable, err := quoteQualifiedIdentifier(w.table, w.sqlDialect)
+	if err != nil {
+		return err
+	}
+	quotedColumns, err := quoteIdentifiers(w.schema.names, w.sqlDialect)
+	if err != nil {
+		return err
+	}
+	w.quotedTable = quotedTable
+	w.quotedColumns = strings.Join(quotedColumns, ", ")
+	return nil
+}
+
+func (w *SQLStatementWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.quotedColumns = ""
+}
+
+func (w *SQLStatementWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.quotedColumns = ""
+}
+
+func (w *SQLStatementWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	databasepb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*SQLStatementWriter)(nil)
+
+func collectStatements(calls *[][]spanner.Statement) func([]spanner.Statement) error {
+	return func(stmts []spanner.Statement) error {
+		*calls = append(*calls, stmts)
+		return nil
+	}
+}
+
+func TestSQLStatementWriter(t *testing.T) {
+	t.Parallel()
+
+	rows := mutationTestRows()
+	tests := []struct {
+		name string
+		opts []SQLStatementOption
+		want [][]spanner.Statement
+	}{
+		{
+			name: "GoogleSQL",
+			opts: []SQLStatementOption{WithSQLStatementsPerCall(2)},
+			want: [][]spanner.Statement{
+				{
+					{SQL: "INSERT INTO `Singers` (`id`, `name`) VALUES (@p1, @p2)", Params: map[string]any{"p1": rows[0][0], "p2": rows[0][1]}},
+					{SQL: "INSERT INTO `Singers` (`id`, `name`) VALUES (@p1, @p2)", Params: map[string]any{"p1": rows[1][0], "p2": rows[1][1]}},
+				},
+				{
+					{SQL: "INSERT INTO `Singers` (`id`, `name`) VALUES (@p1, @p2)", Params: map[string]any{"p1": rows[2][0], "p2": rows[2][1]}},
+				},
+			},
+		},
+		{
+			name: "PostgreSQL batched values",
+			opts: []SQLStatementOption{WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL), WithSQLBatchSize(2)},
+			want: [][]spanner.Statement{
+				{{
+					SQL:    `INSERT INTO "Singers" ("id", "name") VALUES ($1, $2), ($3, $4)`,
+					Params: map[string]any{"p1": rows[0][0], "p2": rows[0][1], "p3": rows[1][0], "p4": rows[1][1]},
+				}},
+				{{
+					SQL:    `INSERT INTO "Singers" ("id", "name") VALUES ($1, $2)`,
+					Params: map[string]any{"p1": rows[2][0], "p2": rows[2][1]},
+				}},
+			},
+		},
+		{
+			name: "GoogleSQL unnest",
+			opts: []SQLStatementOption{WithSQLBatchSize(3), WithSQLStatementBatchForm(SQLBatchUnnest)},
+			want: [][]spanner.Statement{{{
+				SQL: "INSERT INTO `Singers` (`id`, `name`) SELECT * FROM UNNEST(@rows)",
+				Params: map[string]any{"rows": spanner.GenericColumnValue{
+					Type: &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: &sppb.Type{
+						Code: sppb.TypeCode_STRUCT,
+						StructType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+							{Name: "id", Type: &sppb.Type{Code: sppb.TypeCode_INT64}},
+							{Name: "name", Type: &sppb.Type{Code: sppb.TypeCode_STRING}},
+						}},
+					}},
+					Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+						structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("1"), structpb.NewStringValue("a")}}),
+						structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("2"), structpb.NewNullValue()}}),
+						structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("3"), structpb.NewStringValue("c")}}),
+					}}),
+				}},
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls [][]spanner.Statement
+			w, err := NewSQLStatementWriter("Singers", collectStatements(&calls), append([]SQLStatementOption{WithRowType(tableTestRowType())}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, calls, protocmp.Transform()); diff != "" {
+				t.Errorf("statements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLStatementWriter_nilValue(t *testing.T) {
+	t.Parallel()
+
+	// A nil wire value is bound as NULL, both as a parameter and in UNNEST rows.
+	stringType := &sppb.Type{Code: sppb.TypeCode_STRING}
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1), {Type: stringType}}
+	null := spanner.GenericColumnValue{Type: stringType, Value: structpb.NewNullValue()}
+	for _, tt := range []struct {
+		name string
+		form SQLStatementBatchForm
+		want any
+	}{
+		{"values", SQLBatchValues, null},
+		{"unnest", SQLBatchUnnest, structpb.NewNullValue()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls [][]spanner.Statement
+			w, err := NewSQLStatementWriter("Singers", collectStatements(&calls),
+				WithRowType(tableTestRowType()), WithSQLBatchSize(2), WithSQLStatementBatchForm(tt.form))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 2 {
+				if err := w.WriteGCVs(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if len(calls) != 1 || len(calls[0]) != 1 {
+				t.Fatalf("got %d sink calls, want 1 statement", len(calls))
+			}
+			params := calls[0][0].Params
+			var got any = params["p2"]
+			if tt.form == SQLBatchUnnest {
+				got = params["rows"].(spanner.GenericColumnValue).Value.GetListValue().GetValues()[0].GetListValue().GetValues()[1]
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("NULL binding mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLStatementWriter_rowIterator(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]spanner.Statement
+	w, err := NewSQLStatementWriter("Singers", collectStatements(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := RowSeq(mustNewSpannerRow(t, []string{"id", "name"}, []any{int64(1), "a"}))
+	if _, err := WriteRowSeq(&sppb.ResultSetMetadata{RowType: tableTestRowType()}, rows, w); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]spanner.Statement{{{
+		SQL:    "INSERT INTO `Singers` (`id`, `name`) VALUES (@p1, @p2)",
+		Params: map[string]any{"p1": gcvctor.Int64Value(1), "p2": gcvctor.StringValue("a")},
+	}}}
+	if diff := cmp.Diff(want, calls, protocmp.Transform()); diff != "" {
+		t.Errorf("statements mismatch (-want +got):\n%s", diff)
+	}
+	if got := w.TableName(); got != "Singers" {
+		t.Errorf("TableName() = %q", got)
+	}
+}
+
+func TestSQLStatementWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	discard := func([]spanner.Statement) error { return nil }
+	for _, tt := range []struct {
+		name  string
+		table string
+		opts  []SQLStatementOption
+		want  error
+	}{
+		{"empty table", " ", nil, ErrEmptyTableName},
+		{"batch form", "T", []SQLStatementOption{WithSQLStatementBatchForm(SQLStatementBatchForm(2))}, ErrInvalidSQLStatementBatchForm},
+		{"PostgreSQL unnest", "T", []SQLStatementOption{
+			WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL), WithSQLStatementBatchForm(SQLBatchUnnest),
+		}, ErrInvalidSQLStatementBatchForm},
+		{"per call", "T", []SQLStatementOption{WithSQLStatementsPerCall(0)}, ErrInvalidSQLBatchLimit},
+		{"unnamed column", "T", []SQLStatementOption{WithColumnNames([]string{"id", ""})}, ErrEmptyColumnName},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewSQLStatementWriter(tt.table, discard, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("NewSQLStatementWriter error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	t.Run("parameter limit", func(t *testing.T) {
+		t.Parallel()
+		names := make([]string, 10)
+		for i := range names {
+			names[i] = fmt.Sprintf("c%d", i)
+		}
+		if _, err := NewSQLStatementWriter("T", discard, WithColumnNames(names), WithSQLBatchSize(100)); !errors.Is(err, ErrInvalidSQLBatchLimit) {
+			t.Errorf("NewSQLStatementWriter(1000 parameters) error = %v, want ErrInvalidSQLBatchLimit", err)
+		}
+		if _, err := NewSQLStatementWriter("T", discard, WithColumnNames(names), WithSQLBatchSize(95)); err != nil {
+			t.Errorf("NewSQLStatementWriter(950 parameters) error = %v", err)
+		}
+		if _, err := NewSQLStatementWriter("T", discard, WithColumnNames(names), WithSQLBatchSize(100), WithSQLStatementBatchForm(SQLBatchUnnest)); err != nil {
+			t.Errorf("NewSQLStatementWriter(unnest) error = %v", err)
+		}
+		w, err := NewSQLStatementWriter("T", discard, WithSQLBatchSize(100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make([]spanner.GenericColumnValue, len(names))
+		for i := range values {
+			values[i] = gcvctor.Int64Value(int64(i))
+		}
+		if err := w.WriteValues(names, values); !errors.Is(err, ErrInvalidSQLBatchLimit) {
+			t.Errorf("first WriteValues error = %v, want ErrInvalidSQLBatchLimit", err)
+		}
+	})
+
+	if _, err := NewSQLStatementWriter("T", nil); !errors.Is(err, ErrNilStatementSink) {
+		t.Errorf("NewSQLStatementWriter(nil sink) error = %v, want ErrNilStatementSink", err)
+	}
+
+	row := mutationTestRows()[0]
+	w, err := NewSQLStatementWriter("T", discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteGCVs(row); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("WriteGCVs without schema error = %v, want ErrMissingColumnNames", err)
+	}
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		w, err := NewSQLStatementWriter("T", func([]spanner.Statement) error {
+			calls++
+			return errInjected
+		}, WithRowType(tableTestRowType()), WithSQLBatchSize(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) || calls != 1 {
+			t.Errorf("Flush after failure error = %v with %d sink calls, want errInjected and 1 call", err, calls)
+		}
+	})
+}
+
+func TestSQLStatementBatchForm_String(t *testing.T) {
+	t.Parallel()
+
+	for f, want := range map[SQLStatementBatchForm]string{
+		SQLBatchValues: "SQLBatchValues",
+		SQLBatchUnnest: "SQLBatchUnnest",
+		4:              "SQLStatementBatchForm(4)",
+	} {
+		if got := f.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(f), got, want)
+		}
+	}
+}
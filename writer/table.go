@@ -0,0 +1,546 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype"
+	"golang.org/x/text/width"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// TableOption configures a TableWriter created by [NewTableWriter].
+type TableOption interface {
+	applyTableOption(*TableWriter) error
+}
+
+type tableOptionFunc func(*TableWriter) error
+
+func (f tableOptionFunc) applyTableOption(w *TableWriter) error {
+	return f(w)
+}
+
+func applyTableOptions(w *TableWriter, options ...TableOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyTableOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableStyle selects the borders drawn by [TableWriter].
+type TableStyle int
+
+const (
+	// TableStyleASCII draws spanner-cli style +---+ and | borders (the default).
+	TableStyleASCII TableStyle = iota
+	// TableStyleUnicode draws box-drawing borders (┌─┬─┐, │, └─┴─┘).
+	TableStyleUnicode
+	// TableStyleMinimal draws no borders: columns are separated by two
+	// spaces and the header is underlined with dashes.
+	TableStyleMinimal
+)
+
+// String returns the Go constant name for s, or "TableStyle(n)" for unknown values.
+func (s TableStyle) String() string {
+	switch s {
+	case TableStyleASCII:
+		return "TableStyleASCII"
+	case TableStyleUnicode:
+		return "TableStyleUnicode"
+	case TableStyleMinimal:
+		return "TableStyleMinimal"
+	default:
+		return fmt.Sprintf("TableStyle(%d)", int(s))
+	}
+}
+
+// tableBorders holds the glyphs of one style. Horizontal rules are built
+// from left, fill, cross, and right; rows from vertical.
+type tableBorders struct {
+	top, middle, bottom [3]string // left, cross, right
+	fill                string
+	vertical            string
+	padding             string
+}
+
+var tableStyleBorders = map[TableStyle]tableBorders{
+	TableStyleASCII: {
+		top: [3]string{"+", "+", "+"}, middle: [3]string{"+", "+", "+"}, bottom: [3]string{"+", "+", "+"},
+		fill: "-", vertical: "|", padding: " ",
+	},
+	TableStyleUnicode: {
+		top: [3]string{"┌", "┬", "┐"}, middle: [3]string{"├", "┼", "┤"}, bottom: [3]string{"└", "┴", "┘"},
+		fill: "─", vertical: "│", padding: " ",
+	},
+}
+
+// WithTableStyle selects the border style of a [TableWriter] (default
+// [TableStyleASCII]). Unknown styles return [ErrInvalidTableStyle].
+func WithTableStyle(style TableStyle) TableOption {
+	return tableOptionFunc(func(w *TableWriter) error {
+		if style < TableStyleASCII || style > TableStyleMinimal {
+			return fmt.Errorf("%w: %v", ErrInvalidTableStyle, style)
+		}
+		w.style = style
+		return nil
+	})
+}
+
+// TextLayoutOption configures both text layout writers, [TableWriter] and
+// [VerticalWriter].
+type TextLayoutOption interface {
+	TableOption
+	VerticalOption
+}
+
+type typedHeaderOption bool
+
+// WithTypedHeader sets whether column labels include the column type after
+// the name, as in "id INT64" (default false): the header cells of
+// [TableWriter] and the field names of [VerticalWriter]. Types come from the
+// registered row type, or from the written values when only names are
+// registered.
+func WithTypedHeader(typed bool) TextLayoutOption {
+	return typedHeaderOption(typed)
+}
+
+func (o typedHeaderOption) applyTableOption(w *TableWriter) error {
+	w.typedHeader = bool(o)
+	return nil
+}
+
+func (o typedHeaderOption) applyVerticalOption(w *VerticalWriter) error {
+	w.typedNames = bool(o)
+	return nil
+}
+
+// WithTableSampleRows switches [TableWriter] to streaming fixed-width mode:
+// column widths are computed from the header and the first n rows, the
+// table is written once n rows are buffered, and later rows are written as
+// they arrive, wrapping cells wider than their column. n == 0 (the default)
+// buffers every row until [TableWriter.Flush] so widths fit all cells;
+// negative n returns [ErrInvalidSampleRows].
+func WithTableSampleRows(n int) TableOption {
+	return tableOptionFunc(func(w *TableWriter) error {
+		if n < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidSampleRows, n)
+		}
+		w.sampleRows = n
+		return nil
+	})
+}
+
+// TableWriter renders rows as a text table in the spanner-cli layout:
+//
+//	+----+-------+
+//	| id | name  |
+//	+----+-------+
+//	| 1  | Alice |
+//	+----+-------+
+//
+// Column widths use East Asian display width, and cells containing newlines
+// span several lines. By default rows are buffered and the table is written
+// by [TableWriter.Flush]; [WithTableSampleRows] streams with widths fixed from
+// a sample window. Flush ends the table, so rows written after Flush start a
+// new table with the same schema. Cells are formatted with
+// [spanvalue.SpannerCLICompatibleFormatConfig] unless [WithFormatter] is set.
+//
+// Each rendered block is emitted with a single Write. After the first output
+// write failure, every later Write*/Flush call returns that error; discard
+// the writer (see package doc "Write errors").
+type TableWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for header cells.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	style             TableStyle
+	typedHeader       bool
+	sampleRows        int
+
+	schema   columnSchema
+	rowTypes []*sppb.Type
+	pending  [][]string
+	widths   []int
+	out      io.Writer
+}
+
+// NewTableWriter returns a table writer configured by options.
+func NewTableWriter(out io.Writer, options ...TableOption) (*TableWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &TableWriter{
+		formatter: spanvalue.SpannerCLICompatibleFormatConfig(),
+		out:       out,
+	}
+	if err := applyTableOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one table row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *TableWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *TableWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *TableWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one row; see [DelimitedWriter.WriteValues].
+func (w *TableWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one row; see [DelimitedWriter.WriteStructValues].
+func (w *TableWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one row; see [DelimitedWriter.WriteGCVs].
+func (w *TableWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	cells, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	if w.rowTypes == nil {
+		w.rowTypes = make([]*sppb.Type, len(values))
+		for i, v := range values {
+			w.rowTypes[i] = v.Type
+		}
+	}
+	if w.widths != nil {
+		return w.write(w.renderRow(cells))
+	}
+	w.pending = append(w.pending, cells)
+	if w.sampleRows > 0 && len(w.pending) >= w.sampleRows {
+		return w.startTable()
+	}
+	return nil
+}
+
+// Flush writes buffered rows and the bottom border, ending the table. A
+// registered schema with no rows renders a header-only table; a registered
+// zero-column schema writes nothing. With no registered schema and no rows,
+// Flush returns [ErrMissingColumnNames]. After a write failure, Flush returns
+// the latched error (see package doc "Write errors").
+func (w *TableWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	if w.widths == nil {
+		if err := w.startTable(); err != nil {
+			return err
+		}
+	}
+	err := w.write(w.renderRule(w.borders().bottom))
+	w.widths = nil
+	return err
+}
+
+// FormatConfig returns the effective formatter used for table cells.
+// When no formatter is configured, this returns [spanvalue.SpannerCLICompatibleFormatConfig].
+// Configure it only via [NewTableWriter] or [WithFormatter].
+func (w *TableWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return w.formatter
+}
+
+func (w *TableWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+	w.rowTypes = nil
+}
+
+func (w *TableWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+	w.rowTypes = nil
+}
+
+func (w *TableWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
+
+func (w *TableWriter) borders() tableBorders {
+	return tableStyleBorders[w.style]
+}
+
+// headerCells returns the header labels, with types when [WithTypedHeader] is set.
+func (w *TableWriter) headerCells() ([]string, error) {
+	types := w.schema.types
+	if len(types) == 0 {
+		types = w.rowTypes
+	}
+	return columnLabels(w.schema.names, types, w.unnamedFieldNamer, w.typedHeader)
+}
+
+// columnLabels resolves unnamed columns with namer (when non-nil) and, when
+// typed is set, appends each column's type as in "id INT64". Columns
+// without a known type keep the bare name.
+func columnLabels(names []string, types []*sppb.Type, namer spanvalue.UnnamedFieldNamer, typed bool) ([]string, error) {
+	if namer != nil {
+		resolved, err := internal.ResolveColumnNames(names, namer)
+		if err != nil {
+			return nil, err
+		}
+		names = resolved
+	}
+	if !typed {
+		return names, nil
+	}
+	labels := make([]string, len(names))
+	for i, name := range names {
+		labels[i] = name
+		if i < len(types) && types[i] != nil {
+			labels[i] = strings.TrimLeft(name+" "+spantype.FormatTypeVerbose(types[i]), " ")
+		}
+	}
+	return labels, nil
+}
+
+// startTable fixes column widths from the header and pending rows, then
+// writes the top border, the header, and the pending rows.
+func (w *TableWriter) startTable() error {
+	header, err := w.headerCells()
+	if err != nil {
+		return err
+	}
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = cellWidth(cell)
+	}
+	for _, row := range w.pending {
+		for i, cell := range row {
+			widths[i] = max(widths[i], cellWidth(cell))
+		}
+	}
+	w.widths = widths
+
+	var b strings.Builder
+	b.WriteString(w.renderRule(w.borders().top))
+	b.WriteString(w.renderRow(header))
+	if w.style == TableStyleMinimal {
+		dashes := make([]string, len(widths))
+		for i, n := range widths {
+			dashes[i] = strings.Repeat("-", n)
+		}
+		b.WriteString(w.renderRow(dashes))
+	} else {
+		b.WriteString(w.renderRule(w.borders().middle))
+	}
+	for _, row := range w.pending {
+		b.WriteString(w.renderRow(row))
+	}
+	w.pending = nil
+	return w.write(b.String())
+}
+
+// renderRule renders a horizontal border line; empty for TableStyleMinimal.
+func (w *TableWriter) renderRule(glyphs [3]string) string {
+	if w.style == TableStyleMinimal {
+		return ""
+	}
+	borders := w.borders()
+	var b strings.Builder
+	b.WriteString(glyphs[0])
+	for i, n := range w.widths {
+		if i > 0 {
+			b.WriteString(glyphs[1])
+		}
+		b.WriteString(strings.Repeat(borders.fill, n+2*len(borders.padding)))
+	}
+	b.WriteString(glyphs[2])
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// renderRow renders one logical row, which spans as many lines as its
+// tallest cell after wrapping to the column widths.
+func (w *TableWriter) renderRow(cells []string) string {
+	lines := make([][]string, len(cells))
+	height := 1
+	for i, cell := range cells {
+		lines[i] = wrapCell(cell, w.widths[i])
+		height = max(height, len(lines[i]))
+	}
+	borders := w.borders()
+	var b strings.Builder
+	for line := range height {
+		var row strings.Builder
+		if w.style != TableStyleMinimal {
+			row.WriteString(borders.vertical)
+		}
+		for i := range cells {
+			text := ""
+			if line < len(lines[i]) {
+				text = lines[i][line]
+			}
+			// A rune wider than a streamed column, or any text in a zero-width
+			// column, overflows the column rather than being cut.
+			pad := strings.Repeat(" ", max(0, w.widths[i]-displayWidth(text)))
+			if w.style == TableStyleMinimal {
+				if i > 0 {
+					row.WriteString("  ")
+				}
+				row.WriteString(text + pad)
+				continue
+			}
+			row.WriteString(borders.padding + text + pad + borders.padding + borders.vertical)
+		}
+		if w.style == TableStyleMinimal {
+			b.WriteString(strings.TrimRight(row.String(), " "))
+		} else {
+			b.WriteString(row.String())
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (w *TableWriter) write(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := io.WriteString(w.out, s)
+	return w.latchWriteErr(err)
+}
+
+// cellWidth returns the display width of the widest line of cell.
+func cellWidth(cell string) int {
+	n := 0
+	for line := range strings.SplitSeq(cell, "\n") {
+		n = max(n, displayWidth(line))
+	}
+	return n
+}
+
+// wrapCell splits cell into lines at newlines and then wraps each line to
+// at most maxWidth display columns. A single rune wider than maxWidth gets a
+// line of its own.
+func wrapCell(cell string, maxWidth int) []string {
+	var out []string
+	for line := range strings.SplitSeq(cell, "\n") {
+		if displayWidth(line) <= maxWidth {
+			out = append(out, line)
+			continue
+		}
+		var current strings.Builder
+		n := 0
+		for _, r := range line {
+			rw := runeWidth(r)
+			if n+rw > maxWidth && n > 0 {
+				out = append(out, current.String())
+				current.Reset()
+				n = 0
+			}
+			current.WriteRune(r)
+			n += rw
+		}
+		out = append(out, current.String())
+	}
+	return out
+}
+
+// displayWidth returns the number of terminal columns s occupies: East Asian
+// wide and fullwidth runes count as two, combining marks and other
+// zero-width runes as zero.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r < 0x20 || r == 0x7f:
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
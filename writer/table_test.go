@@ -0,0 +1,242 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/spantype/typector"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*TableWriter)(nil)
+
+func tableTestRowType() *sppb.StructType {
+	return typector.MustNameCodeSlicesToStructType(
+		[]string{"id", "name"},
+		[]sppb.TypeCode{sppb.TypeCode_INT64, sppb.TypeCode_STRING},
+	).GetStructType()
+}
+
+func writeTableRows(t *testing.T, w *TableWriter, rows ...[]spanner.GenericColumnValue) {
+	t.Helper()
+	for _, row := range rows {
+		if err := w.WriteGCVs(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTableWriter_styles(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]spanner.GenericColumnValue{
+		{gcvctor.Int64Value(1), gcvctor.StringValue("Alice")},
+		{gcvctor.Int64Value(10), gcvctor.NullFromCode(sppb.TypeCode_STRING)},
+	}
+	tests := []struct {
+		name    string
+		options []TableOption
+		want    string
+	}{
+		{
+			name: "ascii",
+			want: "+----+-------+\n" +
+				"| id | name  |\n" +
+				"+----+-------+\n" +
+				"| 1  | Alice |\n" +
+				"| 10 | NULL  |\n" +
+				"+----+-------+\n",
+		},
+		{
+			name:    "unicode typed header",
+			options: []TableOption{WithTableStyle(TableStyleUnicode), WithTypedHeader(true)},
+			want: "┌──────────┬─────────────┐\n" +
+				"│ id INT64 │ name STRING │\n" +
+				"├──────────┼─────────────┤\n" +
+				"│ 1        │ Alice       │\n" +
+				"│ 10       │ NULL        │\n" +
+				"└──────────┴─────────────┘\n",
+		},
+		{
+			name:    "minimal",
+			options: []TableOption{WithTableStyle(TableStyleMinimal)},
+			want: "id  name\n" +
+				"--  -----\n" +
+				"1   Alice\n" +
+				"10  NULL\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			w := mustNewTableWriter(t, &out, append([]TableOption{WithRowType(tableTestRowType())}, tt.options...)...)
+			writeTableRows(t, w, rows...)
+			if got := out.String(); got != tt.want {
+				t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableWriter_displayWidthAndMultiline(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewTableWriter(t, &out, WithRowType(tableTestRowType()))
+	writeTableRows(t, w,
+		[]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("日本語")},
+		[]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("a\nbc")},
+		[]spanner.GenericColumnValue{gcvctor.Int64Value(3), gcvctor.StringValue("é")},
+	)
+	want := "+----+--------+\n" +
+		"| id | name   |\n" +
+		"+----+--------+\n" +
+		"| 1  | 日本語 |\n" +
+		"| 2  | a      |\n" +
+		"|    | bc     |\n" +
+		"| 3  | é      |\n" +
+		"+----+--------+\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTableWriter_sampleRowsStreams(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewTableWriter(t, &out, WithRowType(tableTestRowType()), WithTableSampleRows(1))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("ab")}); err != nil {
+		t.Fatal(err)
+	}
+	head := "+----+------+\n" +
+		"| id | name |\n" +
+		"+----+------+\n" +
+		"| 1  | ab   |\n"
+	if got := out.String(); got != head {
+		t.Fatalf("output after sample window mismatch\ngot:\n%s\nwant:\n%s", got, head)
+	}
+	writeTableRows(t, w, []spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("abcdef")})
+	want := head +
+		"| 2  | abcd |\n" +
+		"|    | ef   |\n" +
+		"+----+------+\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTableWriter_sampleRowsOverflow(t *testing.T) {
+	t.Parallel()
+
+	// Streamed cells that cannot wrap within the fixed widths overflow
+	// their column instead of panicking.
+	tests := []struct {
+		name   string
+		column string
+		values []string
+		want   string
+	}{
+		{
+			name:   "wide rune",
+			column: "a",
+			values: []string{"x", "日本"},
+			want:   "+---+\n| a |\n+---+\n| x |\n| 日 |\n| 本 |\n+---+\n",
+		},
+		{
+			name:   "zero-width column",
+			column: "",
+			values: []string{"", "x"},
+			want:   "+--+\n|  |\n+--+\n|  |\n| x |\n+--+\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w := mustNewTableWriter(t, &out, WithColumnNames([]string{tt.column}), WithTableSampleRows(1))
+			var rows [][]spanner.GenericColumnValue
+			for _, v := range tt.values {
+				rows = append(rows, []spanner.GenericColumnValue{gcvctor.StringValue(v)})
+			}
+			writeTableRows(t, w, rows...)
+			if got := out.String(); got != tt.want {
+				t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableWriter_namesOnlyAndEmpty(t *testing.T) {
+	t.Parallel()
+
+	// Typed header types come from the first row when only names are known.
+	var out bytes.Buffer
+	w := mustNewTableWriter(t, &out, WithTypedHeader(true))
+	if err := w.WriteValues([]string{"n"}, []spanner.GenericColumnValue{gcvctor.Int64Value(5)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "+---------+\n| n INT64 |\n+---------+\n| 5       |\n+---------+\n"
+	if got := out.String(); got != want {
+		t.Errorf("names-only output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	w = mustNewTableWriter(t, &out, WithRowType(tableTestRowType()))
+	writeTableRows(t, w)
+	want = "+----+------+\n| id | name |\n+----+------+\n+----+------+\n"
+	if got := out.String(); got != want {
+		t.Errorf("header-only output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	w = mustNewTableWriter(t, &out, WithRowType(nil))
+	writeTableRows(t, w)
+	if out.Len() != 0 {
+		t.Errorf("zero-column output = %q, want empty", out.String())
+	}
+
+	w = mustNewTableWriter(t, &out)
+	if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+	}
+}
+
+func TestTableWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if _, err := NewTableWriter(&out, WithTableStyle(TableStyle(99))); !errors.Is(err, ErrInvalidTableStyle) {
+		t.Errorf("invalid style error = %v, want ErrInvalidTableStyle", err)
+	}
+	if _, err := NewTableWriter(&out, WithTableSampleRows(-1)); !errors.Is(err, ErrInvalidSampleRows) {
+		t.Errorf("negative sample rows error = %v, want ErrInvalidSampleRows", err)
+	}
+	if _, err := NewTableWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("nil output error = %v, want ErrNilOutputWriter", err)
+	}
+
+	fw := &failNthWrite{n: 1}
+	w := mustNewTableWriter(t, fw, WithRowType(tableTestRowType()))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Fatalf("Flush error = %v, want errInjected", err)
+	}
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+		t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+	}
+}
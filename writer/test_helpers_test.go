@@ -40,3 +40,129 @@ func mustNewSQLInsertWriter(t *testing.T, out io.Writer, table string, options .
 	}
 	return w
 }
+
+func mustNewTableWriter(t *testing.T, out io.Writer, options ...TableOption) *TableWriter {
+	t.Helper()
+	w, err := NewTableWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewVerticalWriter(t *testing.T, out io.Writer, options ...VerticalOption) *VerticalWriter {
+	t.Helper()
+	w, err := NewVerticalWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewMarkdownWriter(t *testing.T, out io.Writer, options ...MarkdownOption) *MarkdownWriter {
+	t.Helper()
+	w, err := NewMarkdownWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewHTMLWriter(t *testing.T, out io.Writer, options ...HTMLOption) *HTMLWriter {
+	t.Helper()
+	w, err := NewHTMLWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewJSONWriter(t *testing.T, out io.Writer, options ...JSONOption) *JSONWriter {
+	t.Helper()
+	w, err := NewJSONWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewColumnarJSONWriter(t *testing.T, out io.Writer, options ...ColumnarJSONOption) *ColumnarJSONWriter {
+	t.Helper()
+	w, err := NewColumnarJSONWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewParquetWriter(t *testing.T, out io.Writer, options ...ParquetOption) *ParquetWriter {
+	t.Helper()
+	w, err := NewParquetWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewAvroWriter(t *testing.T, out io.Writer, table string, options ...AvroOption) *AvroWriter {
+	t.Helper()
+	w, err := NewAvroWriter(out, table, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewArrowWriter(t *testing.T, out io.Writer, options ...ArrowOption) *ArrowWriter {
+	t.Helper()
+	w, err := NewArrowWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewXLSXWriter(t *testing.T, out io.Writer, options ...XLSXOption) *XLSXWriter {
+	t.Helper()
+	w, err := NewXLSXWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewPGCopyWriter(t *testing.T, out io.Writer, options ...PGCopyOption) *PGCopyWriter {
+	t.Helper()
+	w, err := NewPGCopyWriter(out, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewRawDelimitedWriter(t *testing.T, out io.Writer, delimiter rune, options ...RawDelimitedOption) *RawDelimitedWriter {
+	t.Helper()
+	w, err := NewRawDelimitedWriter(out, delimiter, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewSQLUpdateWriter(t *testing.T, out io.Writer, table string, keyColumns []string, options ...SQLUpdateOption) *SQLUpdateWriter {
+	t.Helper()
+	w, err := NewSQLUpdateWriter(out, table, keyColumns, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func mustNewSQLDeleteWriter(t *testing.T, out io.Writer, table string, keyColumns []string, options ...SQLDeleteOption) *SQLDeleteWriter {
+	t.Helper()
+	w, err := NewSQLDeleteWriter(out, table, keyColumns, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
@@ -0,0 +1,223 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+)
+
+// VerticalOption configures a VerticalWriter created by [NewVerticalWriter].
+type VerticalOption interface {
+	applyVerticalOption(*VerticalWriter) error
+}
+
+func applyVerticalOptions(w *VerticalWriter, options ...VerticalOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyVerticalOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verticalSeparatorStars is the run of asterisks on each side of a
+// record separator, matching the mysql and spanner-cli \G output.
+const verticalSeparatorStars = "***************************"
+
+// VerticalWriter renders each row as a block of "name: value" lines, like
+// the \G output of mysql and spanner-cli:
+//
+//	*************************** 1. row ***************************
+//	  id: 1
+//	name: Alice
+//
+// Names are right-aligned by display width. Continuation lines of a
+// multi-line value are indented to the value column. Rows are numbered from
+// 1 for the lifetime of the writer and written as they arrive, so Flush only
+// reports the latched write error. A registered zero-column schema writes
+// nothing. Values are formatted with
+// [spanvalue.SpannerCLICompatibleFormatConfig] unless [WithFormatter] is set.
+//
+// Each row block is emitted with a single Write. After the first output write
+// failure, every later Write*/Flush call returns that error; discard the
+// writer (see package doc "Write errors").
+type VerticalWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for field labels.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	typedNames        bool
+
+	schema columnSchema
+	rows   int64
+	out    io.Writer
+}
+
+// NewVerticalWriter returns a vertical writer configured by options.
+func NewVerticalWriter(out io.Writer, options ...VerticalOption) (*VerticalWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &VerticalWriter{
+		formatter: spanvalue.SpannerCLICompatibleFormatConfig(),
+		out:       out,
+	}
+	if err := applyVerticalOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one row block. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *VerticalWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *VerticalWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *VerticalWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one row block; see [DelimitedWriter.WriteValues].
+func (w *VerticalWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one row block; see [DelimitedWriter.WriteStructValues].
+func (w *VerticalWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one row block; see [DelimitedWriter.WriteGCVs].
+func (w *VerticalWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	cells, err := spanvalue.FormatRowColumns(w.FormatConfig(), w.schema.names, values)
+	if err != nil {
+		return err
+	}
+	types := w.schema.types
+	if len(types) == 0 {
+		types = make([]*sppb.Type, len(values))
+		for i, v := range values {
+			types[i] = v.Type
+		}
+	}
+	labels, err := columnLabels(w.schema.names, types, w.unnamedFieldNamer, w.typedNames)
+	if err != nil {
+		return err
+	}
+	labelWidth := 0
+	for _, label := range labels {
+		labelWidth = max(labelWidth, displayWidth(label))
+	}
+	indent := strings.Repeat(" ", labelWidth+2)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d. row %s\n", verticalSeparatorStars, w.rows+1, verticalSeparatorStars)
+	for i, label := range labels {
+		b.WriteString(strings.Repeat(" ", labelWidth-displayWidth(label)))
+		b.WriteString(label)
+		b.WriteString(": ")
+		b.WriteString(strings.ReplaceAll(cells[i], "\n", "\n"+indent))
+		b.WriteByte('\n')
+	}
+	if _, err := io.WriteString(w.out, b.String()); err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.rows++
+	return nil
+}
+
+// Flush returns the latched write error, if any; rows are written as they
+// arrive (see package doc "Write errors").
+func (w *VerticalWriter) Flush() error {
+	return w.writeErr
+}
+
+// FormatConfig returns the effective formatter used for field values.
+// When no formatter is configured, this returns [spanvalue.SpannerCLICompatibleFormatConfig].
+// Configure it only via [NewVerticalWriter] or [WithFormatter].
+func (w *VerticalWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return w.formatter
+}
+
+func (w *VerticalWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *VerticalWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *VerticalWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*VerticalWriter)(nil)
+
+func TestVerticalWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewVerticalWriter(t, &out, WithRowType(tableTestRowType()))
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("Alice")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("line1\nline2")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "*************************** 1. row ***************************\n" +
+		"  id: 1\n" +
+		"name: Alice\n" +
+		"*************************** 2. row ***************************\n" +
+		"  id: 2\n" +
+		"name: line1\n" +
+		"      line2\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestVerticalWriter_typedNamesFromValues(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewVerticalWriter(t, &out, WithTypedHeader(true))
+	if err := w.WriteValues([]string{"id", "名前"}, []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.NullFromCode(sppb.TypeCode_STRING)}); err != nil {
+		t.Fatal(err)
+	}
+	want := "*************************** 1. row ***************************\n" +
+		"   id INT64: 1\n" +
+		"名前 STRING: NULL\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestVerticalWriter_goValues(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewVerticalWriter(t, &out)
+	if _, err := WriteGoValues(w, goValueItem{SKU: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "*************************** 1. row ***************************\nsku: a\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestVerticalWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewVerticalWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("nil output error = %v, want ErrNilOutputWriter", err)
+	}
+	var out bytes.Buffer
+	if err := mustNewVerticalWriter(t, &out).WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1)}); !errors.Is(err, ErrMissingColumnNames) {
+		t.Errorf("WriteGCVs without schema error = %v, want ErrMissingColumnNames", err)
+	}
+
+	fw := &failNthWrite{n: 1}
+	w := mustNewVerticalWriter(t, fw, WithRowType(tableTestRowType()))
+	row := []spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}
+	if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+		t.Fatalf("WriteGCVs error = %v, want errInjected", err)
+	}
+	if err := w.WriteGCVs(row); !errors.Is(err, errInjected) {
+		t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+	}
+	if err := w.Flush(); !errors.Is(err, errInjected) {
+		t.Errorf("Flush after failure error = %v, want errInjected", err)
+	}
+	if fw.buf.Len() != 0 {
+		t.Errorf("output after failure = %q, want empty", fw.buf.String())
+	}
+}
@@ -53,16 +53,162 @@ var (
 	// ErrMismatchedStructValueCount reports that WriteStructValues value count does not match the schema.
 	ErrMismatchedStructValueCount = errors.New("mismatched struct value count")
 	// ErrInvalidSQLInsertKindForDialect reports that [WithSQLInsertKind] selected INSERT OR IGNORE
-	// or INSERT OR UPDATE with a PostgreSQL dialect. Those prefixes are GoogleSQL-only; use plain
-	// [SQLInsert] with [WithSQLDialect](databasepb.DatabaseDialect_POSTGRESQL) instead.
+	// or INSERT OR UPDATE with a PostgreSQL dialect and no [WithSQLConflictTarget]. Those prefixes
+	// are GoogleSQL-only; add a conflict target to write ON CONFLICT upserts instead.
 	ErrInvalidSQLInsertKindForDialect = errors.New("INSERT OR IGNORE/UPDATE not supported for PostgreSQL dialect")
 	// ErrInvalidSQLInsertKind reports that [WithSQLInsertKind] received a [SQLInsertKind]
 	// outside the defined constants ([SQLInsert], [SQLInsertOrIgnore], [SQLInsertOrUpdate]).
 	// [NewSQLInsertWriter] rejects such kinds at construction.
 	ErrInvalidSQLInsertKind = errors.New("invalid SQLInsertKind")
+	// ErrConflictTargetWithoutUpsert reports that [WithSQLConflictTarget] was combined with
+	// plain [SQLInsert]; ON CONFLICT needs [SQLInsertOrIgnore] or [SQLInsertOrUpdate].
+	ErrConflictTargetWithoutUpsert = errors.New("conflict target requires SQLInsertOrIgnore or SQLInsertOrUpdate")
+	// ErrInvalidSQLBatchLimit reports a negative [WithSQLBatchBytes], [WithSQLMaxMutations],
+	// [WithSQLIndexColumnCounts], or [WithSQLTransactionSize] value, a
+	// [WithSQLStatementsPerCall] value below 1, or a [SQLStatementWriter] batch that
+	// would bind more than [MaxSQLStatementParameters] parameters.
+	ErrInvalidSQLBatchLimit = errors.New("invalid SQL batch limit")
+	// ErrSQLStatementTooLarge reports a single row whose INSERT statement exceeds
+	// [WithSQLBatchBytes] or [WithSQLMaxMutations] on its own.
+	ErrSQLStatementTooLarge = errors.New("SQL statement exceeds batch limit")
+	// ErrInvalidColumnOverride reports a [WithSQLColumnOverride] for a column missing from
+	// the schema, a nil transform, or overrides that omit every column or a conflict target.
+	ErrInvalidColumnOverride = errors.New("invalid SQL column override")
+	// ErrInvalidStatementSeparator reports an empty [WithSQLStatementSeparator].
+	ErrInvalidStatementSeparator = errors.New("invalid SQL statement separator")
 	// ErrTableNameChangedMidBatch reports that the SQL INSERT table name was mutated while
 	// a multi-row INSERT batch was open.
 	ErrTableNameChangedMidBatch = errors.New("table name changed mid-batch")
+	// ErrInvalidTableStyle reports that [WithTableStyle] received a [TableStyle]
+	// outside the defined constants.
+	ErrInvalidTableStyle = errors.New("invalid TableStyle")
+	// ErrInvalidSampleRows reports that [WithTableSampleRows] received a negative count.
+	ErrInvalidSampleRows = errors.New("invalid sample rows")
+	// ErrInvalidColumnarJSONLayout reports that [WithColumnarJSONLayout] received a
+	// [ColumnarJSONLayout] outside the defined constants.
+	ErrInvalidColumnarJSONLayout = errors.New("invalid ColumnarJSONLayout")
+	// ErrInvalidChunkRows reports that [WithColumnarJSONChunkRows] received a negative count.
+	ErrInvalidChunkRows = errors.New("invalid chunk rows")
+	// ErrInvalidParquetCompression reports that [WithParquetCompression] received a
+	// [ParquetCompression] outside the defined constants.
+	ErrInvalidParquetCompression = errors.New("invalid ParquetCompression")
+	// ErrInvalidRowGroupRows reports that [WithParquetRowGroupRows] received a negative count.
+	ErrInvalidRowGroupRows = errors.New("invalid row group rows")
+	// ErrDuplicateColumnName reports that a writer requiring unique names, such as
+	// [ParquetWriter], received the same column or STRUCT field name twice.
+	ErrDuplicateColumnName = errors.New("duplicate column name")
+	// ErrUnsupportedParquetType reports a column type [ParquetWriter] cannot map.
+	ErrUnsupportedParquetType = errors.New("unsupported Parquet column type")
+	// ErrParquetTypeMismatch reports that a [ParquetWriter] row value type differs
+	// from the column type of the file.
+	ErrParquetTypeMismatch = errors.New("Parquet column type mismatch")
+	// ErrWriterClosed reports a write after a file-based writer, such as
+	// [ParquetWriter], finished its output in Flush.
+	ErrWriterClosed = errors.New("writer closed")
+	// ErrInvalidAvroCompression reports that [WithAvroCompression] received an
+	// [AvroCompression] outside the defined constants.
+	ErrInvalidAvroCompression = errors.New("invalid AvroCompression")
+	// ErrUnsupportedAvroType reports a column type [AvroWriter] or [AvroReader] cannot map.
+	ErrUnsupportedAvroType = errors.New("unsupported Avro column type")
+	// ErrAvroTypeMismatch reports that an [AvroWriter] row value type differs
+	// from the column type of the file.
+	ErrAvroTypeMismatch = errors.New("Avro column type mismatch")
+	// ErrInvalidAvroName reports that an [AvroWriter] column name is not a valid
+	// Avro name ([A-Za-z_][A-Za-z0-9_]*).
+	ErrInvalidAvroName = errors.New("invalid Avro name")
+	// ErrInvalidAvroSchema reports that [NewAvroReader] read a schema that is
+	// not a record of nullable Spanner columns.
+	ErrInvalidAvroSchema = errors.New("invalid Avro schema")
+	// ErrInvalidBatchRows reports that [WithArrowBatchRows] received a negative count.
+	ErrInvalidBatchRows = errors.New("invalid batch rows")
+	// ErrInvalidArrowIPCFormat reports that [WithArrowIPCFormat] received an
+	// [ArrowIPCFormat] outside the defined constants.
+	ErrInvalidArrowIPCFormat = errors.New("invalid ArrowIPCFormat")
+	// ErrUnsupportedArrowType reports a column type [ArrowWriter] cannot map.
+	ErrUnsupportedArrowType = errors.New("unsupported Arrow column type")
+	// ErrArrowTypeMismatch reports that an [ArrowWriter] row value type differs
+	// from the column type of the batches.
+	ErrArrowTypeMismatch = errors.New("Arrow column type mismatch")
+	// ErrInvalidXLSXSheetName reports that [WithXLSXSheetName] received a name
+	// Excel does not accept.
+	ErrInvalidXLSXSheetName = errors.New("invalid XLSX sheet name")
+	// ErrInvalidSheetRows reports that [WithXLSXSheetRows] received a count
+	// outside 0 to 1,048,575.
+	ErrInvalidSheetRows = errors.New("invalid sheet rows")
+	// ErrTooManyXLSXColumns reports a schema wider than the 16,384 columns of
+	// an Excel worksheet.
+	ErrTooManyXLSXColumns = errors.New("too many XLSX columns")
+	// ErrNilInputReader reports that a reader was constructed without an input.
+	ErrNilInputReader = errors.New("nil input reader")
+	// ErrInvalidDelimitedCellFormat reports that [WithReaderCellFormat] received a
+	// [DelimitedCellFormat] outside the defined constants.
+	ErrInvalidDelimitedCellFormat = errors.New("invalid DelimitedCellFormat")
+	// ErrMissingColumnType reports a header name that [WithReaderColumnTypes] has no
+	// type for.
+	ErrMissingColumnType = errors.New("missing column type")
+	// ErrFieldCount reports a [DelimitedReader] record whose field count differs
+	// from the number of columns.
+	ErrFieldCount = errors.New("wrong number of fields")
+	// ErrInvalidCell reports [DelimitedReader] cell text or a [JSONLReader] value
+	// that does not parse as a value of the column type.
+	ErrInvalidCell = errors.New("invalid cell")
+	// ErrUnsupportedCellType reports a column type that [DelimitedReader] or
+	// [JSONLReader] cannot parse.
+	ErrUnsupportedCellType = errors.New("unsupported cell type")
+	// ErrInvalidJSONLRecord reports a [JSONLReader] line that is not a single JSON object.
+	ErrInvalidJSONLRecord = errors.New("invalid JSONL record")
+	// ErrMissingJSONLKey reports a [JSONLReader] object without a key for a column,
+	// under [JSONLMissingKeyError].
+	ErrMissingJSONLKey = errors.New("missing JSONL key")
+	// ErrUnknownJSONLKey reports a [JSONLReader] object key that matches no column,
+	// under [JSONLExtraKeyError].
+	ErrUnknownJSONLKey = errors.New("unknown JSONL key")
+	// ErrInvalidJSONLKeyPolicy reports that [WithJSONLMissingKeys] or
+	// [WithJSONLExtraKeys] received a policy outside the defined constants.
+	ErrInvalidJSONLKeyPolicy = errors.New("invalid JSONL key policy")
+	// ErrInvalidPGCopyFormat reports that [WithPGCopyFormat] received a format
+	// outside the defined constants.
+	ErrInvalidPGCopyFormat = errors.New("invalid PostgreSQL COPY format")
+	// ErrUnsupportedPGCopyType reports a column type, such as STRUCT, that
+	// [PGCopyWriter] cannot write as PostgreSQL input.
+	ErrUnsupportedPGCopyType = errors.New("unsupported PostgreSQL COPY type")
+	// ErrInvalidRawEscapePolicy reports that [WithRawEscapePolicy] received a policy
+	// outside the defined constants.
+	ErrInvalidRawEscapePolicy = errors.New("invalid raw escape policy")
+	// ErrInvalidRawReplacement reports a [WithRawReplacement] string that contains the
+	// delimiter, CR, LF, or a line-terminator character.
+	ErrInvalidRawReplacement = errors.New("invalid raw replacement")
+	// ErrInvalidLineTerminator reports an empty [WithRawLineTerminator] string, or one
+	// that contains the delimiter.
+	ErrInvalidLineTerminator = errors.New("invalid line terminator")
+	// ErrRawDelimitedSpecialChar reports a field containing the delimiter, CR, LF, or a
+	// line-terminator character under [RawEscapeReject].
+	ErrRawDelimitedSpecialChar = errors.New("field contains delimiter or line break")
+	// ErrInvalidMutationKind reports that [WithMutationKind] received a kind outside the
+	// defined constants.
+	ErrInvalidMutationKind = errors.New("invalid mutation kind")
+	// ErrInvalidMutationBatchLimit reports a [WithMutationBatchCount] or
+	// [WithMutationBatchBytes] value below 1.
+	ErrInvalidMutationBatchLimit = errors.New("invalid mutation batch limit")
+	// ErrMutationTooLarge reports a single row whose mutation exceeds a
+	// [MutationWriter] batch limit on its own.
+	ErrMutationTooLarge = errors.New("mutation exceeds batch limit")
+	// ErrMissingKeyColumn reports a [WithMutationKeyColumns] or [WithSQLConflictTarget] name,
+	// or a [NewSQLUpdateWriter]/[NewSQLDeleteWriter] key column, that is not a registered column.
+	ErrMissingKeyColumn = errors.New("key column not in schema")
+	// ErrNilMutationSink reports that [NewMutationWriter] received a nil sink.
+	ErrNilMutationSink = errors.New("nil mutation sink")
+	// ErrNoKeyColumns reports that [NewSQLUpdateWriter] or [NewSQLDeleteWriter]
+	// received no key columns.
+	ErrNoKeyColumns = errors.New("no key columns")
+	// ErrNoUpdateColumns reports a [SQLUpdateWriter] schema, or a [SQLInsertOrUpdate]
+	// [WithSQLConflictTarget] schema, whose columns are all key columns, leaving nothing to SET.
+	ErrNoUpdateColumns = errors.New("no non-key columns to update")
+	// ErrNilStatementSink reports that [NewSQLStatementWriter] received a nil sink.
+	ErrNilStatementSink = errors.New("nil statement sink")
+	// ErrInvalidSQLStatementBatchForm reports a [WithSQLStatementBatchForm] value outside
+	// the defined constants, or [SQLBatchUnnest] with the PostgreSQL dialect.
+	ErrInvalidSQLStatementBatchForm = errors.New("invalid SQL statement batch form")
 )
 
 // Writer writes Spanner rows to an output stream.
@@ -113,12 +259,43 @@ type Option interface {
 	DelimitedOption
 	JSONLOption
 	SQLInsertOption
-}
-
-// NameOption configures field-name handling for delimited and JSONL writers.
+	TableOption
+	VerticalOption
+	MarkdownOption
+	HTMLOption
+	JSONOption
+	ColumnarJSONOption
+	ParquetOption
+	AvroOption
+	ArrowOption
+	XLSXOption
+	PGCopyOption
+	RawDelimitedOption
+	MutationOption
+	SQLUpdateOption
+	SQLDeleteOption
+	SQLStatementOption
+}
+
+// NameOption configures field-name handling for every writer except the SQL DML writers
+// ([SQLInsertWriter], [SQLUpdateWriter], [SQLDeleteWriter], [SQLStatementWriter]) and
+// [MutationWriter], which need real column names, and for [JSONLReader].
 type NameOption interface {
 	DelimitedOption
 	JSONLOption
+	JSONLReaderOption
+	TableOption
+	VerticalOption
+	MarkdownOption
+	HTMLOption
+	JSONOption
+	ColumnarJSONOption
+	ParquetOption
+	AvroOption
+	ArrowOption
+	XLSXOption
+	PGCopyOption
+	RawDelimitedOption
 }
 
 // DelimitedOption configures a DelimitedWriter created by [NewDelimitedWriter] or [NewCSVWriter].
@@ -138,8 +315,10 @@ type JSONLOption interface {
 // cannot be combined with ON CONFLICT in the same statement, and INSERT is not
 // supported in Partitioned DML. See https://cloud.google.com/spanner/docs/reference/standard-sql/dml-syntax .
 //
-// [SQLInsertOrIgnore] and [SQLInsertOrUpdate] are invalid with
-// [WithSQLDialect](databasepb.DatabaseDialect_POSTGRESQL); [NewSQLInsertWriter] rejects
+// With [WithSQLConflictTarget], the same kinds are written as a plain INSERT followed by
+// ON CONFLICT (target) DO NOTHING or DO UPDATE SET col = EXCLUDED.col, which both dialects
+// accept. Without a conflict target, [SQLInsertOrIgnore] and [SQLInsertOrUpdate] are invalid
+// with [WithSQLDialect](databasepb.DatabaseDialect_POSTGRESQL); [NewSQLInsertWriter] rejects
 // that combination at construction via [ErrInvalidSQLInsertKindForDialect].
 type SQLInsertKind int
 
@@ -181,18 +360,53 @@ func (o sqlInsertKindOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+// WithSQLConflictTarget writes [SQLInsertOrIgnore] and [SQLInsertOrUpdate] as
+// INSERT ... ON CONFLICT (columns) DO NOTHING or DO UPDATE SET, updating every column
+// outside columns from EXCLUDED, instead of the GoogleSQL INSERT OR prefixes. columns are
+// usually the primary key. It is required for upserts with the PostgreSQL dialect and
+// optional for GoogleSQL. [NewSQLInsertWriter] rejects an empty name with
+// [ErrEmptyColumnName] and plain [SQLInsert] with [ErrConflictTargetWithoutUpsert]; columns
+// missing from the registered schema return [ErrMissingKeyColumn], and a DO UPDATE
+// with no other column returns [ErrNoUpdateColumns].
+func WithSQLConflictTarget(columns ...string) SQLInsertOption {
+	return sqlConflictTargetOption{columns: slices.Clone(columns)}
+}
+
+type sqlConflictTargetOption struct {
+	columns []string
+}
+
+func (o sqlConflictTargetOption) applySQLInsertOption(w *SQLInsertWriter) error {
+	w.conflictTarget = o.columns
+	return nil
+}
+
 type sqlDialectOption struct {
 	dialect databasepb.DatabaseDialect
 }
 
-// WithSQLDialect sets identifier quoting for table and column names in SQL INSERT
-// output. It does not change INSERT statement prefixes ([WithSQLInsertKind]) or
-// value literal formatting ([WithFormatter]). The default is GoogleSQL
-// ([databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL]).
+// DialectOption configures the writers whose output depends on the database
+// dialect: [SQLInsertWriter], [SQLUpdateWriter], [SQLDeleteWriter], [SQLStatementWriter],
+// and [AvroWriter].
+type DialectOption interface {
+	SQLInsertOption
+	SQLUpdateOption
+	SQLDeleteOption
+	SQLStatementOption
+	AvroOption
+}
+
+// WithSQLDialect sets identifier quoting for table and column names in SQL INSERT,
+// UPDATE, and DELETE output, the form of batched DELETE key lists, and the
+// [SQLStatementWriter] placeholder style (@p1 or $1). It does not change INSERT statement
+// prefixes ([WithSQLInsertKind]) or value literal formatting ([WithFormatter]). The default is GoogleSQL
+// ([databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL]). For [AvroWriter] it selects
+// the type names written to "sqlType" and the quoting of [WithAvroPrimaryKey].
 //
 // PostgreSQL dialect does not support [SQLInsertOrIgnore] or [SQLInsertOrUpdate]
-// prefixes; combining them returns [ErrInvalidSQLInsertKindForDialect] at construction.
-func WithSQLDialect(dialect databasepb.DatabaseDialect) SQLInsertOption {
+// prefixes; combining them without [WithSQLConflictTarget] returns
+// [ErrInvalidSQLInsertKindForDialect] at construction.
+func WithSQLDialect(dialect databasepb.DatabaseDialect) DialectOption {
 	return sqlDialectOption{dialect: dialect}
 }
 
@@ -201,6 +415,26 @@ func (o sqlDialectOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o sqlDialectOption) applySQLUpdateOption(w *SQLUpdateWriter) error {
+	w.sqlDialect = o.dialect
+	return nil
+}
+
+func (o sqlDialectOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	w.sqlDialect = o.dialect
+	return nil
+}
+
+func (o sqlDialectOption) applySQLStatementOption(w *SQLStatementWriter) error {
+	w.sqlDialect = o.dialect
+	return nil
+}
+
+func (o sqlDialectOption) applyAvroOption(w *AvroWriter) error {
+	w.dialect = o.dialect
+	return nil
+}
+
 // WithSQLBatchSize sets how many rows [SQLInsertWriter] combines into one INSERT
 // statement. Values 0 or 1 keep the default of one row per statement. Values greater
 // than 1 emit multi-row INSERT ... VALUES (...), (...); up to n rows per statement.
@@ -209,14 +443,27 @@ func (o sqlDialectOption) applySQLInsertOption(w *SQLInsertWriter) error {
 // Call [SQLInsertWriter.Flush] after the final row to close a partial batch (Flush is
 // also safe when the last batch closed exactly on a size boundary).
 //
-// Batching applies the same [SQLInsertKind] prefix once per batched statement. Multi-row
-// INSERT OR IGNORE and INSERT OR UPDATE follow Spanner GoogleSQL DML rules and require
-// GoogleSQL dialect; PostgreSQL rejects those prefixes ([ErrInvalidSQLInsertKindForDialect]).
+// Batching applies the same [SQLInsertKind] prefix, or [WithSQLConflictTarget] clause, once
+// per batched statement. Multi-row INSERT OR IGNORE and INSERT OR UPDATE follow Spanner
+// GoogleSQL DML rules and require GoogleSQL dialect; PostgreSQL rejects those prefixes
+// ([ErrInvalidSQLInsertKindForDialect]).
 // Identifier quoting follows [WithSQLDialect]; value literals still use [WithFormatter].
-func WithSQLBatchSize(n int) SQLInsertOption {
+//
+// For [SQLDeleteWriter], n rows share one DELETE whose WHERE clause lists their keys;
+// see [NewSQLDeleteWriter]. For [SQLStatementWriter], n rows share one parameterized
+// statement; see [WithSQLStatementBatchForm].
+func WithSQLBatchSize(n int) SQLBatchOption {
 	return sqlBatchSizeOption{batchSize: n}
 }
 
+// SQLBatchOption configures the SQL writers that combine rows into one statement:
+// [SQLInsertWriter], [SQLDeleteWriter], and [SQLStatementWriter].
+type SQLBatchOption interface {
+	SQLInsertOption
+	SQLDeleteOption
+	SQLStatementOption
+}
+
 type sqlBatchSizeOption struct {
 	batchSize int
 }
@@ -226,6 +473,16 @@ func (o sqlBatchSizeOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o sqlBatchSizeOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	w.batchSize = o.batchSize
+	return nil
+}
+
+func (o sqlBatchSizeOption) applySQLStatementOption(w *SQLStatementWriter) error {
+	w.batchSize = o.batchSize
+	return nil
+}
+
 // SQLInsertOption configures a SQLInsertWriter created by [NewSQLInsertWriter].
 type SQLInsertOption interface {
 	applySQLInsertOption(*SQLInsertWriter) error
@@ -302,6 +559,86 @@ func (o metadataOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o metadataOption) applyTableOption(w *TableWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyVerticalOption(w *VerticalWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyMarkdownOption(w *MarkdownWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyHTMLOption(w *HTMLWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyJSONOption(w *JSONWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyParquetOption(w *ParquetWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyAvroOption(w *AvroWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyArrowOption(w *ArrowWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyXLSXOption(w *XLSXWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyPGCopyOption(w *PGCopyWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applyMutationOption(w *MutationWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applySQLUpdateOption(w *SQLUpdateWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
+func (o metadataOption) applySQLStatementOption(w *SQLStatementWriter) error {
+	w.setRowType(rowTypeFromMetadata(o.metadata))
+	return nil
+}
+
 type rowTypeOption struct {
 	rowType *sppb.StructType
 }
@@ -327,6 +664,86 @@ func (o rowTypeOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o rowTypeOption) applyTableOption(w *TableWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyVerticalOption(w *VerticalWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyMarkdownOption(w *MarkdownWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyHTMLOption(w *HTMLWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyJSONOption(w *JSONWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyParquetOption(w *ParquetWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyAvroOption(w *AvroWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyArrowOption(w *ArrowWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyXLSXOption(w *XLSXWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyPGCopyOption(w *PGCopyWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applyMutationOption(w *MutationWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applySQLUpdateOption(w *SQLUpdateWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
+func (o rowTypeOption) applySQLStatementOption(w *SQLStatementWriter) error {
+	w.setRowType(o.rowType)
+	return nil
+}
+
 type columnNamesOption struct {
 	names []string
 }
@@ -362,15 +779,146 @@ func (o columnNamesOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o columnNamesOption) applyTableOption(w *TableWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyVerticalOption(w *VerticalWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyMarkdownOption(w *MarkdownWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyHTMLOption(w *HTMLWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyJSONOption(w *JSONWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyParquetOption(w *ParquetWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyAvroOption(w *AvroWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyArrowOption(w *ArrowWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyXLSXOption(w *XLSXWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyPGCopyOption(w *PGCopyWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applyMutationOption(w *MutationWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applySQLUpdateOption(w *SQLUpdateWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
+func (o columnNamesOption) applySQLStatementOption(w *SQLStatementWriter) error {
+	if len(o.names) == 0 {
+		return ErrMissingColumnNames
+	}
+	w.setColumnNames(o.names)
+	return nil
+}
+
 type formatterOption struct {
 	formatter *spanvalue.FormatConfig
 }
 
 // WithFormatter sets the FormatConfig used by a writer.
 // A nil formatter selects the writer-type default:
-// [DelimitedWriter] uses [spanvalue.SimpleFormatConfig],
-// [JSONLWriter] uses [spanvalue.JSONFormatConfig],
-// and [SQLInsertWriter] uses [spanvalue.LiteralFormatConfig].
+// [DelimitedWriter] and [RawDelimitedWriter] use [spanvalue.SimpleFormatConfig],
+// [JSONLWriter], [JSONWriter], and [ColumnarJSONWriter] use [spanvalue.JSONFormatConfig],
+// [SQLInsertWriter], [SQLUpdateWriter], and [SQLDeleteWriter] use [spanvalue.LiteralFormatConfig],
+// and the display writers ([TableWriter], [VerticalWriter], [MarkdownWriter], [HTMLWriter])
+// use [spanvalue.SpannerCLICompatibleFormatConfig].
+// [ParquetWriter], [AvroWriter], [ArrowWriter], [PGCopyWriter], and [MutationWriter] write typed values and ignore the formatter.
 // Writers do not call [*spanvalue.FormatConfig.Validate] on the supplied config;
 // validate hand-built formatters before construction when early failure is desired.
 func WithFormatter(formatter *spanvalue.FormatConfig) Option {
@@ -404,11 +952,135 @@ func (o formatterOption) applySQLInsertOption(w *SQLInsertWriter) error {
 	return nil
 }
 
+func (o formatterOption) applyTableOption(w *TableWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyVerticalOption(w *VerticalWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyMarkdownOption(w *MarkdownWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyHTMLOption(w *HTMLWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyJSONOption(w *JSONWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.JSONFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.JSONFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyXLSXOption(w *XLSXWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.SimpleFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applySQLUpdateOption(w *SQLUpdateWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.LiteralFormatConfig()
+	}
+	return nil
+}
+
+func (o formatterOption) applySQLDeleteOption(w *SQLDeleteWriter) error {
+	if o.formatter != nil {
+		w.formatter = o.formatter
+	} else {
+		w.formatter = spanvalue.LiteralFormatConfig()
+	}
+	return nil
+}
+
+// applySQLStatementOption is a no-op: [SQLStatementWriter] binds wire values as
+// parameters, not text.
+func (o formatterOption) applySQLStatementOption(*SQLStatementWriter) error {
+	return nil
+}
+
+// applyArrowOption is a no-op: [ArrowWriter] writes typed values, not text.
+func (o formatterOption) applyArrowOption(*ArrowWriter) error {
+	return nil
+}
+
+// applyAvroOption is a no-op: [AvroWriter] writes typed values, not text.
+func (o formatterOption) applyAvroOption(*AvroWriter) error {
+	return nil
+}
+
+// applyParquetOption is a no-op: [ParquetWriter] writes typed values, not text.
+func (o formatterOption) applyParquetOption(*ParquetWriter) error {
+	return nil
+}
+
+// applyPGCopyOption is a no-op: [PGCopyWriter] writes PostgreSQL input syntax,
+// not spanvalue formats.
+func (o formatterOption) applyPGCopyOption(*PGCopyWriter) error {
+	return nil
+}
+
+// applyMutationOption is a no-op: [MutationWriter] sends wire values, not text.
+func (o formatterOption) applyMutationOption(*MutationWriter) error {
+	return nil
+}
+
 type unnamedFieldNamerOption struct {
 	namer spanvalue.UnnamedFieldNamer
 }
 
-// WithUnnamedFieldNamer sets the unnamed-field naming policy for delimited and JSONL writers.
+// WithUnnamedFieldNamer sets the unnamed-field naming policy for every writer except
+// the SQL DML writers and [MutationWriter].
+// [JSONLReader] uses it to match the keys of unnamed columns.
 // The same namer must be passed to [spanvalue.ColumnNames] when resolving display headers
 // outside the writer (for example CLI table output alongside CSV export).
 func WithUnnamedFieldNamer(namer spanvalue.UnnamedFieldNamer) NameOption {
@@ -425,6 +1097,71 @@ func (o unnamedFieldNamerOption) applyJSONLOption(w *JSONLWriter) error {
 	return nil
 }
 
+func (o unnamedFieldNamerOption) applyJSONLReaderOption(r *JSONLReader) error {
+	r.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyTableOption(w *TableWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyVerticalOption(w *VerticalWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyMarkdownOption(w *MarkdownWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyHTMLOption(w *HTMLWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyJSONOption(w *JSONWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyColumnarJSONOption(w *ColumnarJSONWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyParquetOption(w *ParquetWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyAvroOption(w *AvroWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyArrowOption(w *ArrowWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyXLSXOption(w *XLSXWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyPGCopyOption(w *PGCopyWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
+func (o unnamedFieldNamerOption) applyRawDelimitedOption(w *RawDelimitedWriter) error {
+	w.unnamedFieldNamer = o.namer
+	return nil
+}
+
 // WithFlushEachRow configures [DelimitedWriter] to flush the underlying encoding/csv
 // buffer after each successful data row. Use for interactive streaming when consumers
 // should see output before the export finishes; the default buffers until [Flusher.Flush].
@@ -1050,10 +1787,26 @@ type SQLInsertWriter struct {
 	formatter *spanvalue.FormatConfig
 
 	insertKind        SQLInsertKind
+	conflictTarget    []string
+	conflictClause    string
+	conflictColumns   string
+	columnOverrides   map[string]SQLColumnOverride
 	sqlDialect        databasepb.DatabaseDialect
 	batchSize         int
+	batchBytes        int
+	maxMutations      int
+	indexColumns      int
 	batchPending      int
+	batchMutations    int
+	batchTxBase       int
 	batch             strings.Builder
+	separator         string
+	txSize            int
+	txOpen            bool
+	txStatements      int
+	txMutations       int
+	header            []string
+	headerWritten     bool
 	schema            columnSchema
 	quotedColumnNames string
 	quotedTable       string
@@ -1083,6 +1836,9 @@ func NewSQLInsertWriter(out io.Writer, table string, options ...SQLInsertOption)
 		if _, err := w.initOrValidateQuotedColumns(nil); err != nil {
 			return nil, err
 		}
+		if _, err := w.onConflictClause(); err != nil {
+			return nil, err
+		}
 	}
 	return w, nil
 }
@@ -1099,6 +1855,7 @@ func newSQLInsertWriter(out io.Writer, table string) *SQLInsertWriter {
 		table:      table,
 		formatter:  spanvalue.LiteralFormatConfig(),
 		sqlDialect: databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL,
+		separator:  ";\n",
 		out:        out,
 	}
 }
@@ -1158,13 +1915,14 @@ func (w *SQLInsertWriter) prepareRowType(rowType *sppb.StructType) error {
 		w.setRowType(rowType)
 		return nil
 	}
-	quotedColumns, err := quoteIdentifiers(columnNames, w.sqlDialect)
+	quotedColumns, err := w.insertColumnList(columnNames)
 	if err != nil {
 		return err
 	}
 	w.setRowType(rowType)
-	w.quotedColumnNames = strings.Join(quotedColumns, ", ")
-	return nil
+	w.quotedColumnNames = quotedColumns
+	_, err = w.onConflictClause()
+	return err
 }
 
 func (w *SQLInsertWriter) prepareColumnNames(names []string) error {
@@ -1175,7 +1933,8 @@ func (w *SQLInsertWriter) prepareColumnNames(names []string) error {
 		return err
 	}
 	w.schema.types = nil
-	return nil
+	_, err := w.onConflictClause()
+	return err
 }
 
 func (w *SQLInsertWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
@@ -1219,19 +1978,19 @@ func (w *SQLInsertWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
 	return w.writeGCVs(values, quotedColumns)
 }
 
-// Flush finalizes a partial multi-row INSERT batch started by [WithSQLBatchSize].
-// When batch size is 0 or 1, or when the last batch closed on a size boundary,
-// Flush is a no-op. Flush is safe to call unconditionally after the final row:
-// after a write failure it returns the latched error without writing (see
-// package doc "Write errors").
+// Flush finalizes a partial multi-row INSERT batch started by [WithSQLBatchSize],
+// commits a transaction opened by [WithSQLTransactionSize], and writes a
+// [WithSQLHeaderComment] header when no row has been written. Otherwise Flush is a
+// no-op. Flush is safe to call unconditionally after the final row: after a write
+// failure it returns the latched error without writing (see package doc "Write errors").
 func (w *SQLInsertWriter) Flush() error {
 	if w.writeErr != nil {
 		return w.writeErr
 	}
-	if w.sqlBatchSize() <= 1 || w.batchPending == 0 {
-		return nil
+	if err := w.closePendingBatch(); err != nil {
+		return err
 	}
-	return w.closePendingBatch()
+	return w.finishScript()
 }
 
 // closePendingBatch terminates the buffered multi-row statement and emits it
@@ -1240,12 +1999,20 @@ func (w *SQLInsertWriter) closePendingBatch() error {
 	if w.batchPending == 0 {
 		return nil
 	}
-	w.batch.WriteString(";\n")
-	if _, err := io.WriteString(w.out, w.batch.String()); err != nil {
-		return w.latchWriteErr(err)
+	onConflict, err := w.onConflictClause()
+	if err != nil {
+		return err
+	}
+	if onConflict != "" {
+		w.batch.WriteString("\n")
+		w.batch.WriteString(onConflict)
+	}
+	if err := w.emitStatement(w.batch.String(), w.batchMutations); err != nil {
+		return err
 	}
 	w.batch.Reset()
 	w.batchPending = 0
+	w.batchMutations = 0
 	return nil
 }
 
@@ -1262,6 +2029,15 @@ func (w *SQLInsertWriter) validateSQLInsertConfig() error {
 	default:
 		return fmt.Errorf("%w: %d", ErrInvalidSQLInsertKind, int(w.insertKind))
 	}
+	if len(w.conflictTarget) > 0 {
+		if w.insertKind == SQLInsert {
+			return ErrConflictTargetWithoutUpsert
+		}
+		if slices.Contains(w.conflictTarget, "") {
+			return ErrEmptyColumnName
+		}
+		return nil
+	}
 	if w.sqlDialect != databasepb.DatabaseDialect_POSTGRESQL {
 		return nil
 	}
@@ -1287,6 +2063,10 @@ func (w *SQLInsertWriter) writeGCVs(values []spanner.GenericColumnValue, quotedC
 	if err != nil {
 		return err
 	}
+	formattedValues, err = w.applyColumnOverrides(formattedValues, values)
+	if err != nil {
+		return err
+	}
 	if w.sqlBatchSize() <= 1 {
 		return w.writeSingleInsert(quotedColumns, formattedValues)
 	}
@@ -1300,17 +2080,28 @@ func (w *SQLInsertWriter) writeSingleInsert(quotedColumns string, formattedValue
 	if err != nil {
 		return err
 	}
+	onConflict, err := w.onConflictClause()
+	if err != nil {
+		return err
+	}
 	var b strings.Builder
-	b.WriteString(w.insertKind.String())
+	b.WriteString(w.statementPrefix())
 	b.WriteString(" INTO ")
 	b.WriteString(quotedTable)
 	b.WriteString(" (")
 	b.WriteString(quotedColumns)
 	b.WriteString(") VALUES (")
 	appendFormattedValues(&b, formattedValues)
-	b.WriteString(");\n")
-	_, err = io.WriteString(w.out, b.String())
-	return w.latchWriteErr(err)
+	b.WriteString(")")
+	if onConflict != "" {
+		b.WriteString(" ")
+		b.WriteString(onConflict)
+	}
+	mutations := w.rowMutations()
+	if err := w.checkStatementLimits(b.Len(), mutations); err != nil {
+		return err
+	}
+	return w.emitStatement(b.String(), mutations)
 }
 
 func (w *SQLInsertWriter) rejectTableChangeMidBatch() error {
@@ -1327,24 +2118,52 @@ func (w *SQLInsertWriter) appendBatchedInsert(quotedColumns string, formattedVal
 	if err := w.rejectTableChangeMidBatch(); err != nil {
 		return err
 	}
-	if w.batchPending == 0 {
-		quotedTable, err := w.quotedQualifiedTable()
-		if err != nil {
-			return err
+	quotedTable, err := w.quotedQualifiedTable()
+	if err != nil {
+		return err
+	}
+	onConflict, err := w.onConflictClause()
+	if err != nil {
+		return err
+	}
+	var tuple strings.Builder
+	tuple.WriteString("(")
+	appendFormattedValues(&tuple, formattedValues)
+	tuple.WriteString(")")
+	// Each statement ends with "\nON CONFLICT ..." when a conflict target is set.
+	suffixLen := 0
+	if onConflict != "" {
+		suffixLen = 1 + len(onConflict)
+	}
+	prefixLen := len(w.statementPrefix()) + len(" INTO ") + len(quotedTable) + len(" (") + len(quotedColumns) + len(") VALUES\n  ")
+	mutations := w.rowMutations()
+	if err := w.checkStatementLimits(prefixLen+tuple.Len()+suffixLen, mutations); err != nil {
+		return err
+	}
+	if w.batchPending > 0 {
+		overBytes := w.batchBytes > 0 && w.batch.Len()+len(",\n  ")+tuple.Len()+suffixLen > w.batchBytes
+		overMutations := w.maxMutations > 0 && w.batchTxBase+w.batchMutations+mutations > w.maxMutations
+		if overBytes || overMutations {
+			if err := w.closePendingBatch(); err != nil {
+				return err
+			}
 		}
+	}
+	if w.batchPending == 0 {
+		w.batchTxBase = w.txMutationBase(mutations)
 		w.batch.Reset()
-		w.batch.WriteString(w.insertKind.String())
+		w.batch.WriteString(w.statementPrefix())
 		w.batch.WriteString(" INTO ")
 		w.batch.WriteString(quotedTable)
 		w.batch.WriteString(" (")
 		w.batch.WriteString(quotedColumns)
-		w.batch.WriteString(") VALUES\n  (")
+		w.batch.WriteString(") VALUES\n  ")
 	} else {
-		w.batch.WriteString(",\n  (")
+		w.batch.WriteString(",\n  ")
 	}
-	appendFormattedValues(&w.batch, formattedValues)
-	w.batch.WriteString(")")
+	w.batch.WriteString(tuple.String())
 	w.batchPending++
+	w.batchMutations += mutations
 	if w.batchPending >= w.sqlBatchSize() {
 		return w.closePendingBatch()
 	}
@@ -1361,6 +2180,67 @@ func appendFormattedValues(b *strings.Builder, formattedValues []string) {
 	}
 }
 
+// statementPrefix returns the INSERT keyword sequence; with a conflict target the
+// upsert semantics move to the ON CONFLICT clause.
+func (w *SQLInsertWriter) statementPrefix() string {
+	if len(w.conflictTarget) > 0 {
+		return SQLInsert.String()
+	}
+	return w.insertKind.String()
+}
+
+// onConflictClause returns the ON CONFLICT clause for [WithSQLConflictTarget], or ""
+// without one. The clause is cached per registered column list.
+func (w *SQLInsertWriter) onConflictClause() (string, error) {
+	if len(w.conflictTarget) == 0 {
+		return "", nil
+	}
+	if w.conflictClause != "" && w.conflictColumns == w.quotedColumnNames {
+		return w.conflictClause, nil
+	}
+	for _, name := range w.conflictTarget {
+		if !slices.Contains(w.schema.names, name) {
+			return "", fmt.Errorf("%w: %q", ErrMissingKeyColumn, name)
+		}
+	}
+	quotedTarget, err := quoteIdentifiers(w.conflictTarget, w.sqlDialect)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("ON CONFLICT (")
+	b.WriteString(strings.Join(quotedTarget, ", "))
+	b.WriteString(") ")
+	if w.insertKind == SQLInsertOrIgnore {
+		b.WriteString("DO NOTHING")
+	} else {
+		quotedColumns, err := quoteIdentifiers(w.schema.names, w.sqlDialect)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("DO UPDATE SET ")
+		first := true
+		for i, name := range w.schema.names {
+			if slices.Contains(w.conflictTarget, name) || w.omitsColumn(name) {
+				continue
+			}
+			if !first {
+				b.WriteString(", ")
+			}
+			first = false
+			b.WriteString(quotedColumns[i])
+			b.WriteString(" = EXCLUDED.")
+			b.WriteString(quotedColumns[i])
+		}
+		if first {
+			return "", ErrNoUpdateColumns
+		}
+	}
+	w.conflictClause = b.String()
+	w.conflictColumns = w.quotedColumnNames
+	return w.conflictClause, nil
+}
+
 func (w *SQLInsertWriter) setRowType(rowType *sppb.StructType) {
 	w.schema.applyRowType(rowType)
 	w.quotedColumnNames = ""
@@ -1390,7 +2270,7 @@ func (w *SQLInsertWriter) initOrValidateQuotedColumns(columnNames []string) (str
 	if err != nil {
 		return "", err
 	}
-	quotedColumns, err := quoteIdentifiers(names, w.sqlDialect)
+	quotedColumns, err := w.insertColumnList(names)
 	if err != nil {
 		return "", err
 	}
@@ -1398,7 +2278,7 @@ func (w *SQLInsertWriter) initOrValidateQuotedColumns(columnNames []string) (str
 		w.schema.names = names
 	}
 	w.schema.registered = true
-	w.quotedColumnNames = strings.Join(quotedColumns, ", ")
+	w.quotedColumnNames = quotedColumns
 	return w.quotedColumnNames, nil
 }
 
@@ -1110,6 +1110,119 @@ func TestSQLInsertWriterPostgreSQLInsertOrKindsRejected(t *testing.T) {
 	}
 }
 
+func TestSQLInsertWriterConflictTarget(t *testing.T) {
+	t.Parallel()
+
+	columnNames := []string{"id", "name", "age"}
+	row := func(id int64, name string) []spanner.GenericColumnValue {
+		return []spanner.GenericColumnValue{gcvctor.Int64Value(id), gcvctor.StringValue(name), gcvctor.Int64Value(id * 10)}
+	}
+	pg := WithSQLDialect(databasepb.DatabaseDialect_POSTGRESQL)
+
+	tests := []struct {
+		name    string
+		options []SQLInsertOption
+		want    string
+	}{
+		{
+			name:    "PostgreSQL do nothing",
+			options: []SQLInsertOption{pg, WithSQLInsertKind(SQLInsertOrIgnore), WithSQLConflictTarget("id")},
+			want: `INSERT INTO "users" ("id", "name", "age") VALUES (1, "a", 10) ON CONFLICT ("id") DO NOTHING;` + "\n" +
+				`INSERT INTO "users" ("id", "name", "age") VALUES (2, "b", 20) ON CONFLICT ("id") DO NOTHING;` + "\n",
+		},
+		{
+			name:    "PostgreSQL do update batched",
+			options: []SQLInsertOption{pg, WithSQLInsertKind(SQLInsertOrUpdate), WithSQLConflictTarget("id"), WithSQLBatchSize(2)},
+			want: `INSERT INTO "users" ("id", "name", "age") VALUES` + "\n" +
+				`  (1, "a", 10),` + "\n" +
+				`  (2, "b", 20)` + "\n" +
+				`ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "age" = EXCLUDED."age";` + "\n",
+		},
+		{
+			name:    "GoogleSQL composite target",
+			options: []SQLInsertOption{WithSQLInsertKind(SQLInsertOrUpdate), WithSQLConflictTarget("id", "name")},
+			want: "INSERT INTO `users` (`id`, `name`, `age`) VALUES (1, \"a\", 10) ON CONFLICT (`id`, `name`) DO UPDATE SET `age` = EXCLUDED.`age`;\n" +
+				"INSERT INTO `users` (`id`, `name`, `age`) VALUES (2, \"b\", 20) ON CONFLICT (`id`, `name`) DO UPDATE SET `age` = EXCLUDED.`age`;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w := mustNewSQLInsertWriter(t, &out, "users", tt.options...)
+			for _, values := range [][]spanner.GenericColumnValue{row(1, "a"), row(2, "b")} {
+				if err := w.WriteValues(columnNames, values); err != nil {
+					t.Fatalf("WriteValues() error = %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Fatalf("SQL output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLInsertWriterConflictTargetErrors(t *testing.T) {
+	t.Parallel()
+
+	schema := WithColumnNames([]string{"id", "name"})
+	tests := []struct {
+		name    string
+		options []SQLInsertOption
+		want    error
+	}{
+		{
+			name:    "plain insert",
+			options: []SQLInsertOption{WithSQLConflictTarget("id")},
+			want:    ErrConflictTargetWithoutUpsert,
+		},
+		{
+			name:    "empty column",
+			options: []SQLInsertOption{WithSQLInsertKind(SQLInsertOrIgnore), WithSQLConflictTarget("")},
+			want:    ErrEmptyColumnName,
+		},
+		{
+			name:    "missing column",
+			options: []SQLInsertOption{schema, WithSQLInsertKind(SQLInsertOrIgnore), WithSQLConflictTarget("pk")},
+			want:    ErrMissingKeyColumn,
+		},
+		{
+			name:    "nothing to update",
+			options: []SQLInsertOption{schema, WithSQLInsertKind(SQLInsertOrUpdate), WithSQLConflictTarget("id", "name")},
+			want:    ErrNoUpdateColumns,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := NewSQLInsertWriter(&bytes.Buffer{}, "users", tt.options...); !errors.Is(err, tt.want) {
+				t.Fatalf("NewSQLInsertWriter() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing column on first write", func(t *testing.T) {
+		t.Parallel()
+
+		var out bytes.Buffer
+		w := mustNewSQLInsertWriter(t, &out, "users", WithSQLInsertKind(SQLInsertOrIgnore), WithSQLConflictTarget("pk"))
+		err := w.WriteValues([]string{"id"}, []spanner.GenericColumnValue{gcvctor.Int64Value(1)})
+		if !errors.Is(err, ErrMissingKeyColumn) {
+			t.Fatalf("WriteValues() error = %v, want ErrMissingKeyColumn", err)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("WriteValues() wrote %q, want no output", out.String())
+		}
+	})
+}
+
 func TestSQLInsertWriterInsertKind(t *testing.T) {
 	t.Parallel()
 
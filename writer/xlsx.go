@@ -0,0 +1,699 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spanvalue"
+	"github.com/apstndb/spanvalue/internal"
+)
+
+// XLSXOption configures an XLSXWriter created by [NewXLSXWriter].
+type XLSXOption interface {
+	applyXLSXOption(*XLSXWriter) error
+}
+
+type xlsxOptionFunc func(*XLSXWriter) error
+
+func (f xlsxOptionFunc) applyXLSXOption(w *XLSXWriter) error {
+	return f(w)
+}
+
+func applyXLSXOptions(w *XLSXWriter, options ...XLSXOption) error {
+	for _, opt := range options {
+		if opt == nil {
+			continue
+		}
+		if err := opt.applyXLSXOption(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// xlsxMaxRows and xlsxMaxColumns are the worksheet limits of Excel.
+	xlsxMaxRows    = 1 << 20
+	xlsxMaxColumns = 1 << 14
+	// xlsxMaxSheetName is the longest worksheet name Excel accepts.
+	xlsxMaxSheetName = 31
+	// xlsxMaxCellText is the longest text Excel keeps in one cell.
+	xlsxMaxCellText = 32767
+	// xlsxMaxExactInt is the largest magnitude whose digits all survive
+	// Excel's 15 significant digits.
+	xlsxMaxExactInt = 999_999_999_999_999
+)
+
+// WithXLSXSheetName sets the worksheet name (default "Sheet1"). Names must be
+// 1 to 31 characters without any of []:*?/\ and must not start or end with
+// an apostrophe; others return [ErrInvalidXLSXSheetName].
+func WithXLSXSheetName(name string) XLSXOption {
+	return xlsxOptionFunc(func(w *XLSXWriter) error {
+		if n := utf8.RuneCountInString(name); n == 0 || n > xlsxMaxSheetName ||
+			strings.ContainsAny(name, `[]:*?/\`) || strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+			return fmt.Errorf("%w: %q", ErrInvalidXLSXSheetName, name)
+		}
+		w.sheetName = name
+		return nil
+	})
+}
+
+// WithXLSXSheetRows sets the number of data rows per worksheet; rows beyond
+// it continue on a new worksheet with the header repeated. The default and
+// maximum is 1,048,575, the Excel row limit less the header. n == 0 selects
+// the default; negative or larger n returns [ErrInvalidSheetRows].
+func WithXLSXSheetRows(n int) XLSXOption {
+	return xlsxOptionFunc(func(w *XLSXWriter) error {
+		if n < 0 || n > xlsxMaxRows-1 {
+			return fmt.Errorf("%w: %d", ErrInvalidSheetRows, n)
+		}
+		if n == 0 {
+			n = xlsxMaxRows - 1
+		}
+		w.sheetRows = n
+		return nil
+	})
+}
+
+// WithXLSXFreezeHeader sets whether the header row stays visible while
+// scrolling (default true).
+func WithXLSXFreezeHeader(freeze bool) XLSXOption {
+	return xlsxOptionFunc(func(w *XLSXWriter) error {
+		w.freezeHeader = freeze
+		return nil
+	})
+}
+
+// WithXLSXAutoFilter sets whether each worksheet gets filter buttons on the
+// header row (default true).
+func WithXLSXAutoFilter(autoFilter bool) XLSXOption {
+	return xlsxOptionFunc(func(w *XLSXWriter) error {
+		w.autoFilter = autoFilter
+		return nil
+	})
+}
+
+// Cell style indexes into the cellXfs of xlsxStyles.
+const (
+	xlsxStyleHeader    = 1
+	xlsxStyleDate      = 2
+	xlsxStyleTimestamp = 3
+)
+
+// XLSXWriter writes rows as an Excel workbook (Office Open XML
+// SpreadsheetML in a zip file) with typed cells, so spreadsheets keep IDs,
+// dates, and leading zeros intact:
+//
+//   - INT64 up to 15 digits, finite FLOAT32/FLOAT64, and NUMERIC with up to
+//     15 significant digits: number cells; longer values are text, since
+//     Excel keeps only 15 digits
+//   - BOOL: boolean cells
+//   - DATE: date cells formatted yyyy-mm-dd, and TIMESTAMP: date cells in UTC
+//     formatted yyyy-mm-dd hh:mm:ss.000; values before 1900-03-01, which
+//     Excel cannot represent, are text
+//   - other types, including STRING, ARRAY, STRUCT, and JSON: text formatted
+//     with [spanvalue.SpannerCLICompatibleFormatConfig] unless [WithFormatter]
+//     is set, truncated to Excel's limit of 32,767 characters
+//   - NULL: an empty cell
+//
+// The first row of each worksheet holds the column names in bold, frozen by
+// default ([WithXLSXFreezeHeader]) and with filter buttons
+// ([WithXLSXAutoFilter]). Rows beyond the Excel limit, or
+// [WithXLSXSheetRows], continue on worksheets named "Sheet1 (2)" and so on.
+// More than 16,384 columns return [ErrTooManyXLSXColumns].
+//
+// Rows are streamed into the zip as they are written, so memory stays
+// bounded. [XLSXWriter.Flush] finishes the workbook; later Write* calls
+// return [ErrWriterClosed]. A registered zero-column schema writes nothing.
+//
+// After the first output failure, every later Write*/Flush call returns that
+// error; discard the writer (see package doc "Write errors").
+type XLSXWriter struct {
+	stickyWriteError
+	formatter *spanvalue.FormatConfig
+	// unnamedFieldNamer resolves empty column names for the header.
+	// See [WithUnnamedFieldNamer].
+	unnamedFieldNamer spanvalue.UnnamedFieldNamer
+	sheetName         string
+	sheetRows         int
+	freezeHeader      bool
+	autoFilter        bool
+
+	schema columnSchema
+	zip    *zip.Writer
+	// sheet receives the worksheet being written; sheetRowCounts holds the
+	// data rows of each worksheet started so far.
+	sheet          io.Writer
+	sheetRowCounts []int
+	header         []string
+	closed         bool
+	buf            bytes.Buffer
+	out            io.Writer
+}
+
+// NewXLSXWriter returns an XLSX workbook writer configured by options.
+func NewXLSXWriter(out io.Writer, options ...XLSXOption) (*XLSXWriter, error) {
+	if out == nil {
+		return nil, ErrNilOutputWriter
+	}
+	w := &XLSXWriter{
+		formatter:    spanvalue.SpannerCLICompatibleFormatConfig(),
+		sheetName:    "Sheet1",
+		sheetRows:    xlsxMaxRows - 1,
+		freezeHeader: true,
+		autoFilter:   true,
+		out:          out,
+	}
+	if err := applyXLSXOptions(w, options...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteRow writes one worksheet row. Does not require With* or Prepare*; see [DelimitedWriter.WriteRow].
+func (w *XLSXWriter) WriteRow(row *spanner.Row) error {
+	columnNames, values, err := rowData(row)
+	if err != nil {
+		return err
+	}
+	return w.WriteValues(columnNames, values)
+}
+
+// PrepareRowType registers names and field types; see [DelimitedWriter.PrepareRowType].
+// Nil rowType registers an empty schema.
+func (w *XLSXWriter) PrepareRowType(rowType *sppb.StructType) error {
+	rowType = normalizeRowType(rowType)
+	columnNames := columnNamesFromRowType(rowType)
+	if err := validatePrepareRowTypeTransition(&w.schema, columnNames); err != nil {
+		return err
+	}
+	w.setRowType(rowType)
+	return nil
+}
+
+// PrepareColumnNames registers column names; see [DelimitedWriter.PrepareColumnNames].
+func (w *XLSXWriter) PrepareColumnNames(names []string) error {
+	if len(names) == 0 {
+		return ErrMissingColumnNames
+	}
+	if err := w.initOrValidateColumnNames(names); err != nil {
+		return err
+	}
+	w.setColumnNames(names)
+	return nil
+}
+
+// WriteValues writes one worksheet row; see [DelimitedWriter.WriteValues].
+func (w *XLSXWriter) WriteValues(columnNames []string, values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if err := w.initOrValidateColumnNames(columnNames); err != nil {
+		return err
+	}
+	return w.WriteGCVs(values)
+}
+
+// WriteStructValues writes one worksheet row; see [DelimitedWriter.WriteStructValues].
+func (w *XLSXWriter) WriteStructValues(values []*structpb.Value) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	gcvs, err := gcvsFromStructValues(w.schema.types, values)
+	if err != nil {
+		return err
+	}
+	return w.WriteGCVs(gcvs)
+}
+
+// WriteGCVs writes one worksheet row, starting a new worksheet when the
+// current one is full; see [DelimitedWriter.WriteGCVs].
+func (w *XLSXWriter) WriteGCVs(values []spanner.GenericColumnValue) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		if len(values) == 0 {
+			return nil
+		}
+		return ErrMissingColumnNames
+	}
+	if len(values) != len(w.schema.names) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrColumnNamesMismatch, len(values), len(w.schema.names))
+	}
+	if w.zip == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	current := len(w.sheetRowCounts) - 1
+	rowNum := w.sheetRowCounts[current] + 2
+	if w.sheetRowCounts[current] >= w.sheetRows {
+		if err := w.endSheet(); err != nil {
+			return err
+		}
+		if err := w.startSheet(); err != nil {
+			return err
+		}
+		current++
+		rowNum = 2
+	}
+	w.buf.Reset()
+	fmt.Fprintf(&w.buf, `<row r="%d">`, rowNum)
+	for i, v := range values {
+		if err := w.appendCell(&w.buf, xlsxCellRef(i, rowNum), v); err != nil {
+			return fmt.Errorf("column %q: %w", w.header[i], err)
+		}
+	}
+	w.buf.WriteString("</row>")
+	if err := w.writeSheet(w.buf.Bytes()); err != nil {
+		return err
+	}
+	w.sheetRowCounts[current]++
+	return nil
+}
+
+// Flush finishes the workbook: it ends the last worksheet and writes the
+// workbook parts and the zip directory. A registered schema with no rows
+// yields a worksheet with only the header. Flush after that returns nil. With
+// no registered schema, Flush returns [ErrMissingColumnNames]. After a write
+// failure, Flush returns the latched error (see package doc "Write errors").
+func (w *XLSXWriter) Flush() error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return nil
+	}
+	if !w.schema.registered {
+		return ErrMissingColumnNames
+	}
+	if len(w.schema.names) == 0 {
+		return nil
+	}
+	if w.zip == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+	parts := []struct{ name, content string }{
+		{"xl/workbook.xml", w.workbookXML()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRelsXML()},
+		{"xl/styles.xml", xlsxStyles},
+		{"_rels/.rels", xlsxRootRels},
+		{"[Content_Types].xml", w.contentTypesXML()},
+	}
+	for _, part := range parts {
+		f, err := w.zip.Create(part.name)
+		if err != nil {
+			return w.latchWriteErr(err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return w.latchWriteErr(err)
+		}
+	}
+	return w.latchWriteErr(w.zip.Close())
+}
+
+// FormatConfig returns the effective formatter used for text cells.
+// When no formatter is configured, this returns [spanvalue.SpannerCLICompatibleFormatConfig].
+// Configure it only via [NewXLSXWriter] or [WithFormatter].
+func (w *XLSXWriter) FormatConfig() *spanvalue.FormatConfig {
+	if w.formatter == nil {
+		return spanvalue.SpannerCLICompatibleFormatConfig()
+	}
+	return w.formatter
+}
+
+// open resolves the header and starts the zip with the first worksheet.
+func (w *XLSXWriter) open() error {
+	if len(w.schema.names) > xlsxMaxColumns {
+		return fmt.Errorf("%w: %d", ErrTooManyXLSXColumns, len(w.schema.names))
+	}
+	names := w.schema.names
+	if w.unnamedFieldNamer != nil {
+		resolved, err := internal.ResolveColumnNames(names, w.unnamedFieldNamer)
+		if err != nil {
+			return err
+		}
+		names = resolved
+	}
+	w.header = names
+	w.zip = zip.NewWriter(w.out)
+	return w.startSheet()
+}
+
+// startSheet begins the next worksheet part and writes its header row.
+func (w *XLSXWriter) startSheet() error {
+	f, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheetRowCounts)+1))
+	if err != nil {
+		return w.latchWriteErr(err)
+	}
+	w.sheet = f
+	w.sheetRowCounts = append(w.sheetRowCounts, 0)
+
+	w.buf.Reset()
+	w.buf.WriteString(xml.Header)
+	w.buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	if w.freezeHeader {
+		w.buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	w.buf.WriteString(`<sheetData><row r="1">`)
+	for i, name := range w.header {
+		appendXLSXText(&w.buf, xlsxCellRef(i, 1), xlsxStyleHeader, name)
+	}
+	w.buf.WriteString("</row>")
+	return w.writeSheet(w.buf.Bytes())
+}
+
+// endSheet closes the current worksheet part, adding its auto-filter range.
+func (w *XLSXWriter) endSheet() error {
+	w.buf.Reset()
+	w.buf.WriteString("</sheetData>")
+	if w.autoFilter {
+		fmt.Fprintf(&w.buf, `<autoFilter ref="%s"/>`, w.filterRange(len(w.sheetRowCounts)-1, false))
+	}
+	w.buf.WriteString("</worksheet>")
+	return w.writeSheet(w.buf.Bytes())
+}
+
+func (w *XLSXWriter) writeSheet(b []byte) error {
+	_, err := w.sheet.Write(b)
+	return w.latchWriteErr(err)
+}
+
+// filterRange returns the header and data range of worksheet i, as A1:C10
+// or, when absolute, as $A$1:$C$10.
+func (w *XLSXWriter) filterRange(i int, absolute bool) string {
+	last := xlsxColumnName(len(w.header) - 1)
+	rows := w.sheetRowCounts[i] + 1
+	if absolute {
+		return fmt.Sprintf("$A$1:$%s$%d", last, rows)
+	}
+	return fmt.Sprintf("A1:%s%d", last, rows)
+}
+
+// worksheetName returns the name of worksheet i: the configured name, then
+// "name (2)", "name (3)", and so on, shortened to fit 31 characters.
+func (w *XLSXWriter) worksheetName(i int) string {
+	if i == 0 {
+		return w.sheetName
+	}
+	suffix := fmt.Sprintf(" (%d)", i+1)
+	base := []rune(w.sheetName)
+	if n := xlsxMaxSheetName - len(suffix); len(base) > n {
+		base = []rune(strings.TrimRight(string(base[:n]), " "))
+	}
+	return string(base) + suffix
+}
+
+func (w *XLSXWriter) workbookXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i := range w.sheetRowCounts {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(w.worksheetName(i)), i+1, i+1)
+	}
+	b.WriteString("</sheets>")
+	if w.autoFilter {
+		// Excel keeps each auto-filter range in a hidden defined name.
+		b.WriteString("<definedNames>")
+		for i := range w.sheetRowCounts {
+			ref := "'" + strings.ReplaceAll(w.worksheetName(i), "'", "''") + "'!" + w.filterRange(i, true)
+			fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s</definedName>`, i, xlsxEscape(ref))
+		}
+		b.WriteString("</definedNames>")
+	}
+	b.WriteString("</workbook>")
+	return b.String()
+}
+
+func (w *XLSXWriter) workbookRelsXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheetRowCounts {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheetRowCounts)+1)
+	b.WriteString("</Relationships>")
+	return b.String()
+}
+
+func (w *XLSXWriter) contentTypesXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := range w.sheetRowCounts {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	b.WriteString("</Types>")
+	return b.String()
+}
+
+// appendCell appends the cell of v at ref, typed when Excel can hold the
+// value exactly and formatted text otherwise.
+func (w *XLSXWriter) appendCell(b *bytes.Buffer, ref string, v spanner.GenericColumnValue) error {
+	if _, isNull := v.Value.GetKind().(*structpb.Value_NullValue); v.Value == nil || isNull {
+		return nil
+	}
+	switch v.Type.GetCode() {
+	case sppb.TypeCode_BOOL:
+		if bv, ok := v.Value.GetKind().(*structpb.Value_BoolValue); ok {
+			appendXLSXBool(b, ref, bv.BoolValue)
+			return nil
+		}
+	case sppb.TypeCode_INT64:
+		n, err := strconv.ParseInt(v.Value.GetStringValue(), 10, 64)
+		if err != nil {
+			return err
+		}
+		if n >= -xlsxMaxExactInt && n <= xlsxMaxExactInt {
+			appendXLSXNumber(b, ref, 0, strconv.FormatInt(n, 10))
+			return nil
+		}
+	case sppb.TypeCode_FLOAT32, sppb.TypeCode_FLOAT64:
+		f, err := internal.FloatFromWire(v.Value)
+		if err != nil {
+			return err
+		}
+		if !math.IsNaN(f) && !math.IsInf(f, 0) {
+			bitSize := 64
+			if v.Type.GetCode() == sppb.TypeCode_FLOAT32 {
+				bitSize = 32
+			}
+			appendXLSXNumber(b, ref, 0, strconv.FormatFloat(f, 'g', -1, bitSize))
+			return nil
+		}
+	case sppb.TypeCode_NUMERIC:
+		if s := v.Value.GetStringValue(); isXLSXExactDecimal(s) {
+			appendXLSXNumber(b, ref, 0, s)
+			return nil
+		}
+	case sppb.TypeCode_DATE:
+		d, err := civil.ParseDate(v.Value.GetStringValue())
+		if err != nil {
+			return err
+		}
+		if serial, ok := xlsxDateSerial(d); ok {
+			appendXLSXNumber(b, ref, xlsxStyleDate, strconv.Itoa(serial))
+			return nil
+		}
+	case sppb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, v.Value.GetStringValue())
+		if err != nil {
+			return err
+		}
+		t = t.UTC()
+		if serial, ok := xlsxDateSerial(civil.DateOf(t)); ok {
+			dayNanos := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+			f := float64(serial) + float64(dayNanos)/float64(24*time.Hour)
+			appendXLSXNumber(b, ref, xlsxStyleTimestamp, strconv.FormatFloat(f, 'f', -1, 64))
+			return nil
+		}
+	}
+	s, err := w.FormatConfig().FormatToplevelColumn(v)
+	if err != nil {
+		return err
+	}
+	appendXLSXText(b, ref, 0, s)
+	return nil
+}
+
+func appendXLSXBool(b *bytes.Buffer, ref string, v bool) {
+	value := "0"
+	if v {
+		value = "1"
+	}
+	fmt.Fprintf(b, `<c r="%s" t="b"><v>%s</v></c>`, ref, value)
+}
+
+func appendXLSXNumber(b *bytes.Buffer, ref string, style int, value string) {
+	if style != 0 {
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, value)
+		return
+	}
+	fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, value)
+}
+
+// appendXLSXText appends an inline string cell, truncated to the Excel limit.
+func appendXLSXText(b *bytes.Buffer, ref string, style int, s string) {
+	if utf8.RuneCountInString(s) > xlsxMaxCellText {
+		s = string([]rune(s)[:xlsxMaxCellText])
+	}
+	fmt.Fprintf(b, `<c r="%s"`, ref)
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	b.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+	b.WriteString(xlsxEscape(s))
+	b.WriteString("</t></is></c>")
+}
+
+// xlsxEscape escapes s for XML text or attribute content. Characters XML
+// cannot carry become the _xHHHH_ escapes of SpreadsheetML, and text that
+// already looks like such an escape has its underscore escaped as _x005F_.
+func xlsxEscape(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		case r == '_' && isXLSXEscape(s[i:]):
+			b.WriteString("_x005F_")
+		case r < 0x20 && r != '\t' && r != '\n', r == 0xFFFE, r == 0xFFFF:
+			fmt.Fprintf(&b, "_x%04X_", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isXLSXEscape reports whether s starts with a _xHHHH_ escape.
+func isXLSXEscape(s string) bool {
+	if len(s) < 7 || !strings.HasPrefix(s, "_x") || s[6] != '_' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[2:6], 16, 16)
+	return err == nil
+}
+
+// isXLSXExactDecimal reports whether the decimal string s has at most 15
+// significant digits, so an Excel number cell holds it exactly.
+func isXLSXExactDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	intPart, frac, _ := strings.Cut(s, ".")
+	digits := strings.TrimLeft(intPart+strings.TrimRight(frac, "0"), "0")
+	if intPart == "" || len(digits) > 15 {
+		return false
+	}
+	for _, c := range intPart + frac {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// xlsxDateSerial returns the Excel serial day number of d. Excel counts days
+// from 1899-12-30 and treats 1900 as a leap year, so dates before 1900-03-01
+// have no serial that displays correctly.
+func xlsxDateSerial(d civil.Date) (int, bool) {
+	if d.Before(civil.Date{Year: 1900, Month: time.March, Day: 1}) || d.Year > 9999 {
+		return 0, false
+	}
+	return d.DaysSince(civil.Date{Year: 1899, Month: time.December, Day: 30}), true
+}
+
+// xlsxCellRef returns the A1 reference of a zero-based column and a row number.
+func xlsxCellRef(col, row int) string {
+	return xlsxColumnName(col) + strconv.Itoa(row)
+}
+
+// xlsxColumnName returns the letters of a zero-based column: A, ..., Z, AA, ....
+func xlsxColumnName(col int) string {
+	var name []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cell styles: 0 default, 1 bold header, 2 date, and
+// 3 timestamp (see the xlsxStyle constants).
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss.000"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func (w *XLSXWriter) setRowType(rowType *sppb.StructType) {
+	w.schema.applyRowType(rowType)
+}
+
+func (w *XLSXWriter) setColumnNames(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	w.schema.applyNamesOnly(names)
+}
+
+func (w *XLSXWriter) initOrValidateColumnNames(columnNames []string) error {
+	if err := initOrValidateColumnNames(&w.schema, columnNames); err != nil {
+		return err
+	}
+	if len(w.schema.names) > 0 {
+		w.schema.registered = true
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spanvalue/gcvctor"
+)
+
+var _ RowIteratorWriter = (*XLSXWriter)(nil)
+
+// xlsxCell is a parsed worksheet cell rendered as "ref[:t][:s]=value".
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Style  string `xml:"s,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+func (c xlsxCell) String() string {
+	s := c.Ref
+	if c.Type != "" {
+		s += ":" + c.Type
+	}
+	if c.Style != "" {
+		s += ":s" + c.Style
+	}
+	if c.Type == "inlineStr" {
+		return s + "=" + c.Inline
+	}
+	return s + "=" + c.Value
+}
+
+type xlsxSheet struct {
+	Pane *struct {
+		State string `xml:"state,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Rows []struct {
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter *struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+// readXLSX returns the parts of a workbook by name.
+func readXLSX(t *testing.T, b []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = data
+	}
+	return parts
+}
+
+func parseXLSXSheet(t *testing.T, data []byte) xlsxSheet {
+	t.Helper()
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		t.Fatal(err)
+	}
+	return sheet
+}
+
+// xlsxSheetCells renders each row of a worksheet as xlsxCell strings.
+func xlsxSheetCells(sheet xlsxSheet) [][]string {
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var cells []string
+		for _, c := range row.Cells {
+			cells = append(cells, c.String())
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+func TestXLSXWriter_cells(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"id", "big", "f", "num", "b", "d", "ts", "s", "arr"}
+	rows := [][]spanner.GenericColumnValue{
+		{
+			gcvctor.Int64Value(123),
+			gcvctor.Int64Value(1234567890123456789),
+			gcvctor.Float64Value(1.5),
+			gcvctor.NumericValue(big.NewRat(-12345, 100)),
+			gcvctor.BoolValue(true),
+			gcvctor.MustDateStringValue("2024-01-02"),
+			gcvctor.TimestampValue(time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)),
+			gcvctor.StringValue("007 <a&b>"),
+			gcvctor.MustArrayValue(gcvctor.Int64Value(1), gcvctor.Int64Value(2)),
+		},
+		{
+			gcvctor.NullFromCode(sppb.TypeCode_INT64),
+			gcvctor.Int64Value(-999999999999999),
+			gcvctor.Float64Value(math.Inf(1)),
+			gcvctor.MustNumericValueChecked(big.NewRat(1234567890123456789, 1000000000)),
+			gcvctor.BoolValue(false),
+			gcvctor.MustDateStringValue("1899-01-01"),
+			gcvctor.NullFromCode(sppb.TypeCode_TIMESTAMP),
+			gcvctor.StringValue("a\x01_x0041_"),
+			gcvctor.NullArrayFromCode(sppb.TypeCode_INT64),
+		},
+	}
+
+	var out bytes.Buffer
+	w := mustNewXLSXWriter(t, &out)
+	for _, row := range rows {
+		if err := w.WriteValues(names, row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := readXLSX(t, out.Bytes())
+	sheet := parseXLSXSheet(t, parts["xl/worksheets/sheet1.xml"])
+	want := [][]string{
+		{
+			"A1:inlineStr:s1=id", "B1:inlineStr:s1=big", "C1:inlineStr:s1=f", "D1:inlineStr:s1=num", "E1:inlineStr:s1=b",
+			"F1:inlineStr:s1=d", "G1:inlineStr:s1=ts", "H1:inlineStr:s1=s", "I1:inlineStr:s1=arr",
+		},
+		{
+			"A2=123", "B2:inlineStr=1234567890123456789", "C2=1.5", "D2=-123.450000000", "E2:b=1",
+			"F2:s2=45293", "G2:s3=45293.5", "H2:inlineStr=007 <a&b>", "I2:inlineStr=[1, 2]",
+		},
+		{
+			"B3=-999999999999999", "C3:inlineStr=+Inf", "D3:inlineStr=1234567890.123456789", "E3:b=0",
+			"F3:inlineStr=1899-01-01", "H3:inlineStr=a_x0001__x005F_x0041_",
+		},
+	}
+	if diff := cmp.Diff(want, xlsxSheetCells(sheet)); diff != "" {
+		t.Errorf("cells mismatch (-want +got):\n%s", diff)
+	}
+	if sheet.Pane == nil || sheet.Pane.State != "frozen" {
+		t.Errorf("pane = %+v, want frozen", sheet.Pane)
+	}
+	if sheet.AutoFilter == nil || sheet.AutoFilter.Ref != "A1:I3" {
+		t.Errorf("autoFilter = %+v, want A1:I3", sheet.AutoFilter)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+}
+
+func TestXLSXWriter_sheets(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := mustNewXLSXWriter(t, &out,
+		WithXLSXSheetName("It's a very long worksheet name"),
+		WithXLSXSheetRows(2),
+		WithXLSXFreezeHeader(false),
+	)
+	names := []string{"id", "name"}
+	md := &sppb.ResultSetMetadata{RowType: tableTestRowType()}
+	var values []*spanner.Row
+	for i := range 5 {
+		values = append(values, mustNewSpannerRow(t, names, []any{int64(i), "x"}))
+	}
+	if _, err := WriteRowSeq(md, RowSeq(values...), w); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := readXLSX(t, out.Bytes())
+	var got [][][]string
+	for _, name := range []string{"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml"} {
+		sheet := parseXLSXSheet(t, parts[name])
+		if sheet.Pane != nil {
+			t.Errorf("%s: pane = %+v, want none", name, sheet.Pane)
+		}
+		got = append(got, xlsxSheetCells(sheet))
+	}
+	header := []string{"A1:inlineStr:s1=id", "B1:inlineStr:s1=name"}
+	want := [][][]string{
+		{header, {"A2=0", "B2:inlineStr=x"}, {"A3=1", "B3:inlineStr=x"}},
+		{header, {"A2=2", "B2:inlineStr=x"}, {"A3=3", "B3:inlineStr=x"}},
+		{header, {"A2=4", "B2:inlineStr=x"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sheets mismatch (-want +got):\n%s", diff)
+	}
+
+	workbook := string(parts["xl/workbook.xml"])
+	for _, s := range []string{
+		`<sheet name="It's a very long worksheet name" sheetId="1" r:id="rId1"/>`,
+		`<sheet name="It's a very long worksheet (2)" sheetId="2" r:id="rId2"/>`,
+		`localSheetId="2" hidden="1">'It''s a very long worksheet (3)'!$A$1:$B$2</definedName>`,
+	} {
+		if !strings.Contains(workbook, s) {
+			t.Errorf("workbook.xml lacks %s:\n%s", s, workbook)
+		}
+	}
+
+	if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(9), gcvctor.StringValue("z")}); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WriteGCVs after Flush error = %v, want ErrWriterClosed", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("second Flush error = %v, want nil", err)
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	t.Parallel()
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", xlsxMaxColumns - 1: "XFD"} {
+		if got := xlsxColumnName(col); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestXLSXWriter_errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewXLSXWriter(nil); !errors.Is(err, ErrNilOutputWriter) {
+		t.Errorf("NewXLSXWriter(nil) error = %v, want ErrNilOutputWriter", err)
+	}
+	for _, name := range []string{"", "a/b", "'quoted'", strings.Repeat("x", 32)} {
+		if _, err := NewXLSXWriter(&bytes.Buffer{}, WithXLSXSheetName(name)); !errors.Is(err, ErrInvalidXLSXSheetName) {
+			t.Errorf("WithXLSXSheetName(%q) error = %v, want ErrInvalidXLSXSheetName", name, err)
+		}
+	}
+	for _, n := range []int{-1, xlsxMaxRows} {
+		if _, err := NewXLSXWriter(&bytes.Buffer{}, WithXLSXSheetRows(n)); !errors.Is(err, ErrInvalidSheetRows) {
+			t.Errorf("WithXLSXSheetRows(%d) error = %v, want ErrInvalidSheetRows", n, err)
+		}
+	}
+
+	t.Run("lifecycle", func(t *testing.T) {
+		t.Parallel()
+		w := mustNewXLSXWriter(t, &bytes.Buffer{})
+		if err := w.Flush(); !errors.Is(err, ErrMissingColumnNames) {
+			t.Errorf("Flush without schema error = %v, want ErrMissingColumnNames", err)
+		}
+		w = mustNewXLSXWriter(t, &bytes.Buffer{}, WithColumnNames(make([]string, xlsxMaxColumns+1)))
+		if err := w.Flush(); !errors.Is(err, ErrTooManyXLSXColumns) {
+			t.Errorf("wide schema error = %v, want ErrTooManyXLSXColumns", err)
+		}
+
+		var out bytes.Buffer
+		w = mustNewXLSXWriter(t, &out, WithColumnNames([]string{"id"}), WithXLSXAutoFilter(false))
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		sheet := parseXLSXSheet(t, readXLSX(t, out.Bytes())["xl/worksheets/sheet1.xml"])
+		if diff := cmp.Diff([][]string{{"A1:inlineStr:s1=id"}}, xlsxSheetCells(sheet)); diff != "" {
+			t.Errorf("header-only sheet mismatch (-want +got):\n%s", diff)
+		}
+		if sheet.AutoFilter != nil {
+			t.Errorf("autoFilter = %+v, want none", sheet.AutoFilter)
+		}
+
+		out.Reset()
+		w = mustNewXLSXWriter(t, &out, WithRowType(nil))
+		if err := w.WriteGCVs(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("zero-column output = %q, want empty", out.String())
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		fw := &failNthWrite{n: 1}
+		w := mustNewXLSXWriter(t, fw, WithRowType(tableTestRowType()))
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(1), gcvctor.StringValue("a")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Fatalf("Flush error = %v, want errInjected", err)
+		}
+		if err := w.WriteGCVs([]spanner.GenericColumnValue{gcvctor.Int64Value(2), gcvctor.StringValue("b")}); !errors.Is(err, errInjected) {
+			t.Errorf("WriteGCVs after failure error = %v, want errInjected", err)
+		}
+		if err := w.Flush(); !errors.Is(err, errInjected) {
+			t.Errorf("second Flush error = %v, want errInjected", err)
+		}
+	})
+}